@@ -0,0 +1,148 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Options sends OPTIONS to host+endpoint and returns the methods listed in the Allow
+// header, upper-cased, deduplicated and sorted, with the response. The test fails
+// unless the status is 200 or 204 and the response has an Allow header.
+func (b *Builder) Options(t *testing.T, ctx context.Context, host, endpoint string, opts ...Option) ([]string, *http.Response) {
+	t.Helper()
+
+	return b.With(opts...).options(t, ctx, host+endpoint)
+}
+
+func (b *Builder) options(t *testing.T, ctx context.Context, target string) ([]string, *http.Response) {
+	t.Helper()
+
+	response, _ := b.Send(t, ctx, NewSpec(http.MethodOptions, target))
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNoContent {
+		b.require.Failf("unexpected status", "expected 200 or 204 for OPTIONS\n%s", b.describe(response))
+	}
+
+	return b.allowed(response), response
+}
+
+// ExpectAllow fails the test unless the response's Allow header lists exactly methods,
+// in any order, reporting missing and unexpected methods.
+func (b *Builder) ExpectAllow(t *testing.T, response *http.Response, methods ...string) {
+	t.Helper()
+
+	got := b.allowed(response)
+	want := normalizeMethods(methods)
+
+	var missing, unexpected []string
+	for _, m := range want {
+		if !slices.Contains(got, m) {
+			missing = append(missing, m)
+		}
+	}
+	for _, m := range got {
+		if !slices.Contains(want, m) {
+			unexpected = append(unexpected, m)
+		}
+	}
+
+	if len(missing) != 0 || len(unexpected) != 0 {
+		b.require.Failf("unexpected Allow", "expected %s, got %s\nmissing: %s\nunexpected: %s\n%s",
+			strings.Join(want, ", "), strings.Join(got, ", "),
+			strings.Join(missing, ", "), strings.Join(unexpected, ", "), b.describe(response))
+	}
+}
+
+// ExpectMethodNotAllowed fails the test unless the response is 405 Method Not Allowed
+// with the Allow header RFC 9110 requires.
+func (b *Builder) ExpectMethodNotAllowed(t *testing.T, response *http.Response) {
+	t.Helper()
+
+	b.ExpectStatus(t, response, http.StatusMethodNotAllowed)
+	if _, ok := response.Header["Allow"]; !ok {
+		b.require.Failf("missing Allow header", "405 responses must list the allowed methods\n%s", b.describe(response))
+	}
+}
+
+// SweepAllow checks the Allow header of every path defined with Define in a subtest:
+// OPTIONS must list the methods of the endpoints sharing the path plus implicit ones,
+// typically "OPTIONS" and "HEAD". {name} placeholders are filled from pathParams.
+func (b *Builder) SweepAllow(t *testing.T, ctx context.Context, pathParams map[string]string, implicit ...string) {
+	t.Helper()
+
+	b.endpoints.mu.RLock()
+	names := make([]string, 0, len(b.endpoints.specs))
+	for name := range b.endpoints.specs {
+		names = append(names, name)
+	}
+	b.endpoints.mu.RUnlock()
+
+	methods := map[string][]string{}
+	for _, name := range names {
+		endpoint := b.resolve(name)
+		if endpoint.Path == "" {
+			continue
+		}
+		method := endpoint.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		methods[endpoint.Path] = append(methods[endpoint.Path], method)
+	}
+
+	paths := make([]string, 0, len(methods))
+	for path := range methods {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			sub := b.forTest(t)
+
+			target := pathParam.ReplaceAllStringFunc(path, func(placeholder string) string {
+				value, ok := pathParams[placeholder[1:len(placeholder)-1]]
+				sub.require.Truef(ok, "no value for %s in %s", placeholder, path)
+				return url.PathEscape(value)
+			})
+
+			_, response := sub.options(t, ctx, target)
+			sub.ExpectAllow(t, response, append(methods[path], implicit...)...)
+		})
+	}
+}
+
+// allowed returns the normalized methods of the response's Allow header, failing the
+// test if there is none. An empty Allow header is valid and lists no methods.
+func (b *Builder) allowed(response *http.Response) []string {
+	b.require.NotNil(response, "no response")
+
+	values, ok := response.Header["Allow"]
+	if !ok {
+		b.require.Failf("missing Allow header", "%s", b.describe(response))
+	}
+
+	var methods []string
+	for _, value := range values {
+		methods = append(methods, strings.Split(value, ",")...)
+	}
+
+	return normalizeMethods(methods)
+}
+
+// normalizeMethods trims, upper-cases, deduplicates and sorts methods.
+func normalizeMethods(methods []string) []string {
+	normalized := make([]string, 0, len(methods))
+	for _, m := range methods {
+		if m = strings.ToUpper(strings.TrimSpace(m)); m != "" {
+			normalized = append(normalized, m)
+		}
+	}
+	sort.Strings(normalized)
+
+	return slices.Compact(normalized)
+}
@@ -0,0 +1,77 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// allowServer answers OPTIONS with status and, unless allow is nil, the Allow header.
+func allowServer(t *testing.T, status int, allow []string) string {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodOptions, r.Method)
+		if allow != nil {
+			w.Header()["Allow"] = allow
+		}
+		w.WriteHeader(status)
+	})
+
+	return server.URL
+}
+
+func TestOptions(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusNoContent} {
+		host := allowServer(t, status, []string{"get, post", "OPTIONS,GET"})
+
+		b := New(require.New(t))
+		methods, response := b.Options(t, context.Background(), host, "/users")
+
+		require.Equal(t, []string{"GET", "OPTIONS", "POST"}, methods)
+		b.ExpectAllow(t, response, "post", "OPTIONS", "GET")
+	}
+}
+
+func TestOptionsFailures(t *testing.T) {
+	msg := failure(t, nil, func(b *Builder) {
+		_, response := b.Options(t, context.Background(), allowServer(t, http.StatusOK, []string{"GET, PATCH"}), "/users")
+		b.ExpectAllow(t, response, "GET", "POST", "DELETE")
+	})
+	require.Contains(t, msg, "expected DELETE, GET, POST, got GET, PATCH")
+	require.Contains(t, msg, "missing: DELETE, POST")
+	require.Contains(t, msg, "unexpected: PATCH")
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.Options(t, context.Background(), allowServer(t, http.StatusOK, nil), "/users")
+	})
+	require.Contains(t, msg, "missing Allow header")
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.Options(t, context.Background(), allowServer(t, http.StatusNotFound, []string{"GET"}), "/users")
+	})
+	require.Contains(t, msg, "expected 200 or 204 for OPTIONS")
+}
+
+func TestSweepAllow(t *testing.T) {
+	allow := map[string]string{
+		"/users":     "GET, POST, OPTIONS, HEAD",
+		"/users/ann": "GET, DELETE, OPTIONS, HEAD",
+	}
+	var swept []string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		swept = append(swept, r.URL.Path)
+		w.Header().Set("Allow", allow[r.URL.Path])
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	b := New(require.New(t), WithBaseURL(server.URL))
+	b.Define("ListUsers", EndpointSpec{Method: http.MethodGet, Path: "/users"})
+	b.Define("CreateUser", EndpointSpec{Method: http.MethodPost, Path: "/users"})
+	b.Define("GetUser", EndpointSpec{Path: "/users/{id}"})
+	b.Define("DeleteUser", EndpointSpec{Method: http.MethodDelete, Path: "/users/{id}"})
+
+	b.SweepAllow(t, context.Background(), map[string]string{"id": "ann"}, "OPTIONS", "HEAD")
+
+	require.Equal(t, []string{"/users", "/users/ann"}, swept)
+}
@@ -0,0 +1,70 @@
+package reqbuilder
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// APIErr is an error returned by the API in the {"error":{"code":"...","message":"..."}}
+// envelope. Raw holds the body, which is all there is when it is not an envelope.
+type APIErr struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Raw        string
+}
+
+func (e *APIErr) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("status %d: %s", e.StatusCode, e.Raw)
+	}
+
+	return fmt.Sprintf("status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// APIError extracts the error from a non-2xx response. ok is false for 2xx responses.
+// A body that is not an error envelope, such as a plain-text error, is returned with
+// only StatusCode and Raw set.
+func (b *Builder) APIError(response *http.Response) (apiErr *APIErr, ok bool) {
+	if response == nil || response.StatusCode/100 == 2 {
+		return nil, false
+	}
+
+	body, err := b.decodedBody(response)
+	if err != nil {
+		return &APIErr{StatusCode: response.StatusCode, Raw: err.Error()}, true
+	}
+
+	return parseAPIErr(response.StatusCode, body), true
+}
+
+// parseAPIErr returns the APIErr for an error response body, with Code and Message
+// set when the body is an error envelope.
+func parseAPIErr(status int, body []byte) *APIErr {
+	apiErr := &APIErr{StatusCode: status, Raw: string(body)}
+
+	var envelope struct {
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error != nil {
+		apiErr.Code = envelope.Error.Code
+		apiErr.Message = envelope.Error.Message
+	}
+
+	return apiErr
+}
+
+// RequireAPIError fails the test unless the response is an error whose envelope carries code.
+func (b *Builder) RequireAPIError(response *http.Response, code string) {
+	apiErr, ok := b.APIError(response)
+	if !ok {
+		b.require.Failf("expected an API error", "expected error code %q\n%s", code, b.describe(response))
+	}
+	if apiErr.Code != code {
+		b.require.Failf("unexpected API error", "expected error code %q, got %v\n%s", code, apiErr, b.describe(response))
+	}
+}
@@ -0,0 +1,52 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/envelope":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"error":{"code":"VALIDATION_FAILED","message":"name is required"}}`))
+		case "/plain":
+			http.Error(w, "upstream exploded", http.StatusBadGateway)
+		}
+	})
+	b := New(require.New(t))
+
+	response, _ := b.Send(t, context.Background(), Get(server.URL+"/envelope"))
+	apiErr, ok := b.APIError(response)
+	require.True(t, ok)
+	require.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	require.Equal(t, "VALIDATION_FAILED", apiErr.Code)
+	require.Equal(t, "name is required", apiErr.Message)
+	require.EqualError(t, apiErr, "status 422: VALIDATION_FAILED: name is required")
+	b.RequireAPIError(response, "VALIDATION_FAILED")
+
+	response, _ = b.Send(t, context.Background(), Get(server.URL+"/plain"))
+	apiErr, ok = b.APIError(response)
+	require.True(t, ok)
+	require.Empty(t, apiErr.Code)
+	require.Equal(t, "upstream exploded\n", apiErr.Raw)
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.RequireAPIError(response, "VALIDATION_FAILED")
+	})
+	require.Contains(t, msg, `expected error code "VALIDATION_FAILED", got status 502: upstream exploded`)
+
+	response, _ = b.Send(t, context.Background(), Get(server.URL+"/ok"))
+	_, ok = b.APIError(response)
+	require.False(t, ok)
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.RequireAPIError(response, "VALIDATION_FAILED")
+	})
+	require.Contains(t, msg, "expected an API error")
+}
@@ -0,0 +1,196 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"testing"
+	"unicode/utf8"
+)
+
+// maxExcerpt is how much of a decoded body failure messages include.
+const maxExcerpt = 2048
+
+// describe summarizes a response for failure messages: method, final URL, status and
+// the start of the decoded body.
+func (b *Builder) describe(response *http.Response) string {
+	if response == nil {
+		return "no response"
+	}
+
+	method, target := "", ""
+	if response.Request != nil {
+		method = response.Request.Method
+		target = response.Request.URL.String()
+	}
+
+	body, err := b.decodedBody(response)
+	if err != nil {
+		return fmt.Sprintf("%s %s -> %s (reading body: %v)", method, target, response.Status, err)
+	}
+
+	description := fmt.Sprintf("%s %s -> %s\nbody: %s", method, target, response.Status, b.bodyExcerpt(response, body))
+
+	if b.reproOnFailure && response.Request != nil {
+		var sent []byte
+		if meta := metaOf(response); meta != nil {
+			sent = meta.body
+		}
+		description += "\nreproducer:\n" + AsGoSource(response.Request, sent)
+	}
+
+	return description
+}
+
+// WithFailureBodyLimit sets how many bytes of a response body failure messages show,
+// 2048 by default.
+func WithFailureBodyLimit(n int) Option {
+	return func(b *Builder) {
+		b.require.Positivef(n, "failure body limit must be positive, got %d", n)
+		b.failureBodyLimit = n
+	}
+}
+
+// bodyExcerpt formats a decoded response body for a failure message: JSON indented
+// on lines of its own, anything else as excerpt does, both cut at the failure body
+// limit.
+func (b *Builder) bodyExcerpt(response *http.Response, body []byte) string {
+	limit := b.failureBodyLimit
+	if limit == 0 {
+		limit = maxExcerpt
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if isJSONMediaType(mediaType) {
+		var indented bytes.Buffer
+		if json.Indent(&indented, body, "", "  ") == nil {
+			return "\n" + excerpt(indented.Bytes(), limit)
+		}
+	}
+
+	return excerpt(body, limit)
+}
+
+// excerpt returns at most max bytes of body as text, or as a hex dump for binary bodies.
+func excerpt(body []byte, max int) string {
+	more := ""
+	if len(body) > max {
+		more = fmt.Sprintf("... (%d more bytes)", len(body)-max)
+		body = body[:max]
+	}
+
+	if isBinary(body) {
+		return "\n" + hex.Dump(body) + more
+	}
+
+	return string(body) + more
+}
+
+// isBinary reports whether body is not printable text.
+func isBinary(body []byte) bool {
+	// A cut in the middle of a multi-byte rune must not count as binary.
+	trimmed := body
+	for i := 0; i < utf8.UTFMax && len(trimmed) > 0 && !utf8.Valid(trimmed); i++ {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+
+	if !utf8.Valid(trimmed) {
+		return true
+	}
+
+	for _, r := range string(trimmed) {
+		if r < 0x20 && r != '\n' && r != '\r' && r != '\t' {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExpectStatus fails the test unless the response has the given status code.
+func (b *Builder) ExpectStatus(t *testing.T, response *http.Response, code int) {
+	t.Helper()
+
+	b.require.NotNil(response, "no response")
+	if response.StatusCode != code {
+		b.require.Failf("unexpected status", "expected %d\n%s", code, b.describe(response))
+	}
+}
+
+// ExpectSuccess fails the test unless the response status is 2xx.
+func (b *Builder) ExpectSuccess(t *testing.T, response *http.Response) {
+	t.Helper()
+
+	b.expectClass(response, 200, "success (2xx)")
+}
+
+// ExpectClientError fails the test unless the response status is 4xx.
+func (b *Builder) ExpectClientError(t *testing.T, response *http.Response) {
+	t.Helper()
+
+	b.expectClass(response, 400, "client error (4xx)")
+}
+
+// ExpectServerError fails the test unless the response status is 5xx.
+func (b *Builder) ExpectServerError(t *testing.T, response *http.Response) {
+	t.Helper()
+
+	b.expectClass(response, 500, "server error (5xx)")
+}
+
+// expectClass fails unless the response status is within [class, class+100).
+func (b *Builder) expectClass(response *http.Response, class int, name string) {
+	b.require.NotNil(response, "no response")
+	if response.StatusCode < class || response.StatusCode >= class+100 {
+		b.require.Failf("unexpected status", "expected %s\n%s", name, b.describe(response))
+	}
+}
+
+// ExpectRedirectTo fails the test unless the response is a 3xx redirect whose Location,
+// resolved against the request URL, equals location resolved the same way.
+// Redirects are only observable when the Builder does not follow them, see WithoutRedirects.
+func (b *Builder) ExpectRedirectTo(t *testing.T, response *http.Response, location string) {
+	t.Helper()
+
+	b.expectClass(response, 300, "redirect (3xx)")
+
+	header := response.Header.Get("Location")
+	if header == "" {
+		b.require.Failf("missing Location header", "%s", b.describe(response))
+	}
+
+	base := &url.URL{}
+	if response.Request != nil {
+		base = response.Request.URL
+	}
+
+	got, err := base.Parse(header)
+	b.require.NoErrorf(err, "invalid Location header %q", header)
+
+	want, err := base.Parse(location)
+	b.require.NoErrorf(err, "invalid expected location %q", location)
+
+	if got.String() != want.String() {
+		b.require.Failf("unexpected redirect target", "expected %s, got %s\n%s", want, got, b.describe(response))
+	}
+}
+
+// RequireSuccess fails the test unless the response status is 2xx. It is ExpectSuccess
+// for callers without a *testing.T at hand.
+func (b *Builder) RequireSuccess(response *http.Response) {
+	b.expectClass(response, 200, "success (2xx)")
+}
+
+// RequireClientError fails the test unless the response status is 4xx.
+func (b *Builder) RequireClientError(response *http.Response) {
+	b.expectClass(response, 400, "client error (4xx)")
+}
+
+// RequireServerError fails the test unless the response status is 5xx.
+func (b *Builder) RequireServerError(response *http.Response) {
+	b.expectClass(response, 500, "server error (5xx)")
+}
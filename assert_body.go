@@ -0,0 +1,125 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// ExpectBodyContains fails the test unless the decoded body contains substr. On failure
+// it reports where the longest matching prefix of substr was found.
+func (b *Builder) ExpectBodyContains(t *testing.T, response *http.Response, substr string) {
+	t.Helper()
+
+	body := b.requireBody(response)
+	if bytes.Contains(body, []byte(substr)) {
+		return
+	}
+
+	b.require.Failf("body does not contain expected text", "expected %q\n%s\n%s",
+		substr, nearestMatch(body, substr), b.describe(response))
+}
+
+// ExpectBodyMatches fails the test unless the decoded body matches pattern, which is
+// either a string or a precompiled *regexp.Regexp.
+func (b *Builder) ExpectBodyMatches(t *testing.T, response *http.Response, pattern any) {
+	t.Helper()
+
+	b.expectBodyMatches(response, pattern)
+}
+
+// RequireBodyMatches fails the test unless the decoded body matches pattern. It is
+// ExpectBodyMatches for callers without a *testing.T at hand; use the (?m) and (?s)
+// flags for multiline matching.
+func (b *Builder) RequireBodyMatches(response *http.Response, pattern string) {
+	b.expectBodyMatches(response, pattern)
+}
+
+// expectBodyMatches fails unless the decoded body matches pattern.
+func (b *Builder) expectBodyMatches(response *http.Response, pattern any) {
+	var re *regexp.Regexp
+
+	switch p := pattern.(type) {
+	case *regexp.Regexp:
+		re = p
+	case string:
+		var err error
+		re, err = regexp.Compile(p)
+		b.require.NoErrorf(err, "invalid pattern %q", p)
+	default:
+		b.require.Failf("invalid pattern", "expected a string or *regexp.Regexp, got %T", pattern)
+	}
+
+	body := b.requireBody(response)
+	if !re.Match(body) {
+		b.require.Failf("body does not match pattern", "pattern %s\n%s", re, b.describe(response))
+	}
+}
+
+// ExpectBodyLen fails the test unless the decoded body is exactly n bytes long.
+func (b *Builder) ExpectBodyLen(t *testing.T, response *http.Response, n int) {
+	t.Helper()
+
+	body := b.requireBody(response)
+	if len(body) != n {
+		b.require.Failf("unexpected body length", "expected %d bytes, got %d\n%s", n, len(body), b.describe(response))
+	}
+}
+
+// requireBody returns the decoded body, leaving it readable for the caller, and fails the test on read errors.
+func (b *Builder) requireBody(response *http.Response) []byte {
+	b.require.NotNil(response, "no response")
+
+	body, err := b.decodedBody(response)
+	b.require.NoError(err, "reading response body")
+
+	return body
+}
+
+// nearestMatch describes where the longest prefix of substr occurs in body.
+func nearestMatch(body []byte, substr string) string {
+	for n := len(substr) - 1; n > 0; n-- {
+		idx := bytes.Index(body, []byte(substr[:n]))
+		if idx < 0 {
+			continue
+		}
+
+		end := min(idx+n+40, len(body))
+
+		return fmt.Sprintf("longest partial match %q at offset %d, followed by %q",
+			substr[:n], idx, body[idx+n:end])
+	}
+
+	return "no partial match"
+}
+
+// ExpectBodySHA256 fails the test unless the SHA-256 of the decoded body is hexDigest.
+// The body is hashed while streaming, so large downloads are not held in memory; it is
+// consumed unless buffered, see WithBodyReplay.
+func (b *Builder) ExpectBodySHA256(t *testing.T, response *http.Response, hexDigest string) {
+	t.Helper()
+
+	reader, err := b.BodyReader(response)
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+	defer reader.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(hash, reader)
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	if got := hex.EncodeToString(hash.Sum(nil)); got != strings.ToLower(hexDigest) {
+		b.require.Failf("unexpected body digest", "expected sha256 %s, got %s over %d bytes", hexDigest, got, n)
+	}
+}
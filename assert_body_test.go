@@ -0,0 +1,120 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyAssertions(t *testing.T) {
+	encoded, err := EncodeGzip([]byte(`{"user":{"name":"ada","id":42}}`))
+	require.NoError(t, err)
+
+	hits := 0
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(encoded)
+	})
+
+	b := New(require.New(t), WithDisableCompression())
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	b.ExpectBodyContains(t, response, `"name":"ada"`)
+	b.ExpectBodyMatches(t, response, `"id":\d+`)
+	b.ExpectBodyMatches(t, response, regexp.MustCompile(`^\{"user"`))
+	b.ExpectBodyLen(t, response, 31)
+
+	var v struct{ User struct{ Name string } }
+	require.NoError(t, b.DecodeJSON(response, &v))
+	require.Equal(t, "ada", v.User.Name)
+	require.Equal(t, 1, hits, "assertions share one read of the body")
+
+	msg := failure(t, nil, func(fb *Builder) {
+		fb.ExpectBodyContains(t, response, `"name":"bob"`)
+	})
+	require.Contains(t, msg, `longest partial match "\"name\":\"" at offset 9, followed by "ada\",\"id\":42}}"`)
+
+	msg = failure(t, nil, func(fb *Builder) {
+		fb.ExpectBodyMatches(t, response, `"id":"\d+"`)
+	})
+	require.Contains(t, msg, "body does not match pattern")
+
+	msg = failure(t, nil, func(fb *Builder) {
+		fb.ExpectBodyLen(t, response, 10)
+	})
+	require.Contains(t, msg, "expected 10 bytes, got 31")
+}
+
+func TestBodyAssertionBinaryExcerpt(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte{0x00, 0x01, 0xfe, 'a', 'b'})
+	})
+
+	response, _ := New(require.New(t)).Send(t, context.Background(), Get(server.URL))
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.ExpectBodyContains(t, response, "abc")
+	})
+	require.Contains(t, msg, "00000000  00 01 fe 61 62")
+}
+
+func TestRequireBodyMatches(t *testing.T) {
+	page := "<html>\n<title>Reset password</title>\n<p>Hello ada</p>\n</html>"
+	encoded, err := EncodeBrotli([]byte(page))
+	require.NoError(t, err)
+
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Encoding", "br")
+		w.Write(encoded)
+	})
+
+	b := New(require.New(t), WithDisableCompression())
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	b.RequireBodyMatches(response, `<title>Reset password</title>`)
+	b.RequireBodyMatches(response, `(?m)^<p>Hello \w+</p>$`)
+
+	msg := failure(t, nil, func(fb *Builder) {
+		fb.RequireBodyMatches(response, `^<p>Hello \w+</p>$`)
+	})
+	require.Contains(t, msg, "body does not match pattern")
+	require.Contains(t, msg, `pattern ^<p>Hello \w+</p>$`)
+	require.Contains(t, msg, "<title>Reset password</title>", "the failure shows the decoded body")
+
+	msg = failure(t, nil, func(fb *Builder) {
+		fb.RequireBodyMatches(response, `(unclosed`)
+	})
+	require.Contains(t, msg, `invalid pattern "(unclosed"`)
+}
+
+func TestExpectBodySHA256(t *testing.T) {
+	content := bytes.Repeat([]byte("firmware"), 1<<14)
+	encoded, err := EncodeGzip(content)
+	require.NoError(t, err)
+
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(encoded)
+	})
+	digest := sha256.Sum256(content)
+
+	b := New(require.New(t), WithDisableCompression())
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+	b.ExpectBodySHA256(t, response, strings.ToUpper(hex.EncodeToString(digest[:])))
+
+	msg := failure(t, []Option{WithDisableCompression()}, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), Get(server.URL))
+		b.ExpectBodySHA256(t, response, strings.Repeat("0", 64))
+	})
+	require.Contains(t, msg, "got "+hex.EncodeToString(digest[:])+" over 131072 bytes")
+}
@@ -0,0 +1,165 @@
+package reqbuilder
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// sensitiveHeaders are redacted when headers are printed.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+	"X-Csrf-Token":        true,
+}
+
+// redactHeaderValue hides the secret part of a sensitive header value. Cookie names are kept.
+func redactHeaderValue(key, value string) string {
+	key = http.CanonicalHeaderKey(key)
+	if !sensitiveHeaders[key] {
+		return value
+	}
+
+	switch key {
+	case "Set-Cookie":
+		name, _, _ := strings.Cut(value, "=")
+		return name + "=<redacted>"
+	case "Cookie":
+		pairs := strings.Split(value, ";")
+		for i, pair := range pairs {
+			name, _, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			pairs[i] = name + "=<redacted>"
+		}
+		return strings.Join(pairs, "; ")
+	}
+
+	return "<redacted>"
+}
+
+// redactValues returns values with redactHeaderValue applied to each.
+func redactValues(key string, values []string) []string {
+	redacted := make([]string, len(values))
+	for i, v := range values {
+		redacted[i] = redactHeaderValue(key, v)
+	}
+
+	return redacted
+}
+
+// dumpHeader formats a header block in sorted key order with sensitive values redacted.
+func dumpHeader(header http.Header) string {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		for _, v := range header[k] {
+			fmt.Fprintf(&sb, "%s: %s\n", k, redactHeaderValue(k, v))
+		}
+	}
+
+	return sb.String()
+}
+
+// ExpectHeader fails the test unless one of the values of the response header key equals value.
+func (b *Builder) ExpectHeader(t *testing.T, response *http.Response, key, value string) {
+	t.Helper()
+
+	values := b.headerValues(response, key)
+	if !slices.Contains(values, value) {
+		b.require.Failf("unexpected header value", "expected %s: %q, got %q\n%s",
+			http.CanonicalHeaderKey(key), redactHeaderValue(key, value), redactValues(key, values), dumpHeader(response.Header))
+	}
+}
+
+// ExpectHeaderMatches fails the test unless one of the values of the response header key matches pattern.
+func (b *Builder) ExpectHeaderMatches(t *testing.T, response *http.Response, key, pattern string) {
+	t.Helper()
+
+	re, err := regexp.Compile(pattern)
+	b.require.NoErrorf(err, "invalid pattern %q", pattern)
+
+	values := b.headerValues(response, key)
+	for _, v := range values {
+		if re.MatchString(v) {
+			return
+		}
+	}
+
+	b.require.Failf("header does not match pattern", "expected %s to match %s, got %q\n%s",
+		http.CanonicalHeaderKey(key), pattern, redactValues(key, values), dumpHeader(response.Header))
+}
+
+// ExpectHeaderAbsent fails the test if the response has the header key.
+func (b *Builder) ExpectHeaderAbsent(t *testing.T, response *http.Response, key string) {
+	t.Helper()
+
+	if values := b.headerValues(response, key); len(values) != 0 {
+		b.require.Failf("unexpected header", "expected no %s header\n%s",
+			http.CanonicalHeaderKey(key), dumpHeader(response.Header))
+	}
+}
+
+// RequireNoCookies fails the test if the response sets any cookie, naming the cookies.
+func (b *Builder) RequireNoCookies(response *http.Response) {
+	b.require.NotNil(response, "no response")
+
+	cookies := response.Cookies()
+	if len(response.Header.Values("Set-Cookie")) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		names = append(names, c.Name)
+	}
+
+	b.require.Failf("unexpected cookies", "expected no Set-Cookie, got cookies %q\n%s",
+		names, b.describe(response))
+}
+
+// ExpectHeaderValues fails the test unless the values of a repeated response header
+// equal want, in any order.
+func (b *Builder) ExpectHeaderValues(t *testing.T, response *http.Response, key string, want []string) {
+	t.Helper()
+
+	got := slices.Clone(b.headerValues(response, key))
+	sorted := slices.Clone(want)
+	slices.Sort(got)
+	slices.Sort(sorted)
+
+	if !slices.Equal(got, sorted) {
+		b.require.Failf("unexpected header values", "expected %s values %q (any order), got %q\n%s",
+			http.CanonicalHeaderKey(key), redactValues(key, want), redactValues(key, b.headerValues(response, key)),
+			dumpHeader(response.Header))
+	}
+}
+
+// ExpectHeaderValuesInOrder fails the test unless the values of a repeated response header
+// equal want in the same order.
+func (b *Builder) ExpectHeaderValuesInOrder(t *testing.T, response *http.Response, key string, want []string) {
+	t.Helper()
+
+	if got := b.headerValues(response, key); !slices.Equal(got, want) {
+		b.require.Failf("unexpected header values", "expected %s values %q, got %q\n%s",
+			http.CanonicalHeaderKey(key), redactValues(key, want), redactValues(key, got), dumpHeader(response.Header))
+	}
+}
+
+// headerValues returns all values of the response header key, looked up canonically.
+func (b *Builder) headerValues(response *http.Response, key string) []string {
+	b.require.NotNil(response, "no response")
+
+	return response.Header.Values(key)
+}
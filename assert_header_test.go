@@ -0,0 +1,80 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderAssertions(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Add("Vary", "Accept")
+		w.Header().Add("Vary", "Accept-Encoding")
+		w.Header().Set("Authorization", "Bearer s3cret-token")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cret-session"})
+	})
+
+	b := New(require.New(t))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	b.ExpectHeader(t, response, "content-type", "application/json; charset=utf-8")
+	b.ExpectHeaderMatches(t, response, "CONTENT-TYPE", `^application/json`)
+	b.ExpectHeaderAbsent(t, response, "x-missing")
+	b.ExpectHeaderValues(t, response, "vary", []string{"Accept-Encoding", "Accept"})
+	b.ExpectHeaderValuesInOrder(t, response, "Vary", []string{"Accept", "Accept-Encoding"})
+
+	msg := failure(t, nil, func(fb *Builder) {
+		fb.ExpectHeaderValuesInOrder(t, response, "Vary", []string{"Accept-Encoding", "Accept"})
+	})
+	require.Contains(t, msg, `expected Vary values ["Accept-Encoding" "Accept"], got ["Accept" "Accept-Encoding"]`)
+
+	msg = failure(t, nil, func(fb *Builder) {
+		fb.ExpectHeaderAbsent(t, response, "vary")
+	})
+	require.Contains(t, msg, "Content-Type: application/json; charset=utf-8")
+}
+
+func TestHeaderAssertionsRedactSecrets(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Authorization", "Bearer s3cret-token")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cret-session"})
+	})
+
+	response, _ := New(require.New(t)).Send(t, context.Background(), Get(server.URL))
+
+	failures := []func(b *Builder){
+		func(b *Builder) { b.ExpectHeader(t, response, "Authorization", "Bearer other") },
+		func(b *Builder) { b.ExpectHeaderMatches(t, response, "Authorization", "^Basic") },
+		func(b *Builder) { b.ExpectHeaderValues(t, response, "Set-Cookie", []string{"session=other"}) },
+		func(b *Builder) { b.ExpectHeaderValuesInOrder(t, response, "Set-Cookie", []string{"session=other"}) },
+		func(b *Builder) { b.ExpectHeaderAbsent(t, response, "Authorization") },
+	}
+
+	for _, fn := range failures {
+		msg := failure(t, nil, fn)
+		require.NotContains(t, msg, "s3cret")
+		require.Contains(t, msg, "<redacted>")
+	}
+}
+
+func TestRequireNoCookies(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/leaky" {
+			http.SetCookie(w, &http.Cookie{Name: "tracking", Value: "1"})
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cret"})
+		}
+	})
+	b := New(require.New(t))
+
+	response, _ := b.Send(t, context.Background(), Get(server.URL+"/static/app.js"))
+	b.RequireNoCookies(response)
+
+	msg := failure(t, nil, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), Get(server.URL+"/leaky"))
+		b.RequireNoCookies(response)
+	})
+	require.Contains(t, msg, `expected no Set-Cookie, got cookies ["tracking" "session"]`)
+}
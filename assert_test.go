@@ -0,0 +1,158 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// statusServer answers with the status in the "status" query parameter and body in "body".
+func statusServer(t *testing.T) string {
+	t.Helper()
+
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		if location := r.URL.Query().Get("location"); location != "" {
+			w.Header().Set("Location", location)
+		}
+		w.WriteHeader(code)
+		w.Write([]byte(r.URL.Query().Get("body")))
+	})
+
+	return server.URL
+}
+
+func TestStatusAssertions(t *testing.T) {
+	base := statusServer(t)
+	b := New(require.New(t), WithoutRedirects())
+	ctx := context.Background()
+
+	send := func(status int, body string) *http.Response {
+		response, _ := b.Send(t, ctx, Get(base+"/items?status="+strconv.Itoa(status)+"&body="+url.QueryEscape(body)))
+		return response
+	}
+
+	b.ExpectStatus(t, send(201, ""), 201)
+	b.ExpectSuccess(t, send(204, ""))
+	b.ExpectClientError(t, send(404, ""))
+	b.ExpectServerError(t, send(503, ""))
+	b.ExpectSuccess(t, Wrap(send(200, "")).Response)
+
+	response := send(422, `{"error":"name is required"}`)
+	msg := failure(t, nil, func(fb *Builder) {
+		fb.ExpectSuccess(t, response)
+	})
+	require.Contains(t, msg, "expected success (2xx)")
+	require.Contains(t, msg, "GET "+base+"/items?status=422")
+	require.Contains(t, msg, "422 Unprocessable Entity")
+	require.Contains(t, msg, `{"error":"name is required"}`)
+
+	response = send(400, strings.Repeat("x", 3000))
+	msg = failure(t, nil, func(fb *Builder) {
+		fb.ExpectStatus(t, response, 200)
+	})
+	require.Contains(t, msg, strings.Repeat("x", 2048)+"... (952 more bytes)")
+}
+
+func TestExpectRedirectTo(t *testing.T) {
+	base := statusServer(t)
+	b := New(require.New(t), WithoutRedirects())
+
+	response, _ := b.Send(t, context.Background(), Get(base+"/account/settings?status=302&location=../login"))
+	b.ExpectRedirectTo(t, response, "/login")
+	b.ExpectRedirectTo(t, response, base+"/login")
+
+	msg := failure(t, nil, func(fb *Builder) {
+		fb.ExpectRedirectTo(t, response, "/signup")
+	})
+	require.Contains(t, msg, "expected "+base+"/signup, got "+base+"/login")
+
+	response, _ = b.Send(t, context.Background(), Get(base+"/?status=200"))
+	msg = failure(t, nil, func(fb *Builder) {
+		fb.ExpectRedirectTo(t, response, "/login")
+	})
+	require.Contains(t, msg, "expected redirect (3xx)")
+}
+
+func TestStatusClassBoundaries(t *testing.T) {
+	cases := []struct {
+		code                          int
+		success, clientErr, serverErr bool
+	}{
+		{199, false, false, false},
+		{200, true, false, false},
+		{299, true, false, false},
+		{300, false, false, false},
+		{399, false, false, false},
+		{400, false, true, false},
+		{499, false, true, false},
+		{500, false, false, true},
+		{599, false, false, true},
+		{600, false, false, false},
+	}
+
+	for _, c := range cases {
+		response := &http.Response{StatusCode: c.code, Status: strconv.Itoa(c.code), Header: http.Header{}}
+		checks := map[string]struct {
+			want   bool
+			assert func(b *Builder)
+		}{
+			"success":      {c.success, func(b *Builder) { b.RequireSuccess(response) }},
+			"client error": {c.clientErr, func(b *Builder) { b.RequireClientError(response) }},
+			"server error": {c.serverErr, func(b *Builder) { b.RequireServerError(response) }},
+		}
+
+		for name, check := range checks {
+			if check.want {
+				check.assert(New(require.New(t)))
+				continue
+			}
+			msg := failure(t, nil, check.assert)
+			require.Containsf(t, msg, "expected "+name, "%d", c.code)
+		}
+	}
+}
+
+func TestFailureMessageIndentsJSON(t *testing.T) {
+	url := jsonServer(t, http.StatusBadRequest, "1", `{"error":{"code":"invalid","fields":["name"]}}`)
+	indented := "{\n  \"error\": {\n    \"code\": \"invalid\",\n    \"fields\": [\n      \"name\"\n    ]\n  }\n}"
+
+	msg := failure(t, nil, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), Get(url))
+		b.ExpectStatus(t, response, http.StatusOK)
+	})
+	require.Contains(t, unindent(msg), "body: \n"+indented)
+
+	msg = failure(t, nil, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), Get(url))
+		b.RequireJSONEq(t, response, `{"error":{"code":"other"}}`)
+	})
+	require.Contains(t, unindent(msg), "expected:\n{\n  \"error\": {\n    \"code\": \"other\"\n  }\n}")
+	require.Contains(t, unindent(msg), indented)
+}
+
+// unindent removes the prefix testify puts before each continuation line of a message.
+func unindent(msg string) string {
+	return strings.ReplaceAll(msg, "\n\t            \t", "\n")
+}
+
+func TestWithFailureBodyLimit(t *testing.T) {
+	base := statusServer(t)
+
+	msg := failure(t, []Option{WithFailureBodyLimit(5)}, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), Get(base+"/?status=500&body=not+json+at+all"))
+		b.ExpectStatus(t, response, http.StatusOK)
+	})
+
+	require.Contains(t, msg, "body: not j... (10 more bytes)", "non-JSON bodies are shown as-is, cut at the limit")
+
+	msg = failure(t, nil, func(b *Builder) {
+		New(b.require, WithFailureBodyLimit(0))
+	})
+	require.Contains(t, msg, "failure body limit must be positive, got 0")
+}
@@ -0,0 +1,122 @@
+package reqbuilder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// bandwidthLimit caps the rate request bodies are written and response bodies are read at.
+type bandwidthLimit struct {
+	bytesPerSec int64
+	burst       int64
+}
+
+// WithBandwidthLimit throttles request and response bodies to bytesPerSec each, like a
+// slow client link. Bodies are streamed through a token bucket, so a throttled
+// download is delivered gradually rather than after a delay. The burst defaults to a
+// tenth of a second's worth of data, see WithBandwidthBurst. A zero rate removes the limit.
+func WithBandwidthLimit(bytesPerSec int64) Option {
+	return func(b *Builder) {
+		b.require.Truef(bytesPerSec >= 0, "bandwidth limit must not be negative, got %d", bytesPerSec)
+
+		burst := max(bytesPerSec/10, 1)
+		if b.bandwidth != nil && b.bandwidth.burst != 0 {
+			burst = b.bandwidth.burst
+		}
+		b.bandwidth = &bandwidthLimit{bytesPerSec: bytesPerSec, burst: burst}
+	}
+}
+
+// WithBandwidthBurst sets how many bytes a throttled body may transfer at once before
+// the rate set by WithBandwidthLimit applies. burst must be positive.
+func WithBandwidthBurst(burst int64) Option {
+	return func(b *Builder) {
+		b.require.Truef(burst > 0, "bandwidth burst must be positive, got %d", burst)
+
+		limit := bandwidthLimit{burst: burst}
+		if b.bandwidth != nil {
+			limit.bytesPerSec = b.bandwidth.bytesPerSec
+		}
+		b.bandwidth = &limit
+	}
+}
+
+// bandwidthRoundTripper throttles the bodies of requests sent through next.
+type bandwidthRoundTripper struct {
+	limit bandwidthLimit
+	next  http.RoundTripper
+	clock Clock
+}
+
+func (rt *bandwidthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body := req.Body
+		req = req.Clone(ctx)
+		req.Body = rt.throttle(ctx, body)
+	}
+
+	response, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return response, err
+	}
+
+	response.Body = rt.throttle(ctx, response.Body)
+
+	return response, nil
+}
+
+// throttle wraps body in a reader limited to the configured rate.
+func (rt *bandwidthRoundTripper) throttle(ctx context.Context, body io.ReadCloser) io.ReadCloser {
+	return &throttledBody{
+		ReadCloser: body,
+		ctx:        ctx,
+		limit:      rt.limit,
+		tokens:     float64(rt.limit.burst),
+		last:       rt.clock.Now(),
+		clock:      rt.clock,
+	}
+}
+
+// throttledBody is a body read through a token bucket. Reads are capped at the burst
+// size and may overdraw the bucket, after which the reader sleeps off the debt.
+type throttledBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	limit  bandwidthLimit
+	tokens float64
+	last   time.Time
+	clock  Clock
+}
+
+func (r *throttledBody) Read(p []byte) (int, error) {
+	if r.limit.bytesPerSec <= 0 {
+		return r.ReadCloser.Read(p)
+	}
+
+	if int64(len(p)) > r.limit.burst {
+		p = p[:r.limit.burst]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	if n == 0 {
+		return n, err
+	}
+
+	now := r.clock.Now()
+	r.tokens = min(r.tokens+now.Sub(r.last).Seconds()*float64(r.limit.bytesPerSec), float64(r.limit.burst))
+	r.last = now
+	r.tokens -= float64(n)
+
+	if r.tokens < 0 {
+		wait := time.Duration(-r.tokens / float64(r.limit.bytesPerSec) * float64(time.Second))
+		if sleepErr := r.clock.Sleep(r.ctx, wait); sleepErr != nil {
+			return n, sleepErr
+		}
+	}
+
+	return n, err
+}
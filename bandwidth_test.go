@@ -0,0 +1,77 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBandwidthLimitDownload(t *testing.T) {
+	const size = 1 << 20
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), size))
+	})
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	advanceSleepers(t, clock)
+	b := New(require.New(t), WithClock(clock), WithBandwidthLimit(256<<10))
+
+	start := clock.Now()
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	buf := make([]byte, 32<<10)
+	n, err := response.Body.Read(buf)
+	require.NoError(t, err)
+	require.Positive(t, n)
+	require.Equal(t, start, clock.Now(), "the first burst must stream without waiting")
+
+	rest, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, size, n+len(rest))
+	// Everything after the first burst of a tenth of a second arrives at the limit.
+	require.InDelta(t, 3.9, clock.Now().Sub(start).Seconds(), 0.01)
+
+	timings, ok := Wrap(response).Timings()
+	require.True(t, ok)
+	require.Zero(t, timings.TTFB, "throttling must not delay the first byte")
+}
+
+func TestWithBandwidthLimitUpload(t *testing.T) {
+	received := 0
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = len(data)
+	})
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	advanceSleepers(t, clock)
+	b := New(require.New(t), WithClock(clock), WithBandwidthLimit(1000), WithBandwidthBurst(100))
+	b.Request(t, context.Background(), http.MethodPost, server.URL, "/", make([]byte, 1000), nil, nil, "")
+
+	require.Equal(t, 1000, received)
+	// The first burst is free, the rest is sent at 1000 bytes per second.
+	require.InDelta(t, 0.9, clock.Now().Sub(time.Unix(0, 0)).Seconds(), 0.05)
+}
+
+func TestWithBandwidthLimitValidation(t *testing.T) {
+	msg := failure(t, nil, func(b *Builder) {
+		b.With(WithBandwidthBurst(0))
+	})
+	require.Contains(t, msg, "bandwidth burst must be positive, got 0")
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.With(WithBandwidthLimit(1000), WithBandwidthBurst(-5))
+	})
+	require.Contains(t, msg, "bandwidth burst must be positive, got -5")
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.With(WithBandwidthLimit(-1))
+	})
+	require.Contains(t, msg, "bandwidth limit must not be negative, got -1")
+}
@@ -0,0 +1,59 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// SubRequest is one request inside a batch envelope.
+type SubRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// SubResponse is one response inside a batch envelope, in the order of the sub-requests.
+type SubResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Decode unmarshals the sub-response body into v.
+func (r SubResponse) Decode(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Batch POSTs subRequests to a batch endpoint as a JSON array and decodes the JSON
+// array of sub-responses it answers with. The test fails when the batch request is
+// not successful or its response is not a sub-response array.
+func (b *Builder) Batch(
+	t *testing.T,
+	ctx context.Context,
+	host,
+	endpoint string,
+	subRequests []SubRequest,
+	cookies []*http.Cookie,
+	headers map[string]string,
+	authorization string,
+	opts ...Option) ([]SubResponse, *http.Response, []*http.Cookie) {
+	t.Helper()
+
+	if len(opts) != 0 {
+		b = b.With(opts...)
+	}
+
+	response, cookies := b.RequestJSON(t, ctx, http.MethodPost, host, endpoint, subRequests, cookies, headers, authorization)
+	b.ExpectSuccess(t, response)
+
+	var subResponses []SubResponse
+	if err := b.DecodeJSON(response, &subResponses); err != nil {
+		b.logError(t, err)
+		b.require.NoError(err)
+	}
+
+	return subResponses, response, cookies
+}
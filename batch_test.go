@@ -0,0 +1,64 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	var received []SubRequest
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/batch", r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		users := map[string]string{"/users/1": `{"id":1,"name":"ada"}`, "/users/2": `{"id":2,"name":"bob"}`}
+		responses := make([]SubResponse, len(received))
+		for i, sub := range received {
+			responses[i] = SubResponse{Status: http.StatusOK, Headers: map[string]string{"Content-Type": "application/json"},
+				Body: json.RawMessage(users[sub.Path])}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(responses)
+	})
+
+	b := New(require.New(t))
+	subResponses, response, _ := b.Batch(t, context.Background(), server.URL, "/batch", []SubRequest{
+		{Method: http.MethodGet, Path: "/users/1", Headers: map[string]string{"Accept": "application/json"}},
+		{Method: http.MethodGet, Path: "/users/2"},
+	}, nil, nil, "")
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, []SubRequest{
+		{Method: http.MethodGet, Path: "/users/1", Headers: map[string]string{"Accept": "application/json"}},
+		{Method: http.MethodGet, Path: "/users/2"},
+	}, received)
+
+	require.Len(t, subResponses, 2)
+	for i, name := range []string{"ada", "bob"} {
+		var user struct {
+			ID   int
+			Name string
+		}
+		require.Equal(t, http.StatusOK, subResponses[i].Status)
+		require.NoError(t, subResponses[i].Decode(&user))
+		require.Equal(t, i+1, user.ID)
+		require.Equal(t, name, user.Name)
+	}
+}
+
+func TestBatchFailure(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "batch too large", http.StatusRequestEntityTooLarge)
+	})
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.Batch(t, context.Background(), server.URL, "/batch", []SubRequest{{Method: http.MethodGet, Path: "/"}}, nil, nil, "")
+	})
+	require.Contains(t, msg, "413")
+}
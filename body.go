@@ -0,0 +1,217 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// replayBody is a response body buffered in memory so that it can be read more than once.
+type replayBody struct {
+	*bytes.Reader
+	raw []byte
+}
+
+func (r *replayBody) Close() error {
+	return nil
+}
+
+// bufferBody reads the raw (still encoded) response body once and replaces it with a fresh
+// in-memory copy, so assertions can inspect the body without consuming it for the caller.
+func bufferBody(response *http.Response) ([]byte, error) {
+	if response.Body == nil {
+		return nil, nil
+	}
+
+	var raw []byte
+
+	if rb, ok := response.Body.(*replayBody); ok {
+		raw = rb.raw
+	} else {
+		var err error
+
+		raw, err = io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response.Body = &replayBody{Reader: bytes.NewReader(raw), raw: raw}
+
+	return raw, nil
+}
+
+// WithBodyReplay buffers every response body in memory as soon as it arrives, so it
+// can be read any number of times: validators, assertions, ReadResponseBody and
+// DecodeJSON each read it from the start, and response.Body is replaced with a fresh
+// reader after every read. Bodies buffered by validators or assertions behave the same
+// without this option.
+func WithBodyReplay() Option {
+	return func(b *Builder) {
+		b.bodyReplay = true
+	}
+}
+
+// bodyReader returns a reader over the response body. A buffered body is read from the
+// start and response.Body is replaced with a fresh copy for the next reader.
+func bodyReader(response *http.Response) io.Reader {
+	rb, ok := response.Body.(*replayBody)
+	if !ok {
+		return response.Body
+	}
+
+	response.Body = &replayBody{Reader: bytes.NewReader(rb.raw), raw: rb.raw}
+
+	return bytes.NewReader(rb.raw)
+}
+
+// BodyReader returns the response body decoded on the fly, as ReadResponseBody reads
+// it, for NDJSON streams and downloads too large to buffer. Nothing is read until the
+// caller reads. Close closes the decoder and the response body, once; closing again
+// is a no-op.
+func (b *Builder) BodyReader(response *http.Response) (io.ReadCloser, error) {
+	return b.decodedReader(response, true)
+}
+
+// decodedReader is BodyReader, copying to the body tee only if tee is set.
+func (b *Builder) decodedReader(response *http.Response, tee bool) (io.ReadCloser, error) {
+	body := response.Body
+
+	decoder, err := b.responseDecoder(response)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader = decoder
+	if meta := metaOf(response); tee && meta != nil && meta.tee != nil {
+		reader = &teeReader{Reader: decoder, w: meta.tee}
+	}
+
+	return &bodyReadCloser{Reader: reader, decoder: decoder, body: body}, nil
+}
+
+// bodyReadCloser is a decoded response body that closes its decoder and the
+// underlying body exactly once.
+type bodyReadCloser struct {
+	io.Reader
+	decoder io.Closer
+	body    io.Closer
+
+	once sync.Once
+	err  error
+}
+
+func (r *bodyReadCloser) Close() error {
+	r.once.Do(func() {
+		if r.body != nil {
+			r.err = r.body.Close()
+		}
+		if err := r.decoder.Close(); r.err == nil {
+			r.err = err
+		}
+	})
+
+	return r.err
+}
+
+// decodedBody returns the decompressed response body without consuming it, for the
+// Builder's own reads, which are not copied to the body tee.
+func (b *Builder) decodedBody(response *http.Response) ([]byte, error) {
+	raw, err := bufferBody(response)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := *response
+	copied.Body = io.NopCloser(bytes.NewReader(raw))
+
+	body, _, err := b.readBody(&copied, false)
+
+	return body, err
+}
+
+// peekBody returns at most limit bytes of the decoded start of the response body, read
+// from at most limit bytes of the raw body, and puts those bytes back so the caller still sees them. It
+// never waits for more of a slow or endless body than it needs.
+func (b *Builder) peekBody(response *http.Response, limit int64) []byte {
+	peeked := peekResponse(response, limit)
+
+	decoder, err := b.responseDecoder(peeked)
+	if err != nil {
+		return nil
+	}
+	defer decoder.Close()
+
+	// A decoder stops with an error where the prefix cuts the stream; what it
+	// decoded up to there is still worth showing.
+	body, _ := io.ReadAll(io.LimitReader(decoder, limit))
+
+	return body
+}
+
+// WithBodyFromReaderFactory sends the reader returned by newBody as the request body,
+// streamed with chunked encoding. newBody is called again whenever the body has to be
+// resent, by retries or 307/308 redirects, so it must return a fresh reader each time.
+// It is meant as a per-request option; the request's own body is ignored.
+func WithBodyFromReaderFactory(newBody func() io.Reader) Option {
+	return func(b *Builder) {
+		b.bodyFactory = newBody
+	}
+}
+
+// applyBodyFactory replaces the request body with one from the Builder's body factory.
+func (b *Builder) applyBodyFactory(req *http.Request) {
+	req.Body = io.NopCloser(b.bodyFactory())
+	req.ContentLength = -1
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(b.bodyFactory()), nil
+	}
+}
+
+// BodyFromFile returns the contents of a file, typically under testdata, failing the
+// test with the absolute path tried if it cannot be read.
+func (b *Builder) BodyFromFile(t *testing.T, path string) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		abs, absErr := filepath.Abs(path)
+		if absErr != nil {
+			abs = path
+		}
+		b.logError(t, err)
+		b.require.NoError(err, "reading body from %s", abs)
+	}
+
+	return body
+}
+
+// BodyBase64 decodes standard or URL-safe base64, padded or not, failing the test on
+// bad input.
+func (b *Builder) BodyBase64(t *testing.T, s string) []byte {
+	t.Helper()
+
+	s = strings.TrimSpace(s)
+
+	var err error
+	for _, encoding := range []*base64.Encoding{
+		base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding,
+	} {
+		var body []byte
+		if body, err = encoding.DecodeString(s); err == nil {
+			return body
+		}
+	}
+
+	b.logError(t, err)
+	b.require.NoError(err, "decoding base64 %s", excerpt([]byte(s), 64))
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/require"
+)
+
+// generatedStream returns a reader producing size bytes that is not seekable, so only
+// the factory can provide it again.
+func generatedStream(size int) io.Reader {
+	return io.LimitReader(&patternReader{}, int64(size))
+}
+
+// patternReader produces an endless repetition of the bytes 0-255.
+type patternReader struct {
+	n byte
+}
+
+func (r *patternReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.n
+		r.n++
+	}
+
+	return len(p), nil
+}
+
+func TestWithBodyFromReaderFactoryRetry(t *testing.T) {
+	const size = 256 << 10
+
+	var mu sync.Mutex
+	var bodies [][]byte
+	var encodings [][]string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, body)
+		encodings = append(encodings, r.TransferEncoding)
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+
+	calls := 0
+	factory := func() io.Reader {
+		calls++
+		return generatedStream(size)
+	}
+
+	b := New(require.New(t), WithRetry(1, time.Millisecond))
+	response, _ := b.Request(t, context.Background(), http.MethodPut, server.URL, "/upload", []byte("ignored"), nil, nil, "",
+		WithBodyFromReaderFactory(factory))
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, 2, calls)
+	require.Len(t, bodies, 2)
+
+	want, _ := io.ReadAll(generatedStream(size))
+	require.True(t, bytes.Equal(want, bodies[0]), "first attempt sends the full body")
+	require.True(t, bytes.Equal(want, bodies[1]), "the retry sends the full body again")
+	require.Equal(t, [][]string{{"chunked"}, {"chunked"}}, encodings)
+}
+
+func TestWithBodyFromReaderFactoryRedirect(t *testing.T) {
+	var received []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		http.Redirect(w, r, "/new", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+	})
+	server := newServer(t, mux.ServeHTTP)
+
+	b := New(require.New(t))
+	response, _ := b.Request(t, context.Background(), http.MethodPost, server.URL, "/old", nil, nil, nil, "",
+		WithBodyFromReaderFactory(func() io.Reader { return generatedStream(1000) }))
+
+	require.Equal(t, "/new", response.Request.URL.Path)
+	want, _ := io.ReadAll(generatedStream(1000))
+	require.Equal(t, want, received)
+}
+
+func TestBodyFromFile(t *testing.T) {
+	b := New(require.New(t))
+	require.Equal(t, "--golden-boundary", string(b.BodyFromFile(t, "testdata/multipart_two_fields.golden")[:17]))
+
+	abs, err := filepath.Abs("testdata/missing.png")
+	require.NoError(t, err)
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.BodyFromFile(t, "testdata/missing.png")
+	})
+	require.Contains(t, msg, "reading body from "+abs)
+}
+
+func TestBodyBase64(t *testing.T) {
+	b := New(require.New(t))
+	want := []byte{0xfb, 0xff, 0xbf, 'h', 'i'}
+
+	for _, s := range []string{"+/+/aGk=", "+/+/aGk", "-_-_aGk=", "-_-_aGk", " +/+/aGk=\n"} {
+		require.Equal(t, want, b.BodyBase64(t, s), s)
+	}
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.BodyBase64(t, "not base64!")
+	})
+	require.Contains(t, msg, "decoding base64 not base64!")
+}
+
+func TestWithBodyReplay(t *testing.T) {
+	content := []byte(`{"id":7,"name":"ann"}`)
+	encoded, err := EncodeGzip(content)
+	require.NoError(t, err)
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(encoded)
+	})
+
+	var validated []byte
+	b := New(require.New(t), WithRawBodyAccess(), WithBodyReplay(), WithResponseValidator(func(r *http.Response) error {
+		validated, err = io.ReadAll(r.Body)
+		return err
+	}))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+	require.Equal(t, encoded, validated, "the validator reads the body first")
+
+	b.ExpectBodyContains(t, response, `"name":"ann"`)
+	b.ExpectBodySHA256(t, response, hashHex(string(content)))
+
+	for range 2 {
+		body, err := b.ReadResponseBody(response)
+		require.NoError(t, err)
+		require.Equal(t, content, body)
+	}
+
+	var user struct{ ID int }
+	require.NoError(t, b.DecodeJSON(response, &user))
+	require.Equal(t, 7, user.ID)
+
+	raw, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.Equal(t, encoded, raw, "response.Body is a fresh reader over the buffer")
+}
+
+// closeCounter is a response body that counts how often it is closed.
+type closeCounter struct {
+	io.Reader
+	closes int
+}
+
+func (c *closeCounter) Close() error {
+	c.closes++
+
+	return nil
+}
+
+func TestBodyReader(t *testing.T) {
+	content := []byte("{\"n\":1}\n{\"n\":2}\n")
+	b := New(require.New(t))
+
+	for encoding, encode := range map[string]func([]byte) ([]byte, error){
+		"":        func(data []byte) ([]byte, error) { return data, nil },
+		"gzip":    EncodeGzip,
+		"br":      EncodeBrotli,
+		"zstd":    EncodeZstd,
+		"deflate": EncodeDeflate,
+	} {
+		encoded, err := encode(content)
+		require.NoError(t, err)
+		body := &closeCounter{Reader: bytes.NewReader(encoded)}
+		response := fabricated(encoding, nil)
+		response.Body = body
+
+		reader, err := b.BodyReader(response)
+		require.NoError(t, err, "encoding %q", encoding)
+		decoded, err := io.ReadAll(reader)
+		require.NoError(t, err, "encoding %q", encoding)
+		require.Equal(t, content, decoded, "encoding %q", encoding)
+
+		require.NoError(t, reader.Close())
+		require.NoError(t, reader.Close(), "closing twice is a no-op")
+		require.Equal(t, 1, body.closes, "encoding %q: the body is closed exactly once", encoding)
+	}
+}
+
+func TestBrotliReadCloser(t *testing.T) {
+	encoded, err := EncodeBrotli([]byte("hello"))
+	require.NoError(t, err)
+	body := &closeCounter{Reader: bytes.NewReader(encoded)}
+
+	// The exported type works as a value.
+	var reader io.ReadCloser = BrotliReadCloser{Reader: brotli.NewReader(body), Closer: body}
+	decoded, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(decoded))
+	require.NoError(t, reader.Close())
+	require.Equal(t, 1, body.closes)
+}
+
+func TestBrotliBody(t *testing.T) {
+	encoded, err := EncodeBrotli([]byte("hello"))
+	require.NoError(t, err)
+	body := &closeCounter{Reader: bytes.NewReader(encoded)}
+
+	reader := &brotliBody{reader: brotli.NewReader(body), closer: body}
+	decoded, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(decoded))
+
+	require.NoError(t, reader.Close())
+	require.NoError(t, reader.Close(), "closing twice is a no-op")
+	require.Equal(t, 1, body.closes)
+	require.Nil(t, reader.reader, "Close drops the decoder state")
+
+	_, err = reader.Read(make([]byte, 1))
+	require.ErrorIs(t, err, errBrotliClosed)
+}
+
+func TestBrotliBodyCloseDuringRead(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	reader := &brotliBody{reader: brotli.NewReader(pr), closer: pr}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := reader.Read(make([]byte, 1))
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, reader.Close())
+	require.Error(t, <-done, "Close unblocks the pending Read")
+	require.Nil(t, reader.reader)
+}
@@ -0,0 +1,154 @@
+package reqbuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// CacheControl holds the directives of a response Cache-Control header.
+type CacheControl struct {
+	MaxAge         time.Duration
+	HasMaxAge      bool
+	SMaxAge        time.Duration
+	HasSMaxAge     bool
+	NoStore        bool
+	NoCache        bool
+	Private        bool
+	Public         bool
+	MustRevalidate bool
+}
+
+// ParseCacheControl parses the Cache-Control header of the response. Unknown directives
+// are ignored, but malformed ones, such as a max-age without a numeric value, are
+// reported as an error alongside the directives that did parse.
+func ParseCacheControl(response *http.Response) (CacheControl, error) {
+	var cc CacheControl
+	var errs []error
+
+	for _, directive := range strings.Split(strings.Join(response.Header.Values("Cache-Control"), ","), ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(directive, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch name {
+		case "max-age", "s-maxage":
+			seconds, err := strconv.Atoi(value)
+			if !hasValue || err != nil || seconds < 0 {
+				errs = append(errs, fmt.Errorf("malformed Cache-Control directive %q", directive))
+				continue
+			}
+			if name == "max-age" {
+				cc.MaxAge, cc.HasMaxAge = time.Duration(seconds)*time.Second, true
+			} else {
+				cc.SMaxAge, cc.HasSMaxAge = time.Duration(seconds)*time.Second, true
+			}
+		case "no-store", "public", "must-revalidate":
+			if hasValue {
+				errs = append(errs, fmt.Errorf("malformed Cache-Control directive %q: %s takes no value", directive, name))
+				continue
+			}
+			switch name {
+			case "no-store":
+				cc.NoStore = true
+			case "public":
+				cc.Public = true
+			default:
+				cc.MustRevalidate = true
+			}
+		case "no-cache":
+			cc.NoCache = true
+		case "private":
+			cc.Private = true
+		}
+	}
+
+	return cc, errors.Join(errs...)
+}
+
+// cacheControl parses the Cache-Control header and fails the test if it is malformed.
+func (b *Builder) cacheControl(response *http.Response) CacheControl {
+	b.require.NotNil(response, "no response")
+
+	cc, err := ParseCacheControl(response)
+	if err != nil {
+		b.require.Failf("malformed Cache-Control", "%v\n%s", err, dumpHeader(response.Header))
+	}
+
+	return cc
+}
+
+// ExpectNotCacheable fails the test unless the response forbids caching with no-store
+// and does not also allow shared caches with public or s-maxage.
+func (b *Builder) ExpectNotCacheable(t *testing.T, response *http.Response) {
+	t.Helper()
+
+	cc := b.cacheControl(response)
+	if !cc.NoStore || cc.Public || cc.HasSMaxAge {
+		b.require.Failf("response is cacheable", "expected Cache-Control: no-store, got %q\n%s",
+			response.Header.Values("Cache-Control"), b.describe(response))
+	}
+}
+
+// ExpectMaxAgeBetween fails the test unless the response Cache-Control max-age is
+// within [lo, hi].
+func (b *Builder) ExpectMaxAgeBetween(t *testing.T, response *http.Response, lo, hi time.Duration) {
+	t.Helper()
+
+	cc := b.cacheControl(response)
+	if !cc.HasMaxAge || cc.MaxAge < lo || cc.MaxAge > hi {
+		b.require.Failf("unexpected max-age", "expected max-age between %s and %s, got Cache-Control %q",
+			lo, hi, response.Header.Values("Cache-Control"))
+	}
+}
+
+// VerifyCachingRoundtrip sends a GET to url and replays it with If-None-Match and
+// If-Modified-Since taken from the first response's ETag and Last-Modified. A
+// response declared no-store must be sent again in full with 200, since no client
+// holds a copy to revalidate; otherwise a response carrying validators must be
+// revalidated with 304 and an empty body, and one without validators with 200.
+func (b *Builder) VerifyCachingRoundtrip(t *testing.T, ctx context.Context, url string) {
+	t.Helper()
+
+	first, _ := b.Send(t, ctx, Get(url))
+	b.ExpectStatus(t, first, http.StatusOK)
+	cc := b.cacheControl(first)
+
+	etag := first.Header.Get("ETag")
+	lastModified := first.Header.Get("Last-Modified")
+
+	replay := Get(url)
+	if etag != "" {
+		replay.SetHeader("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		replay.SetHeader("If-Modified-Since", lastModified)
+	}
+
+	second, _ := b.Send(t, ctx, replay)
+
+	switch {
+	case cc.NoStore:
+		if second.StatusCode != http.StatusOK {
+			b.require.Failf("unexpected revalidation", "no-store response must be resent with 200\n%s", b.describe(second))
+		}
+	case etag != "" || lastModified != "":
+		b.ExpectStatus(t, second, http.StatusNotModified)
+		b.ExpectBodyLen(t, second, 0)
+		if etag != "" && second.Header.Get("ETag") != "" && second.Header.Get("ETag") != etag {
+			b.require.Failf("ETag changed", "304 ETag %q differs from %q", second.Header.Get("ETag"), etag)
+		}
+	default:
+		b.ExpectStatus(t, second, http.StatusOK)
+	}
+}
@@ -0,0 +1,94 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func cacheResponse(values ...string) *http.Response {
+	return &http.Response{Header: http.Header{"Cache-Control": values}}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	cc, err := ParseCacheControl(cacheResponse(`private, max-age="60"`, "must-revalidate, no-cache, s-maxage=120, x-custom=1"))
+	require.NoError(t, err)
+	require.Equal(t, CacheControl{
+		MaxAge: time.Minute, HasMaxAge: true,
+		SMaxAge: 2 * time.Minute, HasSMaxAge: true,
+		NoCache: true, Private: true, MustRevalidate: true,
+	}, cc)
+
+	cc, err = ParseCacheControl(cacheResponse("public, max-age=abc, no-store=1, s-maxage"))
+	require.EqualError(t, err, `malformed Cache-Control directive "max-age=abc"`+"\n"+
+		`malformed Cache-Control directive "no-store=1": no-store takes no value`+"\n"+
+		`malformed Cache-Control directive "s-maxage"`)
+	require.Equal(t, CacheControl{Public: true}, cc, "directives that parsed are kept")
+
+	cc, err = ParseCacheControl(cacheResponse())
+	require.NoError(t, err)
+	require.Zero(t, cc)
+}
+
+func TestCacheAssertions(t *testing.T) {
+	b := New(require.New(t))
+	b.ExpectNotCacheable(t, cacheResponse("no-store, private"))
+	b.ExpectMaxAgeBetween(t, cacheResponse("max-age=300"), time.Minute, 10*time.Minute)
+
+	for _, header := range []string{"private, max-age=0", "no-store, public", "no-store, s-maxage=10"} {
+		msg := failure(t, nil, func(b *Builder) {
+			b.ExpectNotCacheable(t, cacheResponse(header))
+		})
+		require.Contains(t, msg, "response is cacheable", header)
+	}
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.ExpectMaxAgeBetween(t, cacheResponse("max-age=3600"), time.Minute, 10*time.Minute)
+	})
+	require.Contains(t, msg, `expected max-age between 1m0s and 10m0s, got Cache-Control ["max-age=3600"]`)
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.ExpectNotCacheable(t, cacheResponse("no-store, max-age=-1"))
+	})
+	require.Contains(t, msg, "malformed Cache-Control")
+}
+
+func TestVerifyCachingRoundtrip(t *testing.T) {
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/etag":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/modified":
+			w.Header().Set("Cache-Control", "no-cache")
+			http.ServeContent(w, r, "data.txt", modified, strings.NewReader("data"))
+			return
+		case "/private":
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("ETag", `"v1"`)
+		case "/broken":
+			// Advertises a validator but never answers 304.
+			w.Header().Set("ETag", `"v1"`)
+		}
+		w.Write([]byte("data"))
+	})
+
+	b := New(require.New(t))
+	for _, path := range []string{"/etag", "/modified", "/private", "/plain"} {
+		b.VerifyCachingRoundtrip(t, context.Background(), server.URL+path)
+	}
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.VerifyCachingRoundtrip(t, context.Background(), server.URL+"/broken")
+	})
+	require.Contains(t, msg, "304")
+}
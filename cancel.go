@@ -0,0 +1,131 @@
+package reqbuilder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"testing"
+	"time"
+)
+
+// RequestCancel sends the request described by spec and cancels its context after
+// cancelAfter, returning the resulting error. A request interrupted by the cancellation
+// returns an error for which errors.Is(err, context.Canceled) holds; any other error
+// comes from the request itself. If the response completes first, the body is read
+// to the end and nil is returned.
+func (b *Builder) RequestCancel(t *testing.T, ctx context.Context, spec *RequestSpec, cancelAfter time.Duration) error {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		if b.clock.Sleep(ctx, cancelAfter) == nil {
+			cancel()
+		}
+	}()
+
+	response, err := b.doWith(t, b.newSpecRequest(t, ctx, spec), doHooks{
+		tolerate: func(error) bool { return true },
+	})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	_, err = io.Copy(io.Discard, response.Body)
+
+	return err
+}
+
+// CancelAfter selects the point at which SendAndCancel cancels a request. The first of
+// the set conditions to be reached triggers the cancellation.
+type CancelAfter struct {
+	// HeadersSent cancels once the request headers have been written.
+	HeadersSent bool
+	// BodyBytes cancels once that many request body bytes have been handed to the transport.
+	// It cannot be combined with WithBodyChecksum, which reads the whole body first.
+	BodyBytes int64
+	// Delay cancels after the given time.
+	Delay time.Duration
+}
+
+// SendAndCancel sends the request described by spec and cancels it at the point
+// described by after, to test how the server handles clients that disconnect
+// mid-request. It reports whether response headers arrived before the cancellation.
+// The expected context.Canceled error does not fail the test; other errors do.
+func (b *Builder) SendAndCancel(t *testing.T, ctx context.Context, spec *RequestSpec, after CancelAfter) (responded bool) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if after.HeadersSent {
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			WroteHeaders: cancel,
+		})
+	}
+
+	if after.Delay > 0 {
+		go func() {
+			if b.clock.Sleep(ctx, after.Delay) == nil {
+				cancel()
+			}
+		}()
+	}
+
+	if after.BodyBytes > 0 && b.checksum != nil {
+		b.require.Fail("CancelAfter.BodyBytes cannot be combined with WithBodyChecksum",
+			"the checksum is computed over the whole body before any of it is sent, so the cancellation would happen before the request starts")
+	}
+
+	hooks := doHooks{tolerate: func(err error) bool { return errors.Is(err, context.Canceled) }}
+	if after.BodyBytes > 0 {
+		hooks.prepared = func(req *http.Request) {
+			if req.Body != nil && req.Body != http.NoBody {
+				req.Body = &cancelingBody{ReadCloser: req.Body, remaining: after.BodyBytes, cancel: cancel}
+				req.GetBody = nil
+			}
+		}
+	}
+
+	response, err := b.doWith(t, b.newSpecRequest(t, ctx, spec), hooks)
+	if response != nil {
+		defer response.Body.Close()
+	}
+	if err == nil {
+		_, err = io.Copy(io.Discard, response.Body)
+	}
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		b.logError(t, err)
+		b.require.NoError(err)
+	}
+
+	return response != nil
+}
+
+// cancelingBody cancels a request once remaining body bytes have been read.
+type cancelingBody struct {
+	io.ReadCloser
+	remaining int64
+	cancel    context.CancelFunc
+}
+
+func (r *cancelingBody) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		r.cancel()
+		return 0, context.Canceled
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+
+	return n, err
+}
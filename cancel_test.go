@@ -0,0 +1,170 @@
+package reqbuilder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// slowServer answers after delay unless the client goes away first, reporting on
+// gone whether it saw the client disconnect.
+func slowServer(t *testing.T, delay time.Duration) (string, <-chan bool) {
+	gone := make(chan bool, 1)
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			gone <- true
+		case <-time.After(delay):
+			gone <- false
+		}
+	})
+
+	return server.URL, gone
+}
+
+func TestRequestCancel(t *testing.T) {
+	url, gone := slowServer(t, 5*time.Second)
+	b := New(require.New(t))
+
+	start := time.Now()
+	err := b.RequestCancel(t, context.Background(), Get(url), 50*time.Millisecond)
+
+	require.ErrorIs(t, err, context.Canceled)
+	require.Less(t, time.Since(start), 2*time.Second)
+	require.True(t, <-gone, "the server must see the client disconnect")
+}
+
+func TestRequestCancelCompletes(t *testing.T) {
+	url, _ := slowServer(t, 0)
+
+	err := New(require.New(t)).RequestCancel(t, context.Background(), Get(url), 5*time.Second)
+	require.NoError(t, err)
+}
+
+func TestRequestCancelOtherErrors(t *testing.T) {
+	url, gone := slowServer(t, 5*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := New(require.New(t)).RequestCancel(t, ctx, Get(url), 5*time.Second)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.False(t, errors.Is(err, context.Canceled))
+	<-gone
+}
+
+func TestRequestCancelUsesPipeline(t *testing.T) {
+	slow, gone := slowServer(t, 5*time.Second)
+	fast, _ := slowServer(t, 0)
+	dir := t.TempDir()
+	var validated int
+	b := New(require.New(t), WithReport(dir), WithResponseValidator(func(*http.Response) error {
+		validated++
+		return nil
+	}))
+
+	var name string
+	t.Run("cancel", func(t *testing.T) {
+		name = t.Name()
+		require.ErrorIs(t, b.RequestCancel(t, context.Background(), Get(slow), 50*time.Millisecond), context.Canceled)
+		require.NoError(t, b.RequestCancel(t, context.Background(), Get(fast), 5*time.Second))
+	})
+	<-gone
+
+	entries := readReport(t, dir, name)
+	require.Len(t, entries, 2)
+	require.Contains(t, entries[0].Error, "context canceled")
+	require.Equal(t, http.StatusOK, entries[1].Status)
+	require.Equal(t, 1, validated, "validators run on the response that arrived")
+}
+
+// uploadServer reports how many body bytes it received and whether it observed the
+// request context being canceled.
+func uploadServer(t *testing.T) (string, <-chan int, <-chan bool) {
+	received := make(chan int, 1)
+	done := make(chan bool, 1)
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		received <- int(n)
+
+		select {
+		case <-r.Context().Done():
+			done <- true
+		case <-time.After(5 * time.Second):
+			done <- false
+		}
+	})
+
+	return server.URL, received, done
+}
+
+func TestSendAndCancelHalfBody(t *testing.T) {
+	const size = 4 << 20
+	url, received, done := uploadServer(t)
+	b := New(require.New(t))
+
+	responded := b.SendAndCancel(t, context.Background(),
+		Post(url).BodyBytes("application/octet-stream", make([]byte, size)),
+		CancelAfter{BodyBytes: size / 2})
+
+	require.False(t, responded)
+	require.Less(t, <-received, size)
+	require.True(t, <-done, "the handler must observe r.Context().Done()")
+}
+
+func TestSendAndCancelUsesPipeline(t *testing.T) {
+	const size = 1 << 20
+	url, received, done := uploadServer(t)
+	dir := t.TempDir()
+	b := New(require.New(t), WithReport(dir), WithRequestCompression("gzip"))
+
+	var name string
+	t.Run("upload", func(t *testing.T) {
+		name = t.Name()
+		require.False(t, b.SendAndCancel(t, context.Background(),
+			Post(url).BodyBytes("application/octet-stream", make([]byte, size)),
+			CancelAfter{BodyBytes: 64}))
+	})
+	require.Less(t, <-received, size)
+	<-done
+
+	entries := readReport(t, dir, name)
+	require.Len(t, entries, 1)
+	require.Contains(t, entries[0].Error, "context canceled")
+}
+
+func TestSendAndCancelBodyBytesWithChecksum(t *testing.T) {
+	url, _, _ := uploadServer(t)
+
+	msg := failure(t, []Option{WithBodyChecksum("sha256", "Digest")}, func(b *Builder) {
+		b.SendAndCancel(t, context.Background(),
+			Post(url).BodyBytes("application/octet-stream", make([]byte, 1024)),
+			CancelAfter{BodyBytes: 512})
+	})
+	require.Contains(t, msg, "cannot be combined with WithBodyChecksum")
+}
+
+func TestSendAndCancelHeadersSent(t *testing.T) {
+	url, gone := slowServer(t, 5*time.Second)
+
+	responded := New(require.New(t)).SendAndCancel(t, context.Background(), Get(url), CancelAfter{HeadersSent: true})
+	require.False(t, responded)
+	require.True(t, <-gone)
+}
+
+func TestSendAndCancelDelay(t *testing.T) {
+	url, gone := slowServer(t, 5*time.Second)
+	b := New(require.New(t))
+
+	responded := b.SendAndCancel(t, context.Background(), Get(url), CancelAfter{Delay: 50 * time.Millisecond})
+	require.False(t, responded)
+	require.True(t, <-gone)
+
+	fast, _ := slowServer(t, 0)
+	require.True(t, b.SendAndCancel(t, context.Background(), Get(fast), CancelAfter{Delay: 5 * time.Second}))
+}
@@ -0,0 +1,62 @@
+package reqbuilder
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+// rawCapture holds the serialized form of the last request sent.
+type rawCapture struct {
+	mu   sync.Mutex
+	last []byte
+}
+
+// WithCaptureRawRequest records every request as serialized on the wire, including
+// chunking and transport-added headers, for retrieval with LastRawRequest.
+func WithCaptureRawRequest() Option {
+	return func(b *Builder) {
+		b.rawCapture = &rawCapture{}
+	}
+}
+
+// LastRawRequest returns the wire bytes of the last request sent by the Builder, or nil
+// when WithCaptureRawRequest is not enabled.
+func (b *Builder) LastRawRequest() []byte {
+	if b.rawCapture == nil {
+		return nil
+	}
+
+	b.rawCapture.mu.Lock()
+	defer b.rawCapture.mu.Unlock()
+
+	return b.rawCapture.last
+}
+
+// store records wire as the last request sent.
+func (c *rawCapture) store(wire []byte) {
+	c.mu.Lock()
+	c.last = wire
+	c.mu.Unlock()
+}
+
+// captureRoundTripper records each request as the transport will write it. It sits
+// directly in front of the transport, so headers added by the round-tripper chain,
+// such as WithBodyChecksum's, are included.
+type captureRoundTripper struct {
+	capture *rawCapture
+	next    http.RoundTripper
+}
+
+func (rt *captureRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	rt.capture.store(dump)
+
+	return rt.next.RoundTrip(req)
+}
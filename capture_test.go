@@ -0,0 +1,42 @@
+package reqbuilder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCaptureRawRequest(t *testing.T) {
+	var received string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+	})
+
+	b := New(require.New(t), WithCaptureRawRequest(), WithBodyChecksum("md5", "Content-MD5"))
+	require.Nil(t, New(require.New(t)).LastRawRequest())
+
+	b.Request(t, context.Background(), http.MethodPost, server.URL, "/items", []byte(`{"a":1}`), nil,
+		map[string]string{"X-Trace": "abc"}, "")
+
+	raw := string(b.LastRawRequest())
+	require.True(t, strings.HasPrefix(raw, "POST /items HTTP/1.1\r\n"), raw)
+	require.Contains(t, raw, "\r\nX-Trace: abc\r\n")
+	require.Contains(t, raw, "\r\nContent-Md5: ", "checksum header added by the round-tripper is captured")
+	require.True(t, strings.HasSuffix(raw, "\r\n\r\n{\"a\":1}"), raw)
+	require.Equal(t, `{"a":1}`, received, "capturing must not consume the body")
+}
+
+func TestWithCaptureRawRequestHTTP10(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	b := New(require.New(t), WithCaptureRawRequest(), WithHTTP10())
+	b.RequestWithoutBody(t, context.Background(), http.MethodGet, server.URL, "/old", nil, nil, "")
+
+	raw := string(b.LastRawRequest())
+	require.True(t, strings.HasPrefix(raw, "GET /old HTTP/1.0\r\n"), raw)
+}
@@ -0,0 +1,62 @@
+// Package cbor sends and decodes CBOR (RFC 8949) bodies with a reqbuilder.Builder. It
+// lives in its own package so that only tests using CBOR depend on the CBOR library.
+package cbor
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+
+	reqbuilder "github.com/zuzi90/reqbuilder-"
+)
+
+// Request encodes body as CBOR and sends it with b like Builder.Request, with
+// Content-Type: application/cbor unless headers set another one.
+func Request(
+	b *reqbuilder.Builder,
+	t *testing.T,
+	ctx context.Context,
+	method,
+	host,
+	endpoint string,
+	body any,
+	cookies []*http.Cookie,
+	headers map[string]string,
+	authorization string,
+	opts ...reqbuilder.Option) (*http.Response, []*http.Cookie) {
+	t.Helper()
+
+	reqBody, err := cbor.Marshal(body)
+	if err != nil {
+		t.Fatalf("encoding CBOR body: %v", err)
+	}
+
+	merged := map[string]string{"Content-Type": "application/cbor"}
+	for k, v := range headers {
+		if http.CanonicalHeaderKey(k) == "Content-Type" {
+			k = "Content-Type"
+		}
+		merged[k] = v
+	}
+
+	return b.Request(t, ctx, method, host, endpoint, reqBody, cookies, merged, authorization, opts...)
+}
+
+// Decode decodes the (decompressed) CBOR response body into v. Responses declaring a
+// Content-Type other than application/cbor or a +cbor type are rejected.
+func Decode(b *reqbuilder.Builder, response *http.Response, v any) error {
+	body, err := b.ReadResponseBodyAs(response, "CBOR", isMediaType)
+	if err != nil {
+		return err
+	}
+
+	return cbor.Unmarshal(body, v)
+}
+
+// isMediaType reports whether mediaType is application/cbor or a +cbor type.
+func isMediaType(mediaType string) bool {
+	return mediaType == "application/cbor" || strings.HasSuffix(mediaType, "+cbor")
+}
@@ -0,0 +1,46 @@
+package cbor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	reqbuilder "github.com/zuzi90/reqbuilder-"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		w.Header().Set("Content-Type", contentType)
+		io.Copy(w, r.Body)
+	}))
+	t.Cleanup(server.Close)
+
+	b := reqbuilder.New(require.New(t))
+	sent := map[string]any{"device": "sensor-7", "reading": 21.5, "tags": []any{"a", "b"}}
+	response, _ := Request(b, t, context.Background(), http.MethodPost, server.URL, "/telemetry", sent, nil, nil, "")
+
+	var got map[string]any
+	require.NoError(t, Decode(b, response, &got))
+	require.Equal(t, "application/cbor", contentType)
+	require.Equal(t, sent, got)
+}
+
+func TestDecodeRejectsOtherTypes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<h1>Bad Gateway</h1>"))
+	}))
+	t.Cleanup(server.Close)
+
+	b := reqbuilder.New(require.New(t))
+	response, _ := b.RequestWithoutBody(t, context.Background(), http.MethodGet, server.URL, "/", nil, nil, "")
+
+	var got map[string]any
+	require.ErrorContains(t, Decode(b, response, &got), "expected CBOR but got text/html")
+}
@@ -0,0 +1,75 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+)
+
+// bodyChecksum describes the digest header set by WithBodyChecksum.
+type bodyChecksum struct {
+	newHash func() hash.Hash
+	header  string
+}
+
+// WithBodyChecksum sets headerName to the digest of the request body as sent, after
+// WithRequestCompression, recomputed for every retry and redirect. algo is "md5",
+// "sha1", "sha256" or "sha512". Content-MD5 is base64-encoded as RFC 1864 requires;
+// other headers get lowercase hex.
+func WithBodyChecksum(algo, headerName string) Option {
+	return func(b *Builder) {
+		newHash, ok := map[string]func() hash.Hash{
+			"md5":    md5.New,
+			"sha1":   sha1.New,
+			"sha256": sha256.New,
+			"sha512": sha512.New,
+		}[algo]
+		b.require.Truef(ok, "unsupported checksum algorithm %q", algo)
+		b.checksum = &bodyChecksum{newHash: newHash, header: http.CanonicalHeaderKey(headerName)}
+	}
+}
+
+// checksumRoundTripper sets the checksum header of each request it sends.
+type checksumRoundTripper struct {
+	checksum *bodyChecksum
+	next     http.RoundTripper
+}
+
+func (rt *checksumRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := req.Clone(req.Context())
+	if req.Body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if out.ContentLength == -1 {
+		out.ContentLength = int64(len(body))
+	}
+
+	h := rt.checksum.newHash()
+	h.Write(body)
+	sum := h.Sum(nil)
+
+	if rt.checksum.header == "Content-Md5" {
+		out.Header.Set(rt.checksum.header, base64.StdEncoding.EncodeToString(sum))
+	} else {
+		out.Header.Set(rt.checksum.header, hex.EncodeToString(sum))
+	}
+
+	return rt.next.RoundTrip(out)
+}
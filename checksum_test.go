@@ -0,0 +1,57 @@
+package reqbuilder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBodyChecksum(t *testing.T) {
+	for _, tt := range []struct {
+		algo, header, want string
+	}{
+		{"md5", "Content-MD5", "nwqq6b6ua/tTDk7B5M184w=="},
+		{"sha256", "X-Content-SHA256", "9aeea8be8f23cc5543103a9da6ed06ad19867183da48395fe9f9d93d170f7522"},
+	} {
+		var got []string
+		server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+			got = append(got, r.Header.Get(tt.header))
+			io.Copy(io.Discard, r.Body)
+			if len(got) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})
+
+		b := New(require.New(t), WithBodyChecksum(tt.algo, tt.header), WithRetry(1, 0), WithClock(&sleepRecorder{}))
+		response, _ := b.Send(t, context.Background(), Put(server.URL).BodyBytes("text/plain", []byte("Check Integrity!")))
+
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, []string{tt.want, tt.want}, got, "%s must be set on every attempt", tt.header)
+	}
+}
+
+func TestWithBodyChecksumAfterCompression(t *testing.T) {
+	var header string
+	var body []byte
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Content-SHA256")
+		body, _ = io.ReadAll(r.Body)
+	})
+
+	b := New(require.New(t), WithRequestCompression("gzip"), WithBodyChecksum("sha256", "X-Content-SHA256"))
+	b.Send(t, context.Background(), Post(server.URL).BodyBytes("text/plain", []byte("hello hello hello")))
+
+	require.Equal(t, hashHex(string(body)), header, "the digest covers the encoded body")
+	require.NotEqual(t, hashHex("hello hello hello"), header)
+}
+
+func hashHex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,168 @@
+package reqbuilder
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for time-dependent features such as retry backoff,
+// bandwidth throttling, injected latency and timing metrics.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel that receives the time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+	// Sleep waits for d or until ctx is done, returning ctx.Err() in the latter case.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// WithClock replaces the wall clock used by time-dependent features, e.g. with a
+// FakeClock so that tests of retry backoff do not have to wait.
+func WithClock(clock Clock) Option {
+	return func(b *Builder) {
+		b.clock = clock
+	}
+}
+
+// realClock is the wall clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FakeClock is a Clock that only moves when advanced. Sleepers wake up once Advance
+// moves the clock past their deadline.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+	changed chan struct{}
+}
+
+// fakeWaiter is a pending After or Sleep call.
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFakeClock returns a FakeClock set to start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start, changed: make(chan struct{})}
+}
+
+// Now returns the fake current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After returns a channel that receives the fake time once the clock has been
+// advanced by d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	c.notify()
+
+	return ch
+}
+
+// Sleep waits until the clock has been advanced by d or ctx is done. A sleeper that
+// gives up on ctx no longer counts as pending for BlockUntil.
+func (c *FakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	ch := c.After(d)
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		c.remove(ch)
+		return ctx.Err()
+	}
+}
+
+// Advance moves the clock forward by d, waking the sleepers whose deadline has passed.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	sort.Slice(c.waiters, func(i, j int) bool {
+		return c.waiters[i].deadline.Before(c.waiters[j].deadline)
+	})
+
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+	c.notify()
+}
+
+// BlockUntil waits until n After or Sleep calls are pending, so a test can advance the
+// clock only once the code under test has started waiting.
+func (c *FakeClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		pending, changed := len(c.waiters), c.changed
+		c.mu.Unlock()
+
+		if pending >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+// remove drops the pending waiter that receives on ch, if Advance has not fired it yet.
+func (c *FakeClock) remove(ch <-chan time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, w := range c.waiters {
+		if w.ch == ch {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			c.notify()
+			return
+		}
+	}
+}
+
+// notify wakes BlockUntil callers. c.mu must be held.
+func (c *FakeClock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
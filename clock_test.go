@@ -0,0 +1,135 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// advanceSleepers moves clock to each sleeper's deadline as soon as it starts waiting,
+// until the test ends, and returns a function listing the waits it advanced through.
+func advanceSleepers(t *testing.T, clock *FakeClock) func() []time.Duration {
+	var (
+		mu      sync.Mutex
+		sleeps  []time.Duration
+		stopped atomic.Bool
+	)
+
+	go func() {
+		for {
+			clock.BlockUntil(1)
+			if stopped.Load() {
+				return
+			}
+
+			clock.mu.Lock()
+			if len(clock.waiters) == 0 {
+				clock.mu.Unlock()
+				continue
+			}
+			next := clock.waiters[0].deadline
+			for _, w := range clock.waiters[1:] {
+				if w.deadline.Before(next) {
+					next = w.deadline
+				}
+			}
+			d := next.Sub(clock.now)
+			clock.mu.Unlock()
+
+			mu.Lock()
+			sleeps = append(sleeps, d)
+			mu.Unlock()
+			clock.Advance(d)
+		}
+	}()
+
+	t.Cleanup(func() {
+		stopped.Store(true)
+		clock.After(time.Hour) // wakes the BlockUntil above
+	})
+
+	return func() []time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return slices.Clone(sleeps)
+	}
+}
+
+func TestFakeClockSleepCancelled(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- clock.Sleep(ctx, time.Minute) }()
+
+	clock.BlockUntil(1)
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	// The cancelled sleeper must not satisfy BlockUntil for the next one.
+	woke := make(chan error, 1)
+	go func() { woke <- clock.Sleep(context.Background(), time.Second) }()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	require.NoError(t, <-woke)
+
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	require.Empty(t, clock.waiters)
+}
+
+func TestWithRetryFakeClock(t *testing.T) {
+	calls, url := flakyServer(t, 3, http.StatusServiceUnavailable)
+	clock := NewFakeClock(time.Unix(0, 0))
+
+	b := New(require.New(t), WithRetry(5, time.Hour), WithClock(clock))
+
+	start := time.Now()
+	status := make(chan int, 1)
+	go func() {
+		response, _ := b.Send(t, context.Background(), Get(url))
+		status <- response.StatusCode
+	}()
+
+	for i, backoff := range []time.Duration{time.Hour, 2 * time.Hour, 4 * time.Hour} {
+		clock.BlockUntil(1)
+		clock.Advance(backoff - time.Nanosecond)
+		require.EqualValues(t, i+1, calls.Load(), "retried before the backoff elapsed")
+		clock.Advance(time.Nanosecond)
+	}
+
+	require.Equal(t, http.StatusOK, <-status)
+	require.EqualValues(t, 4, calls.Load())
+	require.Equal(t, time.Unix(0, 0).Add(7*time.Hour), clock.Now())
+	require.Less(t, time.Since(start), time.Second, "backoff must follow the fake clock")
+}
+
+func TestWithRetryFakeClockCancelled(t *testing.T) {
+	calls, url := flakyServer(t, 10, http.StatusServiceUnavailable)
+	clock := NewFakeClock(time.Unix(0, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		clock.BlockUntil(1)
+		cancel()
+	}()
+
+	message := failure(t, []Option{WithRetry(5, time.Hour), WithClock(clock)}, func(b *Builder) {
+		b.Send(t, ctx, Get(url))
+	})
+
+	require.Contains(t, message, "context canceled")
+	require.EqualValues(t, 1, calls.Load())
+
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	require.Empty(t, clock.waiters)
+}
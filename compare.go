@@ -0,0 +1,190 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CompareOptions tunes CompareResponses.
+type CompareOptions struct {
+	// IgnorePaths are JSONPaths such as "$.timestamp" or "$.items[*].id" whose values,
+	// including everything below them, are not compared.
+	IgnorePaths []string
+	// IgnoreHeaders are response headers that are not compared, in addition to Date
+	// and Content-Length, which differ between otherwise equal responses.
+	IgnoreHeaders []string
+}
+
+// CompareResponses fails the test unless left and right have the same status, the same
+// headers and semantically equal bodies: JSON bodies are compared as decoded documents,
+// so formatting and member order do not matter, and other bodies byte for byte. All
+// differences are reported together.
+func (b *Builder) CompareResponses(left, right *http.Response, opts CompareOptions) {
+	b.require.NotNil(left, "no left response")
+	b.require.NotNil(right, "no right response")
+
+	var diffs []string
+
+	if left.StatusCode != right.StatusCode {
+		diffs = append(diffs, fmt.Sprintf("status: %d != %d", left.StatusCode, right.StatusCode))
+	}
+
+	ignoredHeaders := []string{"Date", "Content-Length"}
+	for _, key := range opts.IgnoreHeaders {
+		ignoredHeaders = append(ignoredHeaders, http.CanonicalHeaderKey(key))
+	}
+	diffs = append(diffs, diffHeaders(left.Header, right.Header, ignoredHeaders)...)
+
+	leftBody, rightBody := b.requireBody(left), b.requireBody(right)
+	diffs = append(diffs, diffBodies(leftBody, rightBody, compileIgnorePaths(opts.IgnorePaths))...)
+
+	if len(diffs) != 0 {
+		b.require.Failf("responses differ", "%s\n\nleft: %s\n\nright: %s",
+			strings.Join(diffs, "\n"), b.describe(left), b.describe(right))
+	}
+}
+
+// diffHeaders lists the headers that differ, except the ignored ones.
+func diffHeaders(left, right http.Header, ignored []string) []string {
+	keys := map[string]bool{}
+	for k := range left {
+		keys[k] = true
+	}
+	for k := range right {
+		keys[k] = true
+	}
+
+	var diffs []string
+	for k := range keys {
+		if slices.Contains(ignored, http.CanonicalHeaderKey(k)) {
+			continue
+		}
+		if !slices.Equal(left[k], right[k]) {
+			diffs = append(diffs, fmt.Sprintf("header %s: %q != %q", k, redactValues(k, left[k]), redactValues(k, right[k])))
+		}
+	}
+	sort.Strings(diffs)
+
+	return diffs
+}
+
+// diffBodies compares two bodies, as JSON documents when both decode.
+func diffBodies(left, right []byte, ignore []*regexp.Regexp) []string {
+	var leftDoc, rightDoc any
+	if json.Unmarshal(left, &leftDoc) != nil || json.Unmarshal(right, &rightDoc) != nil {
+		if bytes.Equal(left, right) {
+			return nil
+		}
+		return []string{fmt.Sprintf("body: %s != %s", excerpt(left, 256), excerpt(right, 256))}
+	}
+
+	var diffs []string
+	diffJSON("$", leftDoc, rightDoc, ignore, &diffs)
+
+	return diffs
+}
+
+// diffJSON appends the differences between two decoded JSON values to diffs.
+func diffJSON(path string, left, right any, ignore []*regexp.Regexp, diffs *[]string) {
+	for _, re := range ignore {
+		if re.MatchString(path) {
+			return
+		}
+	}
+
+	switch l := left.(type) {
+	case map[string]any:
+		r, ok := right.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(l)+len(r))
+		for k := range l {
+			keys = append(keys, k)
+		}
+		for k := range r {
+			if _, ok := l[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			lv, lok := l[k]
+			rv, rok := r[k]
+			child := path + "." + k
+			switch {
+			case !lok:
+				diffJSONMissing(child, "missing", jsonText(rv), ignore, diffs)
+			case !rok:
+				diffJSONMissing(child, jsonText(lv), "missing", ignore, diffs)
+			default:
+				diffJSON(child, lv, rv, ignore, diffs)
+			}
+		}
+		return
+	case []any:
+		r, ok := right.([]any)
+		if !ok {
+			break
+		}
+
+		for i := range max(len(l), len(r)) {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(l):
+				diffJSONMissing(child, "missing", jsonText(r[i]), ignore, diffs)
+			case i >= len(r):
+				diffJSONMissing(child, jsonText(l[i]), "missing", ignore, diffs)
+			default:
+				diffJSON(child, l[i], r[i], ignore, diffs)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(left, right) {
+		*diffs = append(*diffs, fmt.Sprintf("body %s: %s != %s", path, jsonText(left), jsonText(right)))
+	}
+}
+
+// diffJSONMissing records a member or element present on one side only, unless ignored.
+func diffJSONMissing(path, left, right string, ignore []*regexp.Regexp, diffs *[]string) {
+	for _, re := range ignore {
+		if re.MatchString(path) {
+			return
+		}
+	}
+
+	*diffs = append(*diffs, fmt.Sprintf("body %s: %s != %s", path, left, right))
+}
+
+// jsonText formats a decoded JSON value for a diff.
+func jsonText(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return excerpt(data, 256)
+}
+
+// compileIgnorePaths turns JSONPaths with optional [*] wildcards into patterns matching
+// the paths produced by diffJSON.
+func compileIgnorePaths(paths []string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(paths))
+	for _, path := range paths {
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(path), `\[\*\]`, `\[\d+\]`)
+		patterns = append(patterns, regexp.MustCompile("^"+pattern+"$"))
+	}
+
+	return patterns
+}
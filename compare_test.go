@@ -0,0 +1,74 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func jsonServer(t *testing.T, status int, requestID, body string) string {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", requestID)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	})
+
+	return server.URL
+}
+
+func TestCompareResponses(t *testing.T) {
+	legacy := jsonServer(t, http.StatusOK, "1", `{"name":"ann","timestamp":"2024-01-01T00:00:00Z","items":[{"id":1,"sku":"a"}]}`)
+	next := jsonServer(t, http.StatusOK, "2", `{
+		"items": [{"sku": "a", "id": 1}],
+		"timestamp": "2024-06-30T12:00:00Z",
+		"name": "ann"
+	}`)
+
+	b := New(require.New(t))
+	left, _ := b.Send(t, context.Background(), Get(legacy))
+	right, _ := b.Send(t, context.Background(), Get(next))
+
+	b.CompareResponses(left, right, CompareOptions{IgnorePaths: []string{"$.timestamp"}, IgnoreHeaders: []string{"x-request-id"}})
+}
+
+func TestCompareResponsesMismatch(t *testing.T) {
+	legacy := jsonServer(t, http.StatusOK, "1", `{"name":"ann","items":[{"id":1,"sku":"a"},{"id":2,"sku":"b"}]}`)
+	next := jsonServer(t, http.StatusCreated, "1", `{"name":"bob","items":[{"id":7,"sku":"a"}]}`)
+
+	msg := failure(t, nil, func(b *Builder) {
+		left, _ := b.Send(t, context.Background(), Get(legacy))
+		right, _ := b.Send(t, context.Background(), Get(next))
+		b.CompareResponses(left, right, CompareOptions{IgnorePaths: []string{"$.items[*].id"}})
+	})
+
+	require.Contains(t, msg, "status: 200 != 201\n")
+	require.Contains(t, msg, `body $.items[1]: {"id":2,"sku":"b"} != missing`)
+	require.Contains(t, msg, `body $.name: "ann" != "bob"`)
+	require.NotContains(t, msg, "$.items[0].id")
+}
+
+func TestCompareResponsesRedactsHeaders(t *testing.T) {
+	server := func(session, key string) string {
+		return newServer(t, func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: session})
+			w.Header().Set("X-Api-Key", key)
+			w.Header().Set("X-Version", key[:2])
+		}).URL
+	}
+	legacy, next := server("s3cret-a", "v1-k3y-a"), server("s3cret-b", "v2-k3y-b")
+
+	msg := failure(t, nil, func(b *Builder) {
+		left, _ := b.Send(t, context.Background(), Get(legacy))
+		right, _ := b.Send(t, context.Background(), Get(next))
+		b.CompareResponses(left, right, CompareOptions{})
+	})
+
+	require.Contains(t, msg, `header Set-Cookie: ["session=<redacted>"] != ["session=<redacted>"]`)
+	require.Contains(t, msg, `header X-Version: ["v1"] != ["v2"]`)
+	for _, secret := range []string{"s3cret", "k3y"} {
+		require.NotContains(t, msg, secret)
+	}
+}
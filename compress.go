@@ -0,0 +1,164 @@
+package reqbuilder
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+)
+
+// WithRequestCompression compresses request bodies with encoding, one of "gzip", "br",
+// "zstd" or "deflate", and sets Content-Encoding accordingly. "deflate" is sent in the
+// zlib format, as RFC 9110 defines it. Content-Length is the compressed size; bodies
+// that cannot be replayed are compressed while streaming and sent chunked.
+func WithRequestCompression(encoding string) Option {
+	return func(b *Builder) {
+		b.require.Truef(slices.Contains([]string{"gzip", "br", "zstd", "deflate"}, encoding),
+			"unsupported request encoding %q", encoding)
+		b.requestEncoding = encoding
+	}
+}
+
+// compressBody replaces the request body with its compressed form and fixes up
+// Content-Length, which otherwise still describes the uncompressed body.
+func (b *Builder) compressBody(t *testing.T, req *http.Request) {
+	t.Helper()
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	req.Header.Set("Content-Encoding", b.requestEncoding)
+
+	if req.GetBody == nil {
+		pr, pw := io.Pipe()
+		body := req.Body
+		go func() {
+			defer body.Close()
+			pw.CloseWithError(compressTo(pw, b.requestEncoding, body))
+		}()
+
+		req.Body = pr
+		req.ContentLength = -1
+
+		return
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+	defer body.Close()
+
+	var buf bytes.Buffer
+	err = compressTo(&buf, b.requestEncoding, body)
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	compressed := buf.Bytes()
+	req.Body = io.NopCloser(bytes.NewReader(compressed))
+	req.ContentLength = int64(len(compressed))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(compressed)), nil
+	}
+}
+
+// compressTo writes r to w compressed with encoding.
+func compressTo(w io.Writer, encoding string, r io.Reader) error {
+	var encoder io.WriteCloser
+
+	switch encoding {
+	case "gzip":
+		encoder = gzip.NewWriter(w)
+	case "br":
+		encoder = brotli.NewWriter(w)
+	case "zstd":
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		encoder = zw
+	case "deflate":
+		encoder = zlib.NewWriter(w)
+	default:
+		return fmt.Errorf("unsupported request encoding %q", encoding)
+	}
+
+	if _, err := io.Copy(encoder, r); err != nil {
+		encoder.Close()
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// EncodeGzip returns data gzip-compressed, for building encoded responses in test
+// servers. The Encode functions use the libraries ReadResponseBody decodes with.
+func EncodeGzip(data []byte) ([]byte, error) {
+	return encode("gzip", data)
+}
+
+// EncodeBrotli returns data brotli-compressed.
+func EncodeBrotli(data []byte) ([]byte, error) {
+	return encode("br", data)
+}
+
+// EncodeZstd returns data zstd-compressed.
+func EncodeZstd(data []byte) ([]byte, error) {
+	return encode("zstd", data)
+}
+
+// EncodeDeflate returns data in the "deflate" content coding, exactly as
+// WithRequestCompression("deflate") sends it: the zlib format RFC 9110 specifies.
+func EncodeDeflate(data []byte) ([]byte, error) {
+	return encode("deflate", data)
+}
+
+// EncodeZlib returns data in the zlib format. It is not a content coding of its own;
+// ReadResponseBody accepts it, and raw DEFLATE streams, for Content-Encoding: deflate.
+func EncodeZlib(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encode compresses data in memory.
+func encode(encoding string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := compressTo(&buf, encoding, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// deflateReader decodes a "deflate" body, which servers send either zlib-wrapped, as
+// the RFC says, or as a raw DEFLATE stream.
+func deflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(2)
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+
+	return flate.NewReader(br), nil
+}
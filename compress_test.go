@@ -0,0 +1,162 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/zlib"
+	"github.com/stretchr/testify/require"
+)
+
+// compressionServer records the request's Content-Length, Transfer-Encoding and
+// decoded body.
+type compressionServer struct {
+	contentLength    int64
+	transferEncoding []string
+	encoding         string
+	wire             []byte
+	body             []byte
+}
+
+func (s *compressionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.contentLength, s.transferEncoding = r.ContentLength, r.TransferEncoding
+	s.encoding = r.Header.Get("Content-Encoding")
+	s.wire, _ = io.ReadAll(r.Body)
+
+	decoder, err := decodingReader(s.encoding, bytes.NewReader(s.wire))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	s.body, _ = io.ReadAll(decoder)
+}
+
+func TestWithRequestCompressionContentLength(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"name":"widget","tags":["a","b"]},`, 200))
+
+	for _, encoding := range []string{"gzip", "br", "zstd", "deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			handler := &compressionServer{}
+			server := newServer(t, handler.ServeHTTP)
+
+			b := New(require.New(t), WithRequestCompression(encoding))
+			response, _ := b.Request(t, context.Background(), http.MethodPost, server.URL, "/", payload, nil, nil, "")
+
+			require.Equal(t, http.StatusOK, response.StatusCode)
+			require.Equal(t, encoding, handler.encoding)
+			require.EqualValues(t, len(handler.wire), handler.contentLength, "Content-Length must be the compressed size")
+			require.Less(t, len(handler.wire), len(payload))
+			require.Empty(t, handler.transferEncoding)
+			require.Equal(t, payload, handler.body)
+		})
+	}
+}
+
+func TestWithRequestCompressionStreamed(t *testing.T) {
+	handler := &compressionServer{}
+	server := newServer(t, handler.ServeHTTP)
+	payload := bytes.Repeat([]byte("stream "), 1000)
+
+	b := New(require.New(t), WithRequestCompression("gzip"))
+
+	// A body without GetBody cannot be compressed up front, so it is sent chunked.
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, io.MultiReader(bytes.NewReader(payload)))
+	require.NoError(t, err)
+	b.do(t, req)
+
+	require.EqualValues(t, -1, handler.contentLength)
+	require.Equal(t, []string{"chunked"}, handler.transferEncoding)
+	require.Equal(t, payload, handler.body)
+}
+
+func TestWithRequestCompressionUnsupported(t *testing.T) {
+	// The encoding is checked when the option is applied, before any request.
+	msg := failure(t, []Option{WithRequestCompression("lzma")}, func(*Builder) {
+		t.Fatal("New must fail")
+	})
+	require.Contains(t, msg, `unsupported request encoding "lzma"`)
+}
+
+func TestEncodeDeflateMatchesRequestCompression(t *testing.T) {
+	handler := &compressionServer{}
+	server := newServer(t, handler.ServeHTTP)
+	payload := bytes.Repeat([]byte("deflate "), 100)
+
+	b := New(require.New(t), WithRequestCompression("deflate"))
+	b.Request(t, context.Background(), http.MethodPost, server.URL, "/", payload, nil, nil, "")
+
+	encoded, err := EncodeDeflate(payload)
+	require.NoError(t, err)
+	require.Equal(t, handler.wire, encoded)
+
+	_, err = encode("zlib", payload)
+	require.ErrorContains(t, err, `unsupported request encoding "zlib"`)
+}
+
+func TestWithRequestCompressionDeflateIsZlib(t *testing.T) {
+	handler := &compressionServer{}
+	server := newServer(t, handler.ServeHTTP)
+	payload := bytes.Repeat([]byte("deflate "), 100)
+
+	b := New(require.New(t), WithRequestCompression("deflate"))
+	b.Request(t, context.Background(), http.MethodPost, server.URL, "/", payload, nil, nil, "")
+
+	reader, err := zlib.NewReader(bytes.NewReader(handler.wire))
+	require.NoError(t, err, "RFC 9110 deflate is zlib-wrapped")
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, payload, body)
+}
+
+// rawDeflate returns data as a raw DEFLATE stream without the zlib wrapper, as some
+// servers send Content-Encoding: deflate.
+func rawDeflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := fw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	original := bytes.Repeat([]byte(`{"id":1,"name":"ada"}`), 100)
+	b := New(require.New(t))
+
+	for _, tt := range []struct {
+		encoding string
+		encode   func([]byte) ([]byte, error)
+	}{
+		{"gzip", EncodeGzip},
+		{"br", EncodeBrotli},
+		{"zstd", EncodeZstd},
+		{"deflate", EncodeDeflate},
+		{"deflate", EncodeZlib},
+		{"deflate", rawDeflate},
+	} {
+		encoded, err := tt.encode(original)
+		require.NoError(t, err)
+		require.Less(t, len(encoded), len(original), tt.encoding)
+
+		response := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Encoding": {tt.encoding}},
+			Body:       io.NopCloser(bytes.NewReader(encoded)),
+		}
+		body, err := b.ReadResponseBody(response)
+		require.NoError(t, err, tt.encoding)
+		require.Equal(t, original, body, tt.encoding)
+	}
+}
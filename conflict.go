@@ -0,0 +1,79 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// ConflictConfig configures ConcurrentUpdateTest.
+type ConflictConfig struct {
+	// Modify changes the resource out of band after it was read, for example through
+	// another Session. When nil the update itself is sent first with a fresh
+	// precondition, as if a second client won the race.
+	Modify func(t *testing.T, ctx context.Context)
+
+	// Status is the status expected for the stale update, 412 Precondition Failed when
+	// zero. APIs that report conflicts as 409 set it here.
+	Status int
+}
+
+// ConcurrentUpdateTest simulates two clients editing the same resource. It reads
+// getURL and remembers its ETag, falling back to Last-Modified; lets config.Modify
+// change the resource; then sends update with the stale If-Match, or
+// If-Unmodified-Since, and expects the conflict status. A weak ETag never matches
+// If-Match, which compares strongly, so Last-Modified is used instead and the test
+// fails when there is none. update carries the cookies and Authorization for the
+// read as well.
+// It returns the read and the rejected update's responses.
+func (b *Builder) ConcurrentUpdateTest(
+	t *testing.T,
+	ctx context.Context,
+	getURL string,
+	update *RequestSpec,
+	config ConflictConfig,
+) (original, conflict *http.Response) {
+	t.Helper()
+
+	read := Get(getURL)
+	read.Cookies = update.Cookies
+	read.Authorization = update.Authorization
+
+	original, _ = b.Send(t, ctx, read)
+	b.ExpectSuccess(t, original)
+
+	header, value := "If-Match", original.Header.Get("ETag")
+	weak := strings.HasPrefix(value, "W/")
+	if value == "" || weak {
+		header, value = "If-Unmodified-Since", original.Header.Get("Last-Modified")
+	}
+	if value == "" && weak {
+		b.require.Failf("no usable validator", "%s has only the weak ETag %s, which never matches If-Match, and no Last-Modified\n%s",
+			getURL, original.Header.Get("ETag"), b.describe(original))
+	}
+	if value == "" {
+		b.require.Failf("no validator", "%s has neither ETag nor Last-Modified\n%s", getURL, b.describe(original))
+	}
+
+	if config.Modify != nil {
+		config.Modify(t, ctx)
+	} else {
+		winner, _ := b.Send(t, ctx, update.clone().SetHeader(header, value))
+		b.ExpectSuccess(t, winner)
+		winner.Body.Close()
+	}
+
+	status := config.Status
+	if status == 0 {
+		status = http.StatusPreconditionFailed
+	}
+
+	conflict, _ = b.Send(t, ctx, update.clone().SetHeader(header, value))
+	if conflict.StatusCode != status {
+		b.require.Failf("stale update was not rejected", "sent %s: %s, expected %d\n%s",
+			header, value, status, b.describe(conflict))
+	}
+
+	return original, conflict
+}
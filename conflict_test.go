@@ -0,0 +1,102 @@
+package reqbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// versionedServer serves a resource whose version goes up with every accepted PUT.
+// Depending on the path it validates with a strong ETag, a weak ETag plus
+// Last-Modified, or a weak ETag alone.
+type versionedServer struct {
+	mu            sync.Mutex
+	version       int
+	preconditions []string
+}
+
+func (s *versionedServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	modified := base.Add(time.Duration(s.version) * time.Second)
+	strong := fmt.Sprintf(`"v%d"`, s.version)
+
+	if r.Method == http.MethodGet {
+		switch r.URL.Path {
+		case "/strong":
+			w.Header().Set("ETag", strong)
+		case "/weak":
+			w.Header().Set("ETag", "W/"+strong)
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		case "/weak-only":
+			w.Header().Set("ETag", "W/"+strong)
+		}
+		return
+	}
+
+	switch {
+	case r.Header.Get("If-Match") != "":
+		s.preconditions = append(s.preconditions, "If-Match")
+		if r.Header.Get("If-Match") != strong {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+	case r.Header.Get("If-Unmodified-Since") != "":
+		s.preconditions = append(s.preconditions, "If-Unmodified-Since")
+		since, err := http.ParseTime(r.Header.Get("If-Unmodified-Since"))
+		if err != nil || modified.After(since) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusPreconditionRequired)
+		return
+	}
+
+	s.version++
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestConcurrentUpdateTest(t *testing.T) {
+	resource := &versionedServer{}
+	url := newServer(t, resource.serveHTTP).URL
+
+	alice := New(require.New(t))
+	bob := New(require.New(t))
+
+	original, conflict := alice.ConcurrentUpdateTest(t, context.Background(), url+"/strong", NewSpec(http.MethodPut, url+"/strong"),
+		ConflictConfig{Modify: func(t *testing.T, ctx context.Context) {
+			response, _ := bob.Send(t, ctx, NewSpec(http.MethodPut, url+"/strong").SetHeader("If-Match", `"v0"`))
+			require.Equal(t, http.StatusNoContent, response.StatusCode)
+		}})
+
+	require.Equal(t, `"v0"`, original.Header.Get("ETag"))
+	require.Equal(t, http.StatusPreconditionFailed, conflict.StatusCode)
+	require.Equal(t, []string{"If-Match", "If-Match"}, resource.preconditions)
+	require.Equal(t, 1, resource.version, "only the second client's update is applied")
+}
+
+func TestConcurrentUpdateTestWeakETag(t *testing.T) {
+	resource := &versionedServer{}
+	url := newServer(t, resource.serveHTTP).URL
+
+	b := New(require.New(t))
+	_, conflict := b.ConcurrentUpdateTest(t, context.Background(), url+"/weak", NewSpec(http.MethodPut, url+"/weak"), ConflictConfig{})
+
+	require.Equal(t, http.StatusPreconditionFailed, conflict.StatusCode)
+	require.Equal(t, []string{"If-Unmodified-Since", "If-Unmodified-Since"}, resource.preconditions,
+		"a weak ETag falls back to Last-Modified")
+	require.Equal(t, 1, resource.version)
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.ConcurrentUpdateTest(t, context.Background(), url+"/weak-only", NewSpec(http.MethodPut, url+"/weak-only"), ConflictConfig{})
+	})
+	require.Contains(t, msg, `has only the weak ETag W/"v1", which never matches If-Match, and no Last-Modified`)
+}
@@ -0,0 +1,69 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Preflight sends the CORS preflight request a browser makes before a cross-origin
+// request with method and the non-safelisted headers reqHeaders: OPTIONS with Origin,
+// Access-Control-Request-Method and, unless reqHeaders is empty,
+// Access-Control-Request-Headers. Check the response with RequireCORS.
+func (b *Builder) Preflight(
+	t *testing.T,
+	ctx context.Context,
+	host,
+	endpoint,
+	origin,
+	method string,
+	reqHeaders []string,
+	opts ...Option) *http.Response {
+	t.Helper()
+
+	spec := NewSpec(http.MethodOptions, host+endpoint).
+		SetHeader("Origin", origin).
+		SetHeader("Access-Control-Request-Method", method)
+	if len(reqHeaders) != 0 {
+		spec.SetHeader("Access-Control-Request-Headers", strings.ToLower(strings.Join(reqHeaders, ",")))
+	}
+
+	response, _ := b.With(opts...).Send(t, ctx, spec)
+
+	return response
+}
+
+// RequireCORS fails the test unless Access-Control-Allow-Origin is wantAllowOrigin and
+// Access-Control-Allow-Methods lists at least wantAllowMethods, or is "*".
+func (b *Builder) RequireCORS(response *http.Response, wantAllowOrigin string, wantAllowMethods ...string) {
+	b.require.NotNil(response, "no response")
+
+	if got := response.Header.Get("Access-Control-Allow-Origin"); got != wantAllowOrigin {
+		b.require.Failf("unexpected Access-Control-Allow-Origin", "expected %q, got %q\n%s",
+			wantAllowOrigin, got, b.describe(response))
+	}
+
+	var listed []string
+	for _, value := range response.Header.Values("Access-Control-Allow-Methods") {
+		listed = append(listed, strings.Split(value, ",")...)
+	}
+	allowed := normalizeMethods(listed)
+	if slices.Contains(allowed, "*") {
+		return
+	}
+
+	var missing []string
+	for _, m := range normalizeMethods(wantAllowMethods) {
+		if !slices.Contains(allowed, m) {
+			missing = append(missing, m)
+		}
+	}
+
+	if len(missing) != 0 {
+		b.require.Failf("unexpected Access-Control-Allow-Methods", "expected %s, got %s\nmissing: %s\n%s",
+			strings.Join(normalizeMethods(wantAllowMethods), ", "), strings.Join(allowed, ", "),
+			strings.Join(missing, ", "), b.describe(response))
+	}
+}
@@ -0,0 +1,62 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// corsServer answers preflights from https://app.example with the allow headers and
+// records the preflight request headers.
+func corsServer(t *testing.T, allowMethods string) (string, *http.Header) {
+	var preflight http.Header
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		preflight = r.Header.Clone()
+		if r.Method != http.MethodOptions || r.Header.Get("Origin") != "https://app.example" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", "https://app.example")
+		w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+		w.Header().Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return server.URL, &preflight
+}
+
+func TestPreflight(t *testing.T) {
+	host, preflight := corsServer(t, "GET, POST,delete")
+
+	b := New(require.New(t))
+	response := b.Preflight(t, context.Background(), host, "/events", "https://app.example", http.MethodDelete,
+		[]string{"X-Request-Id", "Content-Type"})
+
+	require.Equal(t, "https://app.example", preflight.Get("Origin"))
+	require.Equal(t, "DELETE", preflight.Get("Access-Control-Request-Method"))
+	require.Equal(t, "x-request-id,content-type", preflight.Get("Access-Control-Request-Headers"))
+	b.RequireCORS(response, "https://app.example", "DELETE", "post")
+
+	b.Preflight(t, context.Background(), host, "/events", "https://app.example", http.MethodGet, nil)
+	require.NotContains(t, *preflight, "Access-Control-Request-Headers")
+
+	host, _ = corsServer(t, "*")
+	b.RequireCORS(b.Preflight(t, context.Background(), host, "/", "https://app.example", "PURGE", nil), "https://app.example", "PURGE")
+}
+
+func TestRequireCORSFailures(t *testing.T) {
+	host, _ := corsServer(t, "GET")
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.RequireCORS(b.Preflight(t, context.Background(), host, "/", "https://evil.example", http.MethodGet, nil), "https://evil.example")
+	})
+	require.Contains(t, msg, `expected "https://evil.example", got ""`)
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.RequireCORS(b.Preflight(t, context.Background(), host, "/", "https://app.example", http.MethodPut, nil), "https://app.example", "GET", "PUT")
+	})
+	require.Contains(t, msg, "unexpected Access-Control-Allow-Methods")
+	require.Contains(t, msg, "missing: PUT")
+}
@@ -0,0 +1,419 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// curlNoopFlags only affect curl's own output and are accepted without effect.
+var curlNoopFlags = map[string]bool{
+	"-s":           true,
+	"--silent":     true,
+	"-S":           true,
+	"--show-error": true,
+	"-v":           true,
+	"--verbose":    true,
+	"-i":           true,
+	"--include":    true,
+	"-L":           true,
+	"--location":   true,
+	"-g":           true,
+	"--globoff":    true,
+}
+
+// curlValueFlags take an argument; short forms may be attached, as in -XPOST.
+var curlValueFlags = map[string]string{
+	"-X":               "-X",
+	"--request":        "-X",
+	"-H":               "-H",
+	"--header":         "-H",
+	"-d":               "-d",
+	"--data":           "-d",
+	"--data-ascii":     "-d",
+	"--data-raw":       "--data-raw",
+	"--data-binary":    "--data-binary",
+	"--data-urlencode": "--data-urlencode",
+	"-F":               "-F",
+	"--form":           "-F",
+	"-u":               "-u",
+	"--user":           "-u",
+	"-b":               "-b",
+	"--cookie":         "-b",
+	"-A":               "-A",
+	"--user-agent":     "-A",
+	"-e":               "-e",
+	"--referer":        "-e",
+	"--url":            "--url",
+}
+
+// FromCurl parses a curl command line, as pasted from a ticket or a browser's
+// "Copy as cURL", into a RequestSpec. It understands -X, -H, -d and its --data variants,
+// -G, -F (including @file parts), -u, -b, -A, -e, -I and --compressed; flags that only
+// change curl's output are ignored and any other flag is reported as an error by name.
+// File references are resolved relative to the working directory.
+func FromCurl(t *testing.T, cmd string) (*RequestSpec, error) {
+	t.Helper()
+
+	args, err := splitShell(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.New("curl: command must start with curl")
+	}
+
+	var (
+		method    string
+		target    string
+		data      []string
+		getData   bool
+		form      []string
+		header    = http.Header{}
+		cookies   []*http.Cookie
+		basicAuth string
+	)
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if target != "" {
+				return nil, fmt.Errorf("curl: unexpected argument %q", arg)
+			}
+			target = arg
+			continue
+		}
+
+		if curlNoopFlags[arg] {
+			continue
+		}
+
+		switch arg {
+		case "--compressed":
+			header.Set("Accept-Encoding", "deflate, gzip, br, zstd")
+			continue
+		case "-G", "--get":
+			getData = true
+			continue
+		case "-I", "--head":
+			method = http.MethodHead
+			continue
+		}
+
+		flag, value, err := curlFlagValue(args, &i)
+		if err != nil {
+			return nil, err
+		}
+
+		switch flag {
+		case "-X":
+			method = value
+		case "-H":
+			key, v, ok := strings.Cut(value, ":")
+			if !ok {
+				return nil, fmt.Errorf("curl: malformed header %q", value)
+			}
+			header.Add(strings.TrimSpace(key), strings.TrimSpace(v))
+		case "-d", "--data-binary":
+			if strings.HasPrefix(value, "@") {
+				content, err := os.ReadFile(value[1:])
+				if err != nil {
+					return nil, fmt.Errorf("curl: %s: %w", flag, err)
+				}
+				if flag == "-d" {
+					content = bytes.ReplaceAll(bytes.ReplaceAll(content, []byte("\r"), nil), []byte("\n"), nil)
+				}
+				value = string(content)
+			}
+			data = append(data, value)
+		case "--data-raw":
+			data = append(data, value)
+		case "--data-urlencode":
+			encoded, err := curlURLEncode(value)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, encoded)
+		case "-F":
+			form = append(form, value)
+		case "-u":
+			basicAuth = value
+		case "-b":
+			if !strings.Contains(value, "=") {
+				return nil, fmt.Errorf("curl: -b with a cookie file is not supported")
+			}
+			for _, pair := range strings.Split(value, ";") {
+				name, v, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				if name != "" {
+					cookies = append(cookies, &http.Cookie{Name: name, Value: v})
+				}
+			}
+		case "-A":
+			header.Set("User-Agent", value)
+		case "-e":
+			header.Set("Referer", value)
+		case "--url":
+			target = value
+		}
+	}
+
+	if target == "" {
+		return nil, errors.New("curl: no URL")
+	}
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+
+	spec := NewSpec(http.MethodGet, target)
+	spec.Headers = header
+	spec.Cookies = cookies
+
+	if basicAuth != "" {
+		spec.Authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(basicAuth))
+	}
+
+	switch {
+	case len(form) != 0:
+		body, contentType, err := curlMultipart(form)
+		if err != nil {
+			return nil, err
+		}
+		spec.Method, spec.Body = http.MethodPost, body
+		spec.Headers.Set("Content-Type", contentType)
+	case len(data) != 0 && getData:
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, err
+		}
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += strings.Join(data, "&")
+		spec.URL = u.String()
+	case len(data) != 0:
+		spec.Method, spec.Body = http.MethodPost, []byte(strings.Join(data, "&"))
+		if spec.Headers.Get("Content-Type") == "" {
+			spec.Headers.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+
+	if method != "" {
+		spec.Method = method
+	}
+
+	return spec, nil
+}
+
+// curlFlagValue returns the canonical flag and its value for args[*i], consuming the
+// next argument when the value is not attached.
+func curlFlagValue(args []string, i *int) (string, string, error) {
+	arg := args[*i]
+
+	if flag, ok := curlValueFlags[arg]; ok {
+		if *i+1 >= len(args) {
+			return "", "", fmt.Errorf("curl: %s needs a value", arg)
+		}
+		*i++
+		return flag, args[*i], nil
+	}
+
+	if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+		if flag, ok := curlValueFlags[arg[:2]]; ok {
+			return flag, arg[2:], nil
+		}
+
+		// Combined no-op short flags such as -sSL.
+		allNoop := true
+		for _, c := range arg[1:] {
+			allNoop = allNoop && curlNoopFlags["-"+string(c)]
+		}
+		if allNoop {
+			return "", "", nil
+		}
+	}
+
+	return "", "", fmt.Errorf("curl: unsupported flag %s", arg)
+}
+
+// curlURLEncode implements the --data-urlencode forms content, =content, name=content,
+// @file and name@file.
+func curlURLEncode(value string) (string, error) {
+	if name, file, ok := strings.Cut(value, "@"); ok && !strings.Contains(name, "=") {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("curl: --data-urlencode: %w", err)
+		}
+		if name == "" {
+			return url.QueryEscape(string(content)), nil
+		}
+		return name + "=" + url.QueryEscape(string(content)), nil
+	}
+
+	name, content, ok := strings.Cut(value, "=")
+	if !ok {
+		return url.QueryEscape(value), nil
+	}
+	if name == "" {
+		return url.QueryEscape(content), nil
+	}
+
+	return name + "=" + url.QueryEscape(content), nil
+}
+
+// curlMultipart encodes -F fields: name=value, name=@file[;type=...] and name=<file.
+func curlMultipart(fields []string) ([]byte, string, error) {
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+
+	for _, field := range fields {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, "", fmt.Errorf("curl: malformed -F %q", field)
+		}
+
+		switch {
+		case strings.HasPrefix(value, "@"):
+			path, params, _ := strings.Cut(value[1:], ";")
+			contentType := "application/octet-stream"
+			for _, param := range strings.Split(params, ";") {
+				if v, ok := strings.CutPrefix(strings.TrimSpace(param), "type="); ok {
+					contentType = v
+				}
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, "", fmt.Errorf("curl: -F %s: %w", name, err)
+			}
+
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Disposition",
+				fmt.Sprintf(`form-data; name="%s"; filename="%s"`, name, filepath.Base(path)))
+			header.Set("Content-Type", contentType)
+
+			part, err := writer.CreatePart(header)
+			if err != nil {
+				return nil, "", err
+			}
+			if _, err = part.Write(content); err != nil {
+				return nil, "", err
+			}
+		case strings.HasPrefix(value, "<"):
+			content, err := os.ReadFile(value[1:])
+			if err != nil {
+				return nil, "", fmt.Errorf("curl: -F %s: %w", name, err)
+			}
+			if err = writer.WriteField(name, string(content)); err != nil {
+				return nil, "", err
+			}
+		default:
+			if err := writer.WriteField(name, value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// splitShell splits a command line into words following POSIX shell quoting: single
+// quotes, double quotes with backslash escapes, $'...' ANSI-C strings and
+// backslash-newline continuations.
+func splitShell(cmd string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	for i := 0; i < len(cmd); i++ {
+		c := cmd[i]
+
+		switch {
+		case c == '\\' && i+1 < len(cmd) && (cmd[i+1] == '\n' || cmd[i+1] == '\r'):
+			i++
+			if cmd[i] == '\r' && i+1 < len(cmd) && cmd[i+1] == '\n' {
+				i++
+			}
+		case c == '\\':
+			if i+1 < len(cmd) {
+				i++
+				word.WriteByte(cmd[i])
+				inWord = true
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(cmd[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("curl: unterminated single quote")
+			}
+			word.WriteString(cmd[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '$' && i+1 < len(cmd) && cmd[i+1] == '\'':
+			i += 2
+			for ; i < len(cmd) && cmd[i] != '\''; i++ {
+				if cmd[i] != '\\' || i+1 >= len(cmd) {
+					word.WriteByte(cmd[i])
+					continue
+				}
+				i++
+				switch cmd[i] {
+				case 'n':
+					word.WriteByte('\n')
+				case 'r':
+					word.WriteByte('\r')
+				case 't':
+					word.WriteByte('\t')
+				default:
+					word.WriteByte(cmd[i])
+				}
+			}
+			if i >= len(cmd) {
+				return nil, errors.New("curl: unterminated $' quote")
+			}
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(cmd) && cmd[i] != '"'; i++ {
+				if cmd[i] == '\\' && i+1 < len(cmd) && strings.IndexByte("\"\\$`\n", cmd[i+1]) >= 0 {
+					i++
+					if cmd[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(cmd[i])
+			}
+			if i >= len(cmd) {
+				return nil, errors.New("curl: unterminated double quote")
+			}
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
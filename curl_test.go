@@ -0,0 +1,176 @@
+package reqbuilder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromCurl(t *testing.T) {
+	payload := filepath.Join(t.TempDir(), "payload.json")
+	require.NoError(t, os.WriteFile(payload, []byte("{\n  \"a\": 1\n}\n"), 0o600))
+
+	tests := []struct {
+		name    string
+		cmd     string
+		method  string
+		url     string
+		headers map[string]string
+		body    string
+		auth    string
+		cookies string
+	}{
+		{
+			name:   "plain GET",
+			cmd:    `curl https://api.example.com/v1/users`,
+			method: http.MethodGet,
+			url:    "https://api.example.com/v1/users",
+		},
+		{
+			name:   "bare host with silent flags",
+			cmd:    `curl -sSL api.example.com/health`,
+			method: http.MethodGet,
+			url:    "http://api.example.com/health",
+		},
+		{
+			name:    "JSON POST with headers",
+			cmd:     `curl -X POST 'https://api.example.com/v1/orders' -H 'Content-Type: application/json' -H "Authorization: Bearer abc.def" -d '{"sku":"A-1","qty":2}'`,
+			method:  http.MethodPost,
+			url:     "https://api.example.com/v1/orders",
+			headers: map[string]string{"Content-Type": "application/json", "Authorization": "Bearer abc.def"},
+			body:    `{"sku":"A-1","qty":2}`,
+		},
+		{
+			name:    "form data defaults to POST",
+			cmd:     `curl https://example.com/login -d user=ann -d 'pass=s3cr3t!'`,
+			method:  http.MethodPost,
+			url:     "https://example.com/login",
+			headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			body:    "user=ann&pass=s3cr3t!",
+		},
+		{
+			name:   "attached -X and --data-raw",
+			cmd:    `curl -XPATCH https://example.com/items/7 --data-raw '@not-a-file'`,
+			method: http.MethodPatch,
+			url:    "https://example.com/items/7",
+			body:   "@not-a-file",
+		},
+		{
+			name:   "data from file strips newlines",
+			cmd:    `curl https://example.com/import -d @` + payload,
+			method: http.MethodPost,
+			url:    "https://example.com/import",
+			body:   `{  "a": 1}`,
+		},
+		{
+			name:   "binary data from file keeps newlines",
+			cmd:    `curl https://example.com/import --data-binary @` + payload,
+			method: http.MethodPost,
+			url:    "https://example.com/import",
+			body:   "{\n  \"a\": 1\n}\n",
+		},
+		{
+			name:   "urlencoded data with -G",
+			cmd:    `curl -G 'https://example.com/search?page=2' --data-urlencode 'q=go http' --data-urlencode '=a&b'`,
+			method: http.MethodGet,
+			url:    "https://example.com/search?page=2&q=go+http&a%26b",
+		},
+		{
+			name:   "basic auth",
+			cmd:    `curl -u admin:hunter2 https://example.com/admin`,
+			method: http.MethodGet,
+			url:    "https://example.com/admin",
+			auth:   "Basic YWRtaW46aHVudGVyMg==",
+		},
+		{
+			name:    "cookies, user agent and referer",
+			cmd:     `curl https://example.com/cart -b 'session=s1; theme=dark' -A 'Mozilla/5.0' -e https://example.com/`,
+			method:  http.MethodGet,
+			url:     "https://example.com/cart",
+			headers: map[string]string{"User-Agent": "Mozilla/5.0", "Referer": "https://example.com/"},
+			cookies: "session=s1; theme=dark",
+		},
+		{
+			name:    "browser copy as cURL",
+			cmd:     "curl 'https://example.com/api/me' \\\n  -H 'accept: application/json' \\\n  -H $'x-note: a\\tb' \\\n  --compressed",
+			method:  http.MethodGet,
+			url:     "https://example.com/api/me",
+			headers: map[string]string{"Accept": "application/json", "X-Note": "a\tb", "Accept-Encoding": "deflate, gzip, br, zstd"},
+		},
+		{
+			name:   "HEAD request",
+			cmd:    `curl -I "https://example.com/file \"v2\".zip"`,
+			method: http.MethodHead,
+			url:    `https://example.com/file "v2".zip`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := FromCurl(t, tt.cmd)
+			require.NoError(t, err)
+
+			require.Equal(t, tt.method, spec.Method)
+			require.Equal(t, tt.url, spec.URL)
+			for key, value := range tt.headers {
+				require.Equal(t, value, spec.Headers.Get(key), key)
+			}
+			require.Equal(t, tt.body, string(spec.Body))
+			require.Equal(t, tt.auth, spec.Authorization)
+
+			var cookies []string
+			for _, c := range spec.Cookies {
+				cookies = append(cookies, c.String())
+			}
+			require.Equal(t, tt.cookies, strings.Join(cookies, "; "))
+		})
+	}
+}
+
+func TestFromCurlMultipartSend(t *testing.T) {
+	avatar := filepath.Join(t.TempDir(), "avatar.png")
+	require.NoError(t, os.WriteFile(avatar, []byte("PNG"), 0o600))
+
+	var name, file string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		name = r.FormValue("name")
+		f, header, err := r.FormFile("avatar")
+		require.NoError(t, err)
+		data, _ := io.ReadAll(f)
+		file = header.Filename + ":" + header.Header.Get("Content-Type") + ":" + string(data)
+	})
+
+	spec, err := FromCurl(t, `curl -F name=ann -F "avatar=@`+avatar+`;type=image/png" `+server.URL+`/profile`)
+	require.NoError(t, err)
+
+	response, _ := New(require.New(t)).Send(t, context.Background(), spec)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "ann", name)
+	require.Equal(t, "avatar.png:image/png:PNG", file)
+}
+
+func TestFromCurlErrors(t *testing.T) {
+	for cmd, want := range map[string]string{
+		`wget https://example.com`:                     "curl: command must start with curl",
+		`curl --insecure https://example.com`:          "curl: unsupported flag --insecure",
+		`curl -k https://example.com`:                  "curl: unsupported flag -k",
+		`curl -o out.html https://example.com`:         "curl: unsupported flag -o",
+		`curl -b cookies.txt https://example.com`:      "curl: -b with a cookie file is not supported",
+		`curl -H 'no colon' https://example.com`:       `curl: malformed header "no colon"`,
+		`curl 'https://example.com`:                    "curl: unterminated single quote",
+		`curl -X`:                                      "curl: -X needs a value",
+		`curl -s`:                                      "curl: no URL",
+		`curl https://example.com https://example.org`: `curl: unexpected argument "https://example.org"`,
+	} {
+		_, err := FromCurl(t, cmd)
+		require.EqualError(t, err, want, cmd)
+	}
+}
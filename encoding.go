@@ -0,0 +1,94 @@
+package reqbuilder
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrUnsupportedEncoding is matched by the *UnsupportedEncodingError returned in strict
+// encoding mode, see WithStrictEncoding.
+var ErrUnsupportedEncoding = errors.New("unsupported Content-Encoding")
+
+// UnsupportedEncodingError is returned when reading a response whose Content-Encoding
+// the Builder cannot decode, with WithStrictEncoding set.
+type UnsupportedEncodingError struct {
+	Encoding string
+}
+
+func (e *UnsupportedEncodingError) Error() string {
+	return fmt.Sprintf("unsupported Content-Encoding %q", e.Encoding)
+}
+
+func (e *UnsupportedEncodingError) Is(target error) bool {
+	return target == ErrUnsupportedEncoding
+}
+
+// WithStrictEncoding makes reading a response body fail with an
+// *UnsupportedEncodingError when its Content-Encoding is not one the Builder decodes,
+// instead of returning the still encoded bytes.
+func WithStrictEncoding() Option {
+	return func(b *Builder) {
+		b.strictEncoding = true
+	}
+}
+
+// WithEncodingSniffing decodes response bodies sent without a Content-Encoding header
+// that start with the gzip or zstd magic bytes, as some misconfigured proxies and CDNs
+// send them.
+func WithEncodingSniffing() Option {
+	return func(b *Builder) {
+		b.sniffEncoding = true
+	}
+}
+
+// magicNumbers maps content encodings to the bytes their streams start with.
+var magicNumbers = []struct {
+	encoding string
+	magic    []byte
+}{
+	{"gzip", []byte{0x1f, 0x8b}},
+	{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// responseDecoder returns a reader over the decoded response body, applying the
+// Builder's strict and sniffing modes.
+func (b *Builder) responseDecoder(response *http.Response) (io.ReadCloser, error) {
+	encoding := response.Header.Get("Content-Encoding")
+	body := bodyReader(response)
+
+	switch {
+	case b.strictEncoding && !decodable(encoding):
+		return nil, &UnsupportedEncodingError{Encoding: encoding}
+	case b.sniffEncoding && encoding == "" && body != nil:
+		buffered := bufio.NewReader(body)
+		encoding = sniffEncoding(buffered)
+		body = buffered
+	}
+
+	return b.zstd.decoder(encoding, body)
+}
+
+// decodable reports whether decodingReader decodes encoding.
+func decodable(encoding string) bool {
+	switch encoding {
+	case "", "identity", "gzip", "br", "zstd", "deflate":
+		return true
+	default:
+		return false
+	}
+}
+
+// sniffEncoding returns the encoding whose magic bytes r starts with, or "".
+func sniffEncoding(r *bufio.Reader) string {
+	for _, m := range magicNumbers {
+		if head, _ := r.Peek(len(m.magic)); bytes.Equal(head, m.magic) {
+			return m.encoding
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,84 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fabricated returns a response with the given Content-Encoding and raw body, as
+// received from a server without the transport touching it.
+func fabricated(encoding string, body []byte) *http.Response {
+	header := http.Header{}
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewReader(body))}
+}
+
+func TestUnknownEncodingDefault(t *testing.T) {
+	b := New(require.New(t))
+
+	body, err := b.ReadResponseBody(fabricated("gzp", []byte("still encoded")))
+
+	require.NoError(t, err)
+	require.Equal(t, "still encoded", string(body), "unknown encodings pass through unless strict")
+}
+
+func TestWithStrictEncoding(t *testing.T) {
+	b := New(require.New(t), WithStrictEncoding())
+
+	for _, encoding := range []string{"compress", "gzp"} {
+		_, err := b.ReadResponseBody(fabricated(encoding, []byte{0x1f, 0x9d, 0x90}))
+
+		require.ErrorIs(t, err, ErrUnsupportedEncoding)
+		var unsupported *UnsupportedEncodingError
+		require.True(t, errors.As(err, &unsupported))
+		require.Equal(t, encoding, unsupported.Encoding)
+		require.EqualError(t, err, `unsupported Content-Encoding "`+encoding+`"`)
+	}
+
+	gzipped, err := EncodeGzip([]byte(`{"ok":true}`))
+	require.NoError(t, err)
+	for _, encoding := range []string{"", "identity", "gzip"} {
+		raw := gzipped
+		if encoding != "gzip" {
+			raw = []byte(`{"ok":true}`)
+		}
+		body, err := b.ReadResponseBody(fabricated(encoding, raw))
+		require.NoError(t, err, "encoding %q", encoding)
+		require.Equal(t, `{"ok":true}`, string(body))
+	}
+}
+
+func TestWithEncodingSniffing(t *testing.T) {
+	content := []byte(`{"ok":true}`)
+	gzipped, err := EncodeGzip(content)
+	require.NoError(t, err)
+	zstded, err := EncodeZstd(content)
+	require.NoError(t, err)
+
+	b := New(require.New(t), WithEncodingSniffing())
+	for _, raw := range [][]byte{gzipped, zstded, content} {
+		body, err := b.ReadResponseBody(fabricated("", raw))
+		require.NoError(t, err)
+		require.Equal(t, content, body)
+	}
+
+	body, err := b.ReadResponseBody(fabricated("", []byte{0x1f}))
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x1f}, body, "a body shorter than any magic number is left alone")
+
+	body, err = b.ReadResponseBody(fabricated("identity", gzipped))
+	require.NoError(t, err)
+	require.Equal(t, gzipped, body, "only bodies without a Content-Encoding are sniffed")
+
+	body, err = New(require.New(t)).ReadResponseBody(fabricated("", gzipped))
+	require.NoError(t, err)
+	require.Equal(t, gzipped, body, "sniffing is off by default")
+}
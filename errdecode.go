@@ -0,0 +1,134 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// StatusError is the error for a non-2xx response whose body is neither decoded by the
+// Builder's error decoder nor an APIErr envelope.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+	Err        error
+}
+
+func (e *StatusError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("status %d (decoding error body: %v): %s", e.StatusCode, e.Err, excerpt(e.Body, 512))
+	}
+
+	return fmt.Sprintf("status %d: %s", e.StatusCode, excerpt(e.Body, 512))
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// WithErrorDecoder sets how the bodies of non-2xx responses are turned into errors by
+// ResponseError, DoJSON and TryJSON. decode may return nil to fall back to the default
+// of ResponseError.
+func WithErrorDecoder(decode func(status int, body []byte) error) Option {
+	return func(b *Builder) {
+		b.errorDecoder = decode
+	}
+}
+
+// WithErrorType decodes the JSON bodies of non-2xx responses into T, for APIs whose
+// errors do not use the envelope ResponseError decodes into an APIErr by default.
+// Negative tests inspect T with errors.As as they would an APIErr. Bodies that are not
+// valid JSON for T produce a StatusError carrying the raw body.
+func WithErrorType[T error]() Option {
+	return WithErrorDecoder(func(status int, body []byte) error {
+		var target T
+		if err := json.Unmarshal(body, &target); err != nil {
+			return &StatusError{StatusCode: status, Body: body, Err: err}
+		}
+
+		return target
+	})
+}
+
+// ResponseError returns nil for a 2xx response and otherwise the error its body
+// decodes to with the Builder's error decoder. Without one, an error envelope is
+// returned as an *APIErr, so negative tests can inspect it with errors.As:
+//
+//	var apiErr *APIErr
+//	require.ErrorAs(t, b.TryJSON(t, ctx, spec, nil), &apiErr)
+//	require.Equal(t, "VALIDATION_FAILED", apiErr.Code)
+//
+// Any other body is returned as a StatusError.
+func (b *Builder) ResponseError(response *http.Response) error {
+	if response.StatusCode/100 == 2 {
+		return nil
+	}
+
+	body, err := b.decodedBody(response)
+	if err != nil {
+		return &StatusError{StatusCode: response.StatusCode, Err: err}
+	}
+
+	if b.errorDecoder != nil {
+		if err = b.errorDecoder(response.StatusCode, body); err != nil {
+			return err
+		}
+	}
+
+	if apiErr := parseAPIErr(response.StatusCode, body); apiErr.Code != "" {
+		return apiErr
+	}
+
+	return &StatusError{StatusCode: response.StatusCode, Body: body}
+}
+
+// TryJSON sends the request described by spec and decodes a 2xx JSON response into
+// out, which may be nil. A non-2xx response is returned as its ResponseError.
+func (b *Builder) TryJSON(t *testing.T, ctx context.Context, spec *RequestSpec, out any) error {
+	t.Helper()
+
+	response, _ := b.Send(t, ctx, spec)
+	if err := b.ResponseError(response); err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return b.DecodeJSON(response, out)
+}
+
+// DoJSON is TryJSON failing the test on any error, including the decoded error of a
+// non-2xx response.
+func (b *Builder) DoJSON(t *testing.T, ctx context.Context, spec *RequestSpec, out any) {
+	t.Helper()
+
+	if err := b.TryJSON(t, ctx, spec, out); err != nil {
+		b.logError(t, err)
+		b.require.NoError(err)
+	}
+}
+
+// Expect sends the request described by spec, requires status wantStatus and decodes
+// the JSON response body into out, which may be nil. A status mismatch fails with the
+// response body; the body is decompressed before decoding.
+func (b *Builder) Expect(t *testing.T, ctx context.Context, spec *RequestSpec, wantStatus int, out any) *http.Response {
+	t.Helper()
+
+	response, _ := b.Send(t, ctx, spec)
+	b.ExpectStatus(t, response, wantStatus)
+
+	if out == nil {
+		return response
+	}
+
+	if err := b.DecodeJSON(response, out); err != nil {
+		b.logError(t, err)
+		b.require.NoError(err, "decoding %s %s response", spec.Method, spec.URL)
+	}
+
+	return response
+}
@@ -0,0 +1,117 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// envelopeError is an API error envelope as a negative test would declare it.
+type envelopeError struct {
+	Code    string   `json:"code"`
+	Message string   `json:"message"`
+	Details []string `json:"details"`
+}
+
+func (e envelopeError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func errorServer(t *testing.T) string {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/invalid":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"VALIDATION_FAILED","message":"bad input","details":["name"]}`))
+		case "/broken":
+			http.Error(w, "gateway timeout", http.StatusGatewayTimeout)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":1}`))
+		}
+	})
+
+	return server.URL
+}
+
+func TestWithErrorType(t *testing.T) {
+	url := errorServer(t)
+	b := New(require.New(t), WithErrorType[envelopeError]())
+
+	var apiErr envelopeError
+	require.ErrorAs(t, b.TryJSON(t, context.Background(), Get(url+"/invalid"), nil), &apiErr)
+	require.Equal(t, "VALIDATION_FAILED", apiErr.Code)
+	require.Equal(t, []string{"name"}, apiErr.Details)
+
+	var statusErr *StatusError
+	require.ErrorAs(t, b.TryJSON(t, context.Background(), Get(url+"/broken"), nil), &statusErr)
+	require.Equal(t, http.StatusGatewayTimeout, statusErr.StatusCode)
+	require.Equal(t, "gateway timeout\n", string(statusErr.Body))
+	require.Error(t, statusErr.Err, "the decoding error is kept")
+
+	var out struct{ ID int }
+	require.NoError(t, b.TryJSON(t, context.Background(), Get(url+"/ok"), &out))
+	require.Equal(t, 1, out.ID)
+
+	msg := failure(t, []Option{WithErrorType[envelopeError]()}, func(b *Builder) {
+		b.DoJSON(t, context.Background(), Get(url+"/invalid"), nil)
+	})
+	require.Contains(t, msg, "VALIDATION_FAILED: bad input")
+}
+
+func TestResponseErrorWithoutDecoder(t *testing.T) {
+	url := errorServer(t)
+	b := New(require.New(t))
+
+	err := b.TryJSON(t, context.Background(), Get(url+"/invalid"), nil)
+	require.EqualError(t, err, `status 400: {"code":"VALIDATION_FAILED","message":"bad input","details":["name"]}`)
+}
+
+func TestResponseErrorAPIErr(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error":{"code":"VALIDATION_FAILED","message":"bad input"}}`))
+	})
+
+	// The example from the ResponseError documentation.
+	b := New(require.New(t))
+	var apiErr *APIErr
+	require.ErrorAs(t, b.TryJSON(t, context.Background(), Get(server.URL), nil), &apiErr)
+	require.Equal(t, "VALIDATION_FAILED", apiErr.Code)
+	require.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+
+	// A decoder that declines the body falls back to the same default.
+	b = New(require.New(t), WithErrorDecoder(func(int, []byte) error { return nil }))
+	require.ErrorAs(t, b.TryJSON(t, context.Background(), Get(server.URL), nil), &apiErr)
+}
+
+func TestExpect(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, `{"error":"user 7 not found"}`, http.StatusNotFound)
+			return
+		}
+		encoded, _ := EncodeGzip([]byte(`{"id":7,"name":"ada"}`))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(encoded)
+	})
+
+	var user struct {
+		ID   int
+		Name string
+	}
+	b := New(require.New(t), WithDisableCompression())
+	response := b.Expect(t, context.Background(), Get(server.URL+"/users/7"), http.StatusOK, &user)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, 7, user.ID)
+	require.Equal(t, "ada", user.Name)
+
+	msg := failure(t, []Option{WithDisableCompression()}, func(b *Builder) {
+		b.Expect(t, context.Background(), Get(server.URL+"/missing"), http.StatusOK, &user)
+	})
+	require.Contains(t, msg, "404")
+	require.Contains(t, msg, `user 7 not found`)
+}
@@ -0,0 +1,239 @@
+package reqbuilder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInjectedFault is wrapped by every error produced by a FaultTransport.
+var ErrInjectedFault = errors.New("reqbuilder: injected fault")
+
+// Fault describes a network failure injected into matching requests.
+type Fault struct {
+	// Match selects the requests the fault applies to by their URL; nil matches all.
+	Match *regexp.Regexp
+	// Probability, when non-zero, applies the fault only to that fraction of the
+	// matching requests.
+	Probability float64
+	// Latency delays the response by a fixed duration.
+	Latency time.Duration
+	// Jitter adds a random delay in [0, Jitter) on top of Latency.
+	Jitter time.Duration
+	// FailFirst makes the first FailFirst matching attempts fail with a transport error
+	// instead of being sent.
+	FailFirst int
+	// Reset fails the request with a connection reset error instead of sending it.
+	Reset bool
+	// Drop cuts the response body off with an error after DropAfter bytes.
+	Drop      bool
+	DropAfter int64
+	// Corrupt flips every 64th byte of the response body, starting with the first.
+	Corrupt bool
+}
+
+// FaultTransport injects Faults between a Builder and its transport. It sits below
+// retries, so a retried request meets the faults again on every attempt. A
+// FaultTransport keeps its state across requests and Builder copies; Fired reports
+// how often faults took effect.
+type FaultTransport struct {
+	faults []Fault
+
+	mu       sync.Mutex
+	attempts []int
+	rand     *rand.Rand
+
+	fired atomic.Int64
+}
+
+// NewFaultTransport returns a FaultTransport injecting faults. Jitter is drawn from a
+// fixed-seed source, so runs are reproducible.
+func NewFaultTransport(faults ...Fault) *FaultTransport {
+	return &FaultTransport{
+		faults:   faults,
+		attempts: make([]int, len(faults)),
+		rand:     rand.New(rand.NewPCG(1, 2)),
+	}
+}
+
+// WithFaultTransport sends requests through faults. Applied with With or as a
+// per-request option it only affects those requests.
+func WithFaultTransport(faults *FaultTransport) Option {
+	return func(b *Builder) {
+		b.faults = faults
+	}
+}
+
+// Fired returns how many times a fault took effect.
+func (f *FaultTransport) Fired() int {
+	return int(f.fired.Load())
+}
+
+// Wrap returns next with the faults injected.
+func (f *FaultTransport) Wrap(next http.RoundTripper) http.RoundTripper {
+	return &faultRoundTripper{faults: f, next: next, clock: realClock{}}
+}
+
+// faultRoundTripper applies a FaultTransport to requests sent through next.
+type faultRoundTripper struct {
+	faults *FaultTransport
+	next   http.RoundTripper
+	clock  Clock
+}
+
+func (rt *faultRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	f := rt.faults
+	target := req.URL.String()
+
+	var delay time.Duration
+	var matched []Fault
+
+	f.mu.Lock()
+	for i, fault := range f.faults {
+		if fault.Match != nil && !fault.Match.MatchString(target) {
+			continue
+		}
+		if fault.Probability != 0 && f.rand.Float64() >= fault.Probability {
+			continue
+		}
+
+		if fault.Reset {
+			f.mu.Unlock()
+			f.fired.Add(1)
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, fmt.Errorf("%w: connection to %s reset", ErrInjectedFault, target)
+		}
+
+		f.attempts[i]++
+		if f.attempts[i] <= fault.FailFirst {
+			f.mu.Unlock()
+			f.fired.Add(1)
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, fmt.Errorf("%w: attempt %d to %s failed", ErrInjectedFault, f.attempts[i], target)
+		}
+
+		delay += fault.Latency
+		if fault.Jitter > 0 {
+			delay += time.Duration(f.rand.Int64N(int64(fault.Jitter)))
+		}
+		matched = append(matched, fault)
+	}
+	f.mu.Unlock()
+
+	if delay > 0 {
+		f.fired.Add(1)
+		if err := rt.clock.Sleep(req.Context(), delay); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, err
+		}
+	}
+
+	response, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return response, err
+	}
+
+	for _, fault := range matched {
+		if fault.Drop || fault.Corrupt {
+			response.Body = &faultyBody{ReadCloser: response.Body, fault: fault, fired: &f.fired}
+		}
+	}
+
+	return response, nil
+}
+
+// faultyBody drops or corrupts a response body.
+type faultyBody struct {
+	io.ReadCloser
+	fault  Fault
+	fired  *atomic.Int64
+	offset int64
+	noted  bool
+}
+
+func (r *faultyBody) Read(p []byte) (int, error) {
+	if r.fault.Drop {
+		remaining := r.fault.DropAfter - r.offset
+		if remaining <= 0 {
+			r.note()
+			return 0, fmt.Errorf("%w: connection dropped after %d bytes", ErrInjectedFault, r.offset)
+		}
+		if int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+	}
+
+	n, err := r.ReadCloser.Read(p)
+
+	if r.fault.Corrupt {
+		for i := range n {
+			if (r.offset+int64(i))%64 == 0 {
+				p[i] ^= 0xFF
+				r.note()
+			}
+		}
+	}
+	r.offset += int64(n)
+
+	return n, err
+}
+
+// note counts the fault as fired once per body.
+func (r *faultyBody) note() {
+	if !r.noted {
+		r.noted = true
+		r.fired.Add(1)
+	}
+}
+
+// FaultConfig describes random network faults, each applied to the given fraction of
+// requests.
+type FaultConfig struct {
+	// Seed seeds the random source, so a failing run can be reproduced.
+	Seed uint64
+	// Match limits the faults to requests whose URL matches; nil matches all.
+	Match *regexp.Regexp
+
+	DelayRate float64
+	Delay     time.Duration
+
+	ResetRate float64
+
+	// TruncateRate cuts response bodies off after TruncateAfter bytes.
+	TruncateRate  float64
+	TruncateAfter int64
+}
+
+// WithFaultInjection sends requests through a FaultTransport that randomly delays,
+// resets or truncates them according to config. It replaces a FaultTransport set with
+// WithFaultTransport.
+func WithFaultInjection(config FaultConfig) Option {
+	return func(b *Builder) {
+		var faults []Fault
+		if config.DelayRate > 0 {
+			faults = append(faults, Fault{Match: config.Match, Probability: config.DelayRate, Latency: config.Delay})
+		}
+		if config.ResetRate > 0 {
+			faults = append(faults, Fault{Match: config.Match, Probability: config.ResetRate, Reset: true})
+		}
+		if config.TruncateRate > 0 {
+			faults = append(faults, Fault{Match: config.Match, Probability: config.TruncateRate, Drop: true, DropAfter: config.TruncateAfter})
+		}
+
+		ft := NewFaultTransport(faults...)
+		ft.rand = rand.New(rand.NewPCG(config.Seed, config.Seed))
+		b.faults = ft
+	}
+}
@@ -0,0 +1,149 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func countingServer(t *testing.T, body []byte) (*atomic.Int32, string) {
+	var hits atomic.Int32
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write(body)
+	})
+
+	return &hits, server.URL
+}
+
+func TestFaultLatencyScopedByURL(t *testing.T) {
+	_, url := countingServer(t, []byte("ok"))
+	clock := &sleepRecorder{}
+	faults := NewFaultTransport(Fault{Match: regexp.MustCompile(`/slow$`), Latency: 200 * time.Millisecond, Jitter: 50 * time.Millisecond})
+
+	b := New(require.New(t), WithClock(clock), WithFaultTransport(faults))
+	b.Send(t, context.Background(), Get(url+"/fast"))
+	require.Empty(t, clock.sleeps)
+	require.Zero(t, faults.Fired())
+
+	b.Send(t, context.Background(), Get(url+"/slow"))
+	require.Len(t, clock.sleeps, 1)
+	require.GreaterOrEqual(t, clock.sleeps[0], 200*time.Millisecond)
+	require.Less(t, clock.sleeps[0], 250*time.Millisecond)
+	require.Equal(t, 1, faults.Fired())
+}
+
+func TestFaultFailFirstUnderRetry(t *testing.T) {
+	hits, url := countingServer(t, []byte("ok"))
+	faults := NewFaultTransport(Fault{FailFirst: 2})
+
+	b := New(require.New(t), WithRetry(3, time.Second), WithClock(&sleepRecorder{}), WithFaultTransport(faults))
+	response, _ := b.Send(t, context.Background(), Get(url))
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, 2, faults.Fired(), "every retry meets the fault again")
+	require.EqualValues(t, 1, hits.Load())
+}
+
+func TestFaultPerRequest(t *testing.T) {
+	hits, url := countingServer(t, []byte("ok"))
+	faults := NewFaultTransport(Fault{Reset: true})
+	b := New(require.New(t))
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.RequestWithoutBody(t, context.Background(), http.MethodGet, url, "/", nil, nil, "", WithFaultTransport(faults))
+	})
+	require.Contains(t, msg, "reqbuilder: injected fault: connection to "+url+"/ reset")
+
+	b.RequestWithoutBody(t, context.Background(), http.MethodGet, url, "/", nil, nil, "")
+	require.Equal(t, 1, faults.Fired())
+	require.EqualValues(t, 1, hits.Load())
+}
+
+func TestFaultBodies(t *testing.T) {
+	payload := bytes.Repeat([]byte{'a'}, 200)
+	_, url := countingServer(t, payload)
+
+	drop := NewFaultTransport(Fault{Drop: true, DropAfter: 10})
+	b := New(require.New(t), WithFaultTransport(drop))
+	response, _ := b.Send(t, context.Background(), Get(url))
+	data, err := io.ReadAll(response.Body)
+	require.ErrorIs(t, err, ErrInjectedFault)
+	require.Len(t, data, 10)
+	require.Equal(t, 1, drop.Fired())
+
+	corrupt := NewFaultTransport(Fault{Corrupt: true})
+	client := &http.Client{Transport: corrupt.Wrap(http.DefaultTransport)}
+	response, err = client.Get(url)
+	require.NoError(t, err)
+	data, err = io.ReadAll(response.Body)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	var flipped []int
+	for i, c := range data {
+		if c != 'a' {
+			flipped = append(flipped, i)
+		}
+	}
+	require.Equal(t, []int{0, 64, 128, 192}, flipped)
+	require.Equal(t, 1, corrupt.Fired(), "a corrupted body counts once")
+}
+
+func TestWithFaultInjection(t *testing.T) {
+	_, url := countingServer(t, []byte("ok"))
+	client := func(seed uint64) *http.Client {
+		b := New(require.New(t), WithFaultInjection(FaultConfig{Seed: seed, ResetRate: 0.5}))
+		return &http.Client{Transport: b.faults.Wrap(http.DefaultTransport)}
+	}
+
+	outcomes := func(seed uint64) []bool {
+		c := client(seed)
+		var failed []bool
+		for range 20 {
+			response, err := c.Get(url)
+			if err == nil {
+				response.Body.Close()
+			}
+			require.True(t, err == nil || errors.Is(err, ErrInjectedFault), err)
+			failed = append(failed, err != nil)
+		}
+		return failed
+	}
+
+	first := outcomes(42)
+	require.Equal(t, first, outcomes(42), "the same seed injects the same faults")
+	require.Contains(t, first, true)
+	require.Contains(t, first, false)
+}
+
+func TestWithFaultInjectionRates(t *testing.T) {
+	calls, url := countingServer(t, []byte("payload"))
+
+	msg := failure(t, []Option{WithFaultInjection(FaultConfig{ResetRate: 1})}, func(b *Builder) {
+		b.Send(t, context.Background(), Get(url))
+	})
+	require.Contains(t, msg, "injected fault")
+	require.Zero(t, calls.Load(), "a dropped request never reaches the server")
+
+	b := New(require.New(t), WithFaultInjection(FaultConfig{TruncateRate: 1, TruncateAfter: 3}))
+	response, _ := b.Send(t, context.Background(), Get(url))
+	body, err := io.ReadAll(response.Body)
+	require.ErrorIs(t, err, ErrInjectedFault)
+	require.Equal(t, "pay", string(body))
+
+	b = New(require.New(t), WithFaultInjection(FaultConfig{ResetRate: 0, TruncateRate: 0, DelayRate: 0}))
+	for range 10 {
+		response, _ := b.Send(t, context.Background(), Get(url))
+		require.Equal(t, "payload", string(b.requireBody(response)))
+	}
+	require.Zero(t, b.faults.Fired())
+}
@@ -0,0 +1,358 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// placeholder matches {{name}} variable references in flow steps.
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// Flow is a scripted sequence of requests that pass captured values to later steps.
+// Steps run in order and share cookies and the Authorization value.
+type Flow struct {
+	Authorization string
+
+	b       *Builder
+	t       *testing.T
+	ctx     context.Context
+	steps   []flowStep
+	vars    map[string]string
+	cookies []*http.Cookie
+}
+
+type flowStep struct {
+	name string
+	spec *RequestSpec
+}
+
+// Flow starts a new request flow. Relative step URLs are resolved with WithBaseURL.
+func (b *Builder) Flow(t *testing.T, ctx context.Context) *Flow {
+	return &Flow{b: b, t: t, ctx: ctx, vars: make(map[string]string)}
+}
+
+// Step appends a named step to the flow.
+func (f *Flow) Step(name string, spec *RequestSpec) *Flow {
+	f.steps = append(f.steps, flowStep{name: name, spec: spec})
+
+	return f
+}
+
+// Set defines a variable before the flow runs.
+func (f *Flow) Set(name, value string) *Flow {
+	f.vars[name] = value
+
+	return f
+}
+
+// Var returns a captured variable.
+func (f *Flow) Var(name string) string {
+	return f.vars[name]
+}
+
+// Run executes the steps in order. A failing step fails the test with its request and
+// response and the variables captured so far.
+func (f *Flow) Run() {
+	f.t.Helper()
+
+	for _, step := range f.steps {
+		f.run(step)
+	}
+}
+
+func (f *Flow) run(step flowStep) {
+	f.t.Helper()
+
+	spec, err := f.expand(step.spec)
+	if err == nil {
+		err = spec.err
+	}
+	if err != nil {
+		f.fail(step, nil, nil, err.Error())
+	}
+
+	spec.Cookies = append(spec.Cookies, f.cookies...)
+	if spec.Authorization == "" {
+		spec.Authorization = f.Authorization
+	}
+
+	req := f.b.newSpecRequest(f.t, f.ctx, spec)
+	response := f.b.do(f.t, req)
+	f.cookies = mergeCookies(response, f.cookies)
+
+	if spec.expectStatus != 0 && response.StatusCode != spec.expectStatus {
+		f.fail(step, spec, response, fmt.Sprintf("expected status %d, got %d", spec.expectStatus, response.StatusCode))
+	}
+
+	for key, values := range spec.expectHeaders {
+		got := response.Header.Values(key)
+		for _, want := range values {
+			if !headerMatches(key, want, got) {
+				f.fail(step, spec, response, fmt.Sprintf("expected header %s: %s, got %q", key, want, got))
+			}
+		}
+	}
+
+	var doc any
+	decoded := false
+
+	decode := func(what string) any {
+		if decoded {
+			return doc
+		}
+
+		body, err := f.b.decodedBody(response)
+		if err == nil {
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			err = decoder.Decode(&doc)
+		}
+		if err != nil {
+			f.fail(step, spec, response, fmt.Sprintf("%s: decoding JSON body: %v", what, err))
+		}
+		decoded = true
+
+		return doc
+	}
+
+	if spec.expectJSON != nil {
+		expected, err := normalizeJSON(spec.expectJSON)
+		if err != nil {
+			f.fail(step, spec, response, fmt.Sprintf("expected JSON: %v", err))
+		}
+		if err := jsonContains(expected, decode("expected JSON"), "$"); err != nil {
+			f.fail(step, spec, response, err.Error())
+		}
+	}
+
+	for _, c := range spec.captures {
+		if c.header != "" {
+			value := response.Header.Get(c.header)
+			if value == "" {
+				f.fail(step, spec, response, fmt.Sprintf("capture %q: no %s header", c.name, c.header))
+			}
+			f.vars[c.name] = value
+			continue
+		}
+
+		value, err := jsonPath(decode(fmt.Sprintf("capture %q", c.name)), c.jsonPath)
+		if err != nil {
+			f.fail(step, spec, response, fmt.Sprintf("capture %q: %v", c.name, err))
+		}
+		f.vars[c.name] = captureString(value)
+	}
+}
+
+// normalizeJSON round-trips v through encoding/json so it compares like a decoded body.
+func normalizeJSON(v any) (any, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	err = decoder.Decode(&doc)
+
+	return doc, err
+}
+
+// jsonContains reports where actual does not contain expected. Objects may have extra
+// keys; arrays must have the same length; numbers compare by value.
+func jsonContains(expected, actual any, path string) error {
+	switch want := expected.(type) {
+	case map[string]any:
+		got, ok := actual.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %s", path, captureString(actual))
+		}
+		keys := make([]string, 0, len(want))
+		for key := range want {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := got[key]
+			if !ok {
+				return fmt.Errorf("%s.%s: missing", path, key)
+			}
+			if err := jsonContains(want[key], value, path+"."+key); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		got, ok := actual.([]any)
+		if !ok || len(got) != len(want) {
+			return fmt.Errorf("%s: expected %s, got %s", path, captureString(want), captureString(actual))
+		}
+		for i := range want {
+			if err := jsonContains(want[i], got[i], fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case json.Number:
+		if got, ok := actual.(json.Number); ok {
+			w, werr := want.Float64()
+			g, gerr := got.Float64()
+			if werr == nil && gerr == nil && w == g {
+				return nil
+			}
+		}
+	default:
+		if expected == actual {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s: expected %s, got %s", path, captureString(expected), captureString(actual))
+}
+
+// headerMatches reports whether one of the got values of header key matches want. A
+// Content-Type matches when the media types are equal and it has the parameters listed
+// in want, so "application/json" matches "application/json; charset=utf-8".
+func headerMatches(key, want string, got []string) bool {
+	if http.CanonicalHeaderKey(key) != "Content-Type" {
+		return slices.Contains(got, want)
+	}
+
+	wantType, wantParams, err := mime.ParseMediaType(want)
+	if err != nil {
+		return slices.Contains(got, want)
+	}
+
+	for _, value := range got {
+		gotType, gotParams, err := mime.ParseMediaType(value)
+		if err != nil || gotType != wantType {
+			continue
+		}
+
+		matched := true
+		for name, v := range wantParams {
+			if !strings.EqualFold(gotParams[name], v) {
+				matched = false
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expand returns a copy of spec with {{name}} placeholders in the URL, header values,
+// body and expectations replaced by flow variables.
+func (f *Flow) expand(spec *RequestSpec) (*RequestSpec, error) {
+	var missing []string
+
+	replace := func(s string) string {
+		return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+			name := placeholder.FindStringSubmatch(m)[1]
+			value, ok := f.vars[name]
+			if !ok {
+				missing = append(missing, name)
+			}
+			return value
+		})
+	}
+
+	expanded := spec.clone()
+	expanded.URL = replace(spec.URL)
+	expanded.Body = []byte(replace(string(spec.Body)))
+	for _, header := range []http.Header{expanded.Headers, expanded.expectHeaders} {
+		for _, vs := range header {
+			for i, v := range vs {
+				vs[i] = replace(v)
+			}
+		}
+	}
+	expanded.expectJSON = expandJSON(spec.expectJSON, replace)
+
+	if len(missing) != 0 {
+		return nil, fmt.Errorf("undefined variables %s", strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
+
+// expandJSON returns a copy of v with replace applied to every string.
+func expandJSON(v any, replace func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return replace(v)
+	case map[string]any:
+		expanded := make(map[string]any, len(v))
+		for key, value := range v {
+			expanded[key] = expandJSON(value, replace)
+		}
+		return expanded
+	case []any:
+		expanded := make([]any, len(v))
+		for i, value := range v {
+			expanded[i] = expandJSON(value, replace)
+		}
+		return expanded
+	}
+
+	return v
+}
+
+// fail fails the test naming the step, with its request, response and the variable table.
+func (f *Flow) fail(step flowStep, spec *RequestSpec, response *http.Response, reason string) {
+	f.t.Helper()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "step %q: %s\n", step.name, reason)
+
+	if spec != nil {
+		fmt.Fprintf(&sb, "request: %s %s\n%s", spec.Method, spec.URL, dumpHeader(spec.Headers))
+		if len(spec.Body) != 0 {
+			fmt.Fprintf(&sb, "body: %s\n", excerpt(spec.Body, maxExcerpt))
+		}
+	}
+
+	if response != nil {
+		fmt.Fprintf(&sb, "response: %s\n", f.b.describe(response))
+	}
+
+	names := make([]string, 0, len(f.vars))
+	for name := range f.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sb.WriteString("variables:\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "  %s = %s\n", name, f.vars[name])
+	}
+
+	f.b.require.Fail("flow step failed", sb.String())
+}
+
+// captureString formats a captured JSON value for substitution.
+func captureString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return "null"
+	}
+
+	encoded, _ := json.Marshal(value)
+
+	return string(encoded)
+}
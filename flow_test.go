@@ -0,0 +1,125 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// itemServer is a tiny CRUD API that requires a bearer token and a session cookie
+// set by /login on every other request.
+func itemServer(t *testing.T) string {
+	var mu sync.Mutex
+	items := map[string]map[string]any{}
+	next := 0
+
+	return newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "s1"})
+			return
+		}
+		if c, err := r.Cookie("sid"); err != nil || c.Value != "s1" || r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, "/items/")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/items":
+			var item map[string]any
+			json.NewDecoder(r.Body).Decode(&item)
+			next++
+			id = fmt.Sprint(next)
+			item["id"] = id
+			items[id] = item
+			w.Header().Set("Location", "/items/"+id)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(item)
+		case r.Method == http.MethodGet && items[id] != nil:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(items[id])
+		case r.Method == http.MethodPut && items[id] != nil:
+			var item map[string]any
+			json.NewDecoder(r.Body).Decode(&item)
+			item["id"] = id
+			items[id] = item
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && items[id] != nil:
+			delete(items, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}).URL
+}
+
+func TestFlow(t *testing.T) {
+	b := New(require.New(t), WithBaseURL(itemServer(t)))
+
+	f := b.Flow(t, context.Background())
+	f.Authorization = "Bearer tok"
+	f.Set("name", "widget").
+		Step("login", Post("/login")).
+		Step("create", Post("/items").JSONBody(map[string]string{"name": "{{name}}"}).
+			ExpectStatus(http.StatusCreated).
+			Capture("id", "$.id").
+			CaptureHeader("location", "Location")).
+		Step("fetch", Get("{{location}}").ExpectJSON(map[string]any{"id": "{{id}}", "name": "widget"})).
+		Step("update", Put("/items/{{id}}").JSONBody(map[string]string{"name": "gadget"}).ExpectStatus(http.StatusNoContent)).
+		Step("refetch", Get("/items/{{id}}").ExpectJSON(map[string]any{"name": "gadget"})).
+		Step("delete", Delete("/items/{{id}}").ExpectStatus(http.StatusNoContent)).
+		Step("gone", Get("/items/{{id}}").ExpectStatus(http.StatusNotFound)).
+		Run()
+
+	require.Equal(t, "1", f.Var("id"))
+	require.Equal(t, "/items/1", f.Var("location"))
+}
+
+func TestFlowFailure(t *testing.T) {
+	base := itemServer(t)
+
+	msg := failure(t, []Option{WithBaseURL(base)}, func(b *Builder) {
+		f := b.Flow(t, context.Background())
+		f.Authorization = "Bearer tok"
+		f.Step("login", Post("/login")).
+			Step("create", Post("/items").JSONBody(map[string]string{"name": "widget"}).Capture("id", "$.id")).
+			Step("fetch", Get("/items/{{id}}").ExpectJSON(map[string]any{"name": "gadget"})).
+			Run()
+	})
+
+	require.Contains(t, msg, `step "fetch": $.name: expected gadget, got widget`)
+	require.Contains(t, msg, "request: GET /items/1")
+	require.Contains(t, msg, "response: ")
+	require.Regexp(t, `variables:\s+id = 1\n`, msg)
+
+	msg = failure(t, []Option{WithBaseURL(base)}, func(b *Builder) {
+		b.Flow(t, context.Background()).Step("fetch", Get("/items/{{missing}}")).Run()
+	})
+	require.Contains(t, msg, `step "fetch": undefined variables missing`)
+}
+
+func TestJSONBodyError(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request with an unmarshalable body was sent")
+	})
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.Send(t, context.Background(), Post(server.URL).JSONBody(func() {}))
+	})
+	require.Contains(t, msg, "JSONBody: json: unsupported type: func()")
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.Flow(t, context.Background()).Step("create", Post(server.URL).JSONBody(make(chan int))).Run()
+	})
+	require.Contains(t, msg, `step "create": JSONBody: json: unsupported type: chan int`)
+}
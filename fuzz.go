@@ -0,0 +1,308 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// FuzzField is a location in a request that fuzz payloads are written to.
+type FuzzField struct {
+	Path  string
+	Apply func(spec *RequestSpec, value []byte)
+}
+
+// Mutator lists the fuzzable fields of a base request.
+type Mutator func(base *RequestSpec) []FuzzField
+
+// FuzzOptions configures FuzzRequest.
+type FuzzOptions struct {
+	// MaxCases is the number of mutated requests to send; 0 means 100.
+	MaxCases int
+	// Seed makes the sequence of cases reproducible.
+	Seed int64
+	// Mutators default to HeaderMutator, JSONMutator and QueryMutator.
+	Mutators []Mutator
+	// Payloads default to DefaultFuzzPayloads.
+	Payloads [][]byte
+}
+
+// DefaultFuzzPayloads are the values fuzzed fields are set to: empty, oversized and
+// 10MB values, invalid UTF-8, NUL bytes, emoji, JSON metacharacters and numbers out of range.
+func DefaultFuzzPayloads() [][]byte {
+	return [][]byte{
+		{},
+		bytes.Repeat([]byte("A"), 64<<10),
+		bytes.Repeat([]byte("A"), 10<<20),
+		{0xff, 0xfe, 0xfd},
+		{'a', 0x00, 'b'},
+		[]byte("😀🔥"),
+		[]byte(`"}]{[\`),
+		[]byte("null"),
+		[]byte("-1"),
+		[]byte("1e999"),
+		[]byte("99999999999999999999999999999999"),
+		[]byte("<script>alert(1)</script>"),
+		[]byte("' OR 1=1 --"),
+		[]byte("../../etc/passwd"),
+	}
+}
+
+// FuzzRequest sends MaxCases variations of base with one field set to a fuzz payload
+// each and fails a subtest, named after the mutated field, for every 5xx response or
+// transport error. Cases are chosen deterministically from Seed.
+func (b *Builder) FuzzRequest(t *testing.T, ctx context.Context, base *RequestSpec, opts FuzzOptions) {
+	t.Helper()
+
+	fields := fuzzFields(base, opts.Mutators)
+	b.require.NotEmpty(fields, "no fuzzable fields in the base request")
+
+	payloads := opts.Payloads
+	if payloads == nil {
+		payloads = DefaultFuzzPayloads()
+	}
+
+	cases := opts.MaxCases
+	if cases == 0 {
+		cases = 100
+	}
+
+	rnd := rand.New(rand.NewSource(opts.Seed))
+
+	for i := 0; i < cases; i++ {
+		field := fields[rnd.Intn(len(fields))]
+		payload := payloads[rnd.Intn(len(payloads))]
+
+		t.Run(fmt.Sprintf("%d %s", i, field.Path), func(t *testing.T) {
+			b.forTest(t).sendFuzzCase(t, ctx, base, field, payload)
+		})
+	}
+}
+
+// FuzzTarget wires a base request into native Go fuzzing: the fuzzer chooses a field
+// by index and the payload bytes. Seed corpus entries are added for every field.
+//
+//	func FuzzCreateUser(f *testing.F) {
+//		b.FuzzTarget(f, ctx, reqbuilder.Post(url).JSONBody(user), nil)
+//	}
+func (b *Builder) FuzzTarget(f *testing.F, ctx context.Context, base *RequestSpec, mutators []Mutator) {
+	f.Helper()
+
+	fields := fuzzFields(base, mutators)
+	if len(fields) == 0 {
+		f.Fatal("no fuzzable fields in the base request")
+	}
+
+	for i := range fields {
+		f.Add(uint(i), []byte("seed"))
+	}
+
+	f.Fuzz(func(t *testing.T, index uint, payload []byte) {
+		field := fields[index%uint(len(fields))]
+		b.forTest(t).sendFuzzCase(t, ctx, base, field, payload)
+	})
+}
+
+// sendFuzzCase sends base with field set to payload and fails on a 5xx status.
+func (b *Builder) sendFuzzCase(t *testing.T, ctx context.Context, base *RequestSpec, field FuzzField, payload []byte) {
+	t.Helper()
+
+	spec := base.clone()
+	field.Apply(spec, payload)
+
+	defer func() {
+		if t.Failed() {
+			b.logf(t, slog.LevelError, "fuzzed field", slog.String("field", field.Path), slog.String("payload", excerpt(payload, 256)))
+		}
+	}()
+
+	response := b.do(t, b.newSpecRequest(t, ctx, spec))
+	defer response.Body.Close()
+
+	if response.StatusCode >= 500 {
+		b.require.Failf("server error", "field %s = %s\n%s", field.Path, excerpt(payload, 256), b.describe(response))
+	}
+}
+
+// fuzzFields collects the fields of all mutators, defaulting to the built-in ones.
+func fuzzFields(base *RequestSpec, mutators []Mutator) []FuzzField {
+	if mutators == nil {
+		mutators = []Mutator{HeaderMutator(), JSONMutator(), QueryMutator()}
+	}
+
+	var fields []FuzzField
+	for _, m := range mutators {
+		fields = append(fields, m(base)...)
+	}
+
+	return fields
+}
+
+// HeaderMutator fuzzes the values of the request headers. Bytes net/http refuses to
+// send in a header (CR, LF, NUL and other controls) are dropped from payloads.
+func HeaderMutator() Mutator {
+	return func(base *RequestSpec) []FuzzField {
+		keys := make([]string, 0, len(base.Headers))
+		for k := range base.Headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fields := make([]FuzzField, 0, len(keys))
+		for _, k := range keys {
+			key := k
+			fields = append(fields, FuzzField{
+				Path: "header " + key,
+				Apply: func(spec *RequestSpec, value []byte) {
+					spec.Headers.Set(key, string(bytes.Map(func(r rune) rune {
+						if r < 0x20 && r != '\t' || r == 0x7f {
+							return -1
+						}
+						return r
+					}, value)))
+				},
+			})
+		}
+
+		return fields
+	}
+}
+
+// QueryMutator fuzzes the values of the URL query parameters.
+func QueryMutator() Mutator {
+	return func(base *RequestSpec) []FuzzField {
+		u, err := url.Parse(base.URL)
+		if err != nil {
+			return nil
+		}
+
+		keys := make([]string, 0)
+		for k := range u.Query() {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fields := make([]FuzzField, 0, len(keys))
+		for _, k := range keys {
+			key := k
+			fields = append(fields, FuzzField{
+				Path: "query " + key,
+				Apply: func(spec *RequestSpec, value []byte) {
+					u, _ := url.Parse(spec.URL)
+					query := u.Query()
+					query.Set(key, string(value))
+					u.RawQuery = query.Encode()
+					spec.URL = u.String()
+				},
+			})
+		}
+
+		return fields
+	}
+}
+
+// JSONMutator fuzzes every string and number in a JSON request body, walking nested
+// objects and arrays. The payload is written as a JSON string with its bytes kept
+// verbatim, so invalid UTF-8 reaches the server unchanged.
+func JSONMutator() Mutator {
+	return func(base *RequestSpec) []FuzzField {
+		var doc any
+
+		decoder := json.NewDecoder(bytes.NewReader(base.Body))
+		decoder.UseNumber()
+		if len(base.Body) == 0 || decoder.Decode(&doc) != nil {
+			return nil
+		}
+
+		var fields []FuzzField
+		walkJSON(doc, "$", func(path string, set func(any) any) {
+			fields = append(fields, FuzzField{
+				Path: "json " + path,
+				Apply: func(spec *RequestSpec, value []byte) {
+					spec.Body = replaceJSONLeaf(base.Body, set, value)
+				},
+			})
+		})
+
+		return fields
+	}
+}
+
+// fuzzMarker stands in for a payload while the body is re-encoded.
+const fuzzMarker = "reqbuilder-fuzz"
+
+// walkJSON calls fn for every string and number leaf with its path and a function that
+// returns a copy of the document with the leaf replaced.
+func walkJSON(node any, path string, fn func(path string, set func(any) any)) {
+	var visit func(node any, path string, rebuild func(any) any)
+
+	visit = func(node any, path string, rebuild func(any) any) {
+		switch v := node.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			for _, k := range keys {
+				key := k
+				visit(v[key], path+"."+key, func(leaf any) any {
+					copied := make(map[string]any, len(v))
+					for kk, vv := range v {
+						copied[kk] = vv
+					}
+					copied[key] = leaf
+					return rebuild(copied)
+				})
+			}
+		case []any:
+			for i := range v {
+				index := i
+				visit(v[index], path+"["+strconv.Itoa(index)+"]", func(leaf any) any {
+					copied := append([]any(nil), v...)
+					copied[index] = leaf
+					return rebuild(copied)
+				})
+			}
+		case string, json.Number:
+			fn(path, rebuild)
+		}
+	}
+
+	visit(node, path, func(doc any) any { return doc })
+}
+
+// replaceJSONLeaf re-encodes the document with one leaf set to value as a raw JSON string.
+func replaceJSONLeaf(body []byte, set func(any) any, value []byte) []byte {
+	encoded, err := json.Marshal(set(fuzzMarker))
+	if err != nil {
+		return body
+	}
+
+	var raw bytes.Buffer
+	raw.WriteByte('"')
+	for _, c := range value {
+		switch {
+		case c == '"' || c == '\\':
+			raw.WriteByte('\\')
+			raw.WriteByte(c)
+		case c < 0x20:
+			fmt.Fprintf(&raw, `\u%04x`, c)
+		default:
+			raw.WriteByte(c)
+		}
+	}
+	raw.WriteByte('"')
+
+	marker, _ := json.Marshal(fuzzMarker)
+
+	return bytes.Replace(encoded, marker, raw.Bytes(), 1)
+}
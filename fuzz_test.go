@@ -0,0 +1,133 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fuzzServer fails with 500 when the JSON name is not valid UTF-8,
+// recording what it received.
+type fuzzServer struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *fuzzServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Header.Get("X-Client")+"|"+r.URL.RawQuery+"|"+string(body))
+	s.mu.Unlock()
+
+	var doc struct {
+		Name json.RawMessage `json:"name"`
+	}
+	if json.Unmarshal(body, &doc) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !utf8.Valid(doc.Name) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func fuzzBase(url string) *RequestSpec {
+	return Post(url+"/users?lang=en").
+		SetHeader("X-Client", "web").
+		JSONBody(map[string]any{"name": "ann", "age": 30, "tags": []any{"a", map[string]any{"k": "v"}}})
+}
+
+func TestFuzzRequestDeterministic(t *testing.T) {
+	run := func(seed int64) []string {
+		handler := &fuzzServer{}
+		server := newServer(t, handler.ServeHTTP)
+
+		b := New(require.New(t))
+		b.FuzzRequest(t, context.Background(), fuzzBase(server.URL), FuzzOptions{
+			MaxCases: 40,
+			Seed:     seed,
+			Payloads: [][]byte{[]byte("😀"), []byte("null"), []byte("' OR 1=1 --")},
+		})
+
+		return handler.requests
+	}
+
+	first := run(7)
+	require.Len(t, first, 40)
+	require.Equal(t, first, run(7))
+	require.NotEqual(t, first, run(8))
+}
+
+func TestFuzzFields(t *testing.T) {
+	var paths []string
+	for _, field := range fuzzFields(fuzzBase("http://localhost"), nil) {
+		paths = append(paths, field.Path)
+	}
+
+	require.Equal(t, []string{
+		"header Content-Type", "header X-Client",
+		"json $.age", "json $.name", "json $.tags[0]", "json $.tags[1].k",
+		"query lang",
+	}, paths)
+}
+
+func TestFuzzMutators(t *testing.T) {
+	base := fuzzBase("http://localhost")
+	fields := fuzzFields(base, nil)
+	byPath := make(map[string]FuzzField, len(fields))
+	for _, field := range fields {
+		byPath[field.Path] = field
+	}
+
+	spec := base.clone()
+	byPath["header X-Client"].Apply(spec, []byte("a\r\nInjected: 1\x00b"))
+	require.Equal(t, "aInjected: 1b", spec.Headers.Get("X-Client"))
+
+	spec = base.clone()
+	byPath["json $.tags[1].k"].Apply(spec, []byte{'"', 0xff, '\n'})
+	require.Equal(t, `{"age":30,"name":"ann","tags":["a",{"k":"\"`+"\xff"+`\u000a"}]}`, string(spec.Body))
+	require.JSONEq(t, `{"name":"ann","age":30,"tags":["a",{"k":"v"}]}`, string(base.Body), "base must not change")
+
+	spec = base.clone()
+	byPath["query lang"].Apply(spec, []byte("x&y=z"))
+	require.Equal(t, "http://localhost/users?lang=x%26y%3Dz", spec.URL)
+}
+
+func TestSendFuzzCaseReportsServerErrors(t *testing.T) {
+	handler := &fuzzServer{}
+	server := newServer(t, handler.ServeHTTP)
+
+	base := fuzzBase(server.URL)
+	var name FuzzField
+	for _, field := range fuzzFields(base, []Mutator{JSONMutator()}) {
+		if field.Path == "json $.name" {
+			name = field
+		}
+	}
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.sendFuzzCase(t, context.Background(), base, name, []byte{0xff, 0xfe})
+	})
+	require.Contains(t, msg, "server error")
+	require.Contains(t, msg, "field json $.name = \xff\xfe")
+	require.Contains(t, msg, "-> 500 Internal Server Error")
+}
+
+// FuzzCreateUser shows FuzzTarget in native Go fuzzing: go test runs the seed corpus
+// and go test -fuzz FuzzCreateUser explores further.
+func FuzzCreateUser(f *testing.F) {
+	server := httptest.NewServer(&fuzzServer{})
+	f.Cleanup(server.Close)
+
+	New(require.New(f)).FuzzTarget(f, context.Background(),
+		Post(server.URL+"/users?lang=en").SetHeader("X-Client", "web").JSONBody(map[string]any{"age": 30}),
+		[]Mutator{HeaderMutator(), QueryMutator()})
+}
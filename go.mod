@@ -4,12 +4,16 @@ go 1.22.1
 
 require (
 	github.com/andybalholm/brotli v1.1.1
+	github.com/fxamacker/cbor/v2 v2.7.0
 	github.com/klauspost/compress v1.17.11
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/net v0.33.0
+	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 )
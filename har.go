@@ -0,0 +1,191 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// harEntry is the subset of a HAR 1.2 entry needed to replay its request.
+type harEntry struct {
+	Request struct {
+		Method  string `json:"method"`
+		URL     string `json:"url"`
+		Headers []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"headers"`
+		Cookies []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"cookies"`
+		PostData *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+			Params   []struct {
+				Name        string `json:"name"`
+				Value       string `json:"value"`
+				FileName    string `json:"fileName"`
+				ContentType string `json:"contentType"`
+			} `json:"params"`
+		} `json:"postData"`
+	} `json:"request"`
+}
+
+// hopByHopHeaders are connection-specific headers that must not be replayed.
+var hopByHopHeaders = map[string]bool{
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Proxy-Connection":    true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+	"Content-Length":      true,
+	"Host":                true,
+}
+
+// FromHAREntry builds a RequestSpec from a single HAR entry (the object holding
+// "request" and "response"), as exported by browser dev tools. Hop-by-hop headers are
+// dropped, base64 post data is decoded and multipart params are re-encoded into a body.
+func FromHAREntry(t *testing.T, entryJSON []byte) *RequestSpec {
+	t.Helper()
+
+	spec, err := parseHAREntry(entryJSON)
+	require.NoError(t, err, "parsing HAR entry")
+
+	return spec
+}
+
+func parseHAREntry(entryJSON []byte) (*RequestSpec, error) {
+	var entry harEntry
+	if err := json.Unmarshal(entryJSON, &entry); err != nil {
+		return nil, err
+	}
+
+	request := entry.Request
+	if request.Method == "" || request.URL == "" {
+		return nil, fmt.Errorf("HAR entry has no request method or URL")
+	}
+
+	spec := NewSpec(request.Method, request.URL)
+
+	for _, c := range request.Cookies {
+		spec.Cookies = append(spec.Cookies, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+
+	for _, h := range request.Headers {
+		key := http.CanonicalHeaderKey(h.Name)
+		if strings.HasPrefix(h.Name, ":") || hopByHopHeaders[key] {
+			continue
+		}
+		if key == "Cookie" && len(spec.Cookies) != 0 {
+			continue
+		}
+		spec.Headers.Add(key, h.Value)
+	}
+
+	if request.PostData == nil {
+		return spec, nil
+	}
+
+	postData := request.PostData
+
+	switch {
+	case postData.Text == "" && len(postData.Params) != 0 && strings.HasPrefix(postData.MimeType, "multipart/form-data"):
+		buf := &bytes.Buffer{}
+		writer := multipart.NewWriter(buf)
+
+		if _, params, err := mime.ParseMediaType(postData.MimeType); err == nil && params["boundary"] != "" {
+			if err := writer.SetBoundary(params["boundary"]); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, p := range postData.Params {
+			header := textproto.MIMEHeader{}
+			disposition := fmt.Sprintf(`form-data; name="%s"`, p.Name)
+			if p.FileName != "" {
+				disposition += fmt.Sprintf(`; filename="%s"`, p.FileName)
+			}
+			header.Set("Content-Disposition", disposition)
+			if p.ContentType != "" {
+				header.Set("Content-Type", p.ContentType)
+			}
+
+			part, err := writer.CreatePart(header)
+			if err != nil {
+				return nil, err
+			}
+			if _, err = part.Write([]byte(p.Value)); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+
+		spec.Body = buf.Bytes()
+		spec.Headers.Set("Content-Type", writer.FormDataContentType())
+	case postData.Text == "" && len(postData.Params) != 0:
+		values := url.Values{}
+		for _, p := range postData.Params {
+			values.Add(p.Name, p.Value)
+		}
+		spec.Body = []byte(values.Encode())
+	case postData.Encoding == "base64":
+		body, err := base64.StdEncoding.DecodeString(postData.Text)
+		if err != nil {
+			return nil, fmt.Errorf("decoding base64 post data: %w", err)
+		}
+		spec.Body = body
+	default:
+		spec.Body = []byte(postData.Text)
+	}
+
+	if spec.Headers.Get("Content-Type") == "" && postData.MimeType != "" {
+		spec.Headers.Set("Content-Type", postData.MimeType)
+	}
+
+	return spec, nil
+}
+
+// RebaseHost returns a copy of spec targeting newHost, which is either a bare host
+// ("staging.example.com:8443") or a scheme and host ("http://127.0.0.1:8080").
+// The path and query are kept.
+func RebaseHost(spec *RequestSpec, newHost string) (*RequestSpec, error) {
+	u, err := url.Parse(spec.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(newHost, "://") {
+		base, err := url.Parse(newHost)
+		if err != nil {
+			return nil, err
+		}
+		u.Scheme, u.Host = base.Scheme, base.Host
+	} else {
+		u.Host = newHost
+	}
+
+	rebased := *spec
+	rebased.URL = u.String()
+	rebased.Headers = spec.Headers.Clone()
+
+	return &rebased, nil
+}
@@ -0,0 +1,93 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromHAREntryReplay(t *testing.T) {
+	type seen struct {
+		method, uri string
+		header      http.Header
+		body        []byte
+		form        map[string]string
+		file        string
+	}
+
+	var requests []seen
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		s := seen{method: r.Method, uri: r.RequestURI, header: r.Header}
+		if r.URL.Path == "/api/returns" {
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			s.form = map[string]string{"order": r.FormValue("order")}
+			file, header, err := r.FormFile("photo")
+			require.NoError(t, err)
+			data, _ := io.ReadAll(file)
+			s.file = header.Filename + ":" + header.Header.Get("Content-Type") + ":" + string(data)
+		} else {
+			s.body, _ = io.ReadAll(r.Body)
+		}
+		requests = append(requests, s)
+	})
+
+	data, err := os.ReadFile("testdata/checkout.har")
+	require.NoError(t, err)
+
+	var har struct {
+		Log struct {
+			Entries []json.RawMessage `json:"entries"`
+		} `json:"log"`
+	}
+	require.NoError(t, json.Unmarshal(data, &har))
+
+	b := New(require.New(t))
+	for _, entry := range har.Log.Entries {
+		spec, err := RebaseHost(FromHAREntry(t, entry), server.URL)
+		require.NoError(t, err)
+		b.Send(t, context.Background(), spec)
+	}
+
+	require.Len(t, requests, 3)
+
+	cart := requests[0]
+	require.Equal(t, http.MethodPost, cart.method)
+	require.Equal(t, "/api/cart/items?currency=EUR", cart.uri)
+	require.Equal(t, "application/json", cart.header.Get("Content-Type"))
+	require.Equal(t, "b7e1c9", cart.header.Get("X-Request-Id"))
+	require.Equal(t, "de-DE,de;q=0.9", cart.header.Get("Accept-Language"))
+	require.Equal(t, []string{"session=s-42; theme=dark"}, cart.header.Values("Cookie"))
+	require.Empty(t, cart.header.Get(":authority"))
+	require.JSONEq(t, `{"sku":"A-100","qty":2}`, string(cart.body))
+
+	returns := requests[1]
+	require.Equal(t, "/api/returns", returns.uri)
+	require.Equal(t, map[string]string{"order": "1042"}, returns.form)
+	require.Equal(t, "damage.png:image/png:PNGDATA", returns.file)
+	require.Empty(t, returns.header.Values("Transfer-Encoding"))
+
+	avatar := requests[2]
+	require.Equal(t, http.MethodPut, avatar.method)
+	require.Equal(t, []byte{0, 1, 2, 3, 255}, avatar.body)
+}
+
+func TestRebaseHost(t *testing.T) {
+	spec := Get("https://shop.example.com/api/items?page=2").SetHeader("X-Env", "prod")
+
+	rebased, err := RebaseHost(spec, "staging.example.com:8443")
+	require.NoError(t, err)
+	require.Equal(t, "https://staging.example.com:8443/api/items?page=2", rebased.URL)
+
+	rebased, err = RebaseHost(spec, "http://127.0.0.1:8080")
+	require.NoError(t, err)
+	require.Equal(t, "http://127.0.0.1:8080/api/items?page=2", rebased.URL)
+
+	rebased.SetHeader("X-Env", "staging")
+	require.Equal(t, "https://shop.example.com/api/items?page=2", spec.URL)
+	require.Equal(t, "prod", spec.Headers.Get("X-Env"))
+}
@@ -0,0 +1,389 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// HTMLPage is a parsed HTML response.
+type HTMLPage struct {
+	URL     *url.URL
+	Root    *html.Node
+	cookies []*http.Cookie
+	b       *Builder
+}
+
+// Form is an HTML form with its current field values. Fields holds the first value of
+// each field and Values all of them, for checkbox groups and multiple selects; a field
+// changed or removed in Fields is submitted as in Fields.
+type Form struct {
+	Action  string
+	Method  string
+	Enctype string
+	Fields  map[string]string
+	Values  url.Values
+	page    *HTMLPage
+}
+
+// Link is an anchor on an HTML page with its href resolved against the page URL.
+type Link struct {
+	Href string
+	Text string
+}
+
+// ParseHTML parses the decoded response body as HTML. The cookies, typically those the
+// page was requested with, are kept on the page together with the ones the response
+// sets, and sent along by SubmitForm.
+func (b *Builder) ParseHTML(t *testing.T, response *http.Response, cookies ...*http.Cookie) *HTMLPage {
+	t.Helper()
+
+	body := b.requireBody(response)
+
+	root, err := html.Parse(bytes.NewReader(body))
+	b.require.NoError(err, "parsing HTML")
+
+	page := &HTMLPage{Root: root, cookies: mergeCookies(response, cookies), b: b, URL: &url.URL{}}
+	if response.Request != nil {
+		page.URL = response.Request.URL
+	}
+
+	return page
+}
+
+// FindForm returns the form matching selectorOrName: "#id" matches the id attribute,
+// anything else the name or id attribute, and "" the first form on the page.
+// The test fails when no form matches.
+func (p *HTMLPage) FindForm(selectorOrName string) *Form {
+	var found *html.Node
+
+	walk(p.Root, func(n *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if n.Type != html.ElementNode || n.Data != "form" {
+			return true
+		}
+
+		id := attr(n, "id")
+		switch {
+		case selectorOrName == "",
+			strings.HasPrefix(selectorOrName, "#") && id == selectorOrName[1:],
+			attr(n, "name") == selectorOrName,
+			id == selectorOrName:
+			found = n
+			return false
+		}
+
+		return true
+	})
+
+	if found == nil {
+		p.b.require.Failf("form not found", "no form matching %q on %s", selectorOrName, p.URL)
+	}
+
+	method := strings.ToUpper(attr(found, "method"))
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	enctype := attr(found, "enctype")
+	if enctype == "" {
+		enctype = "application/x-www-form-urlencoded"
+	}
+
+	values := formFields(found)
+	fields := make(map[string]string, len(values))
+	for k := range values {
+		fields[k] = values.Get(k)
+	}
+
+	return &Form{
+		Action:  attr(found, "action"),
+		Method:  method,
+		Enctype: enctype,
+		Fields:  fields,
+		Values:  values,
+		page:    p,
+	}
+}
+
+// Links returns all anchors with an href on the page.
+func (p *HTMLPage) Links() []Link {
+	var links []Link
+
+	walk(p.Root, func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.Data == "a" && hasAttr(n, "href") {
+			href := attr(n, "href")
+			if u, err := p.URL.Parse(href); err == nil {
+				href = u.String()
+			}
+			links = append(links, Link{Href: href, Text: strings.TrimSpace(text(n))})
+		}
+		return true
+	})
+
+	return links
+}
+
+// Meta returns the content of the <meta> element with the given name or property.
+func (p *HTMLPage) Meta(name string) (string, bool) {
+	var content string
+	var ok bool
+
+	walk(p.Root, func(n *html.Node) bool {
+		if ok {
+			return false
+		}
+		if n.Type == html.ElementNode && n.Data == "meta" &&
+			(strings.EqualFold(attr(n, "name"), name) || strings.EqualFold(attr(n, "property"), name)) {
+			content, ok = attr(n, "content"), true
+			return false
+		}
+		return true
+	})
+
+	return content, ok
+}
+
+// SubmitForm submits the form with its field values, replaced or extended by overrides.
+// The action is resolved against the page URL, the body is encoded according to the
+// form's enctype and the page's cookies, see ParseHTML, are sent along.
+func (b *Builder) SubmitForm(
+	t *testing.T,
+	ctx context.Context,
+	form *Form,
+	overrides map[string]string) (*http.Response, []*http.Cookie) {
+	t.Helper()
+
+	spec := b.formSpec(t, form, overrides)
+	spec.Cookies = form.page.cookies
+
+	response := b.do(t, b.newSpecRequest(t, ctx, spec))
+
+	return response, mergeCookies(response, spec.Cookies)
+}
+
+// ParseHTML parses the decoded response body as HTML with the session's cookies.
+func (s *Session) ParseHTML(t *testing.T, response *http.Response) *HTMLPage {
+	t.Helper()
+
+	return s.b.ParseHTML(t, response, s.Cookies()...)
+}
+
+// SubmitForm submits the form like Builder.SubmitForm, within the session: it sends
+// the session's cookies, sticky headers and Referer and records the response's.
+func (s *Session) SubmitForm(
+	t *testing.T,
+	ctx context.Context,
+	form *Form,
+	overrides map[string]string,
+	opts ...Option) *http.Response {
+	t.Helper()
+
+	return s.Send(t, ctx, s.b.formSpec(t, form, overrides), opts...)
+}
+
+// formSpec returns the RequestSpec submitting form with overrides applied.
+func (b *Builder) formSpec(t *testing.T, form *Form, overrides map[string]string) *RequestSpec {
+	t.Helper()
+
+	target, err := form.page.URL.Parse(form.Action)
+	b.require.NoErrorf(err, "invalid form action %q", form.Action)
+
+	values := url.Values{}
+	for k, v := range form.Fields {
+		if all := form.Values[k]; len(all) != 0 && all[0] == v {
+			values[k] = append([]string(nil), all...)
+		} else {
+			values.Set(k, v)
+		}
+	}
+	for k, v := range overrides {
+		values.Set(k, v)
+	}
+
+	spec := NewSpec(form.Method, "")
+
+	switch {
+	case form.Method == http.MethodGet:
+		target.RawQuery = values.Encode()
+	case strings.HasPrefix(form.Enctype, "multipart/form-data"):
+		buf := &bytes.Buffer{}
+		writer := b.multipartWriter(buf)
+		// Sorted, like Encode, so the body is the same on every run.
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range values[k] {
+				b.require.NoError(writer.WriteField(k, v))
+			}
+		}
+		b.require.NoError(writer.Close())
+		spec.BodyBytes(writer.FormDataContentType(), buf.Bytes())
+	default:
+		spec.BodyBytes("application/x-www-form-urlencoded", []byte(values.Encode()))
+	}
+
+	spec.URL = target.String()
+
+	return spec
+}
+
+// formFields collects the submittable values of a form's controls in document order.
+func formFields(form *html.Node) url.Values {
+	fields := url.Values{}
+
+	walk(form, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+
+		name := attr(n, "name")
+		if name == "" || hasAttr(n, "disabled") {
+			return true
+		}
+
+		switch n.Data {
+		case "input":
+			switch strings.ToLower(attr(n, "type")) {
+			case "submit", "button", "image", "reset", "file":
+			case "checkbox", "radio":
+				if hasAttr(n, "checked") {
+					value := attr(n, "value")
+					if !hasAttr(n, "value") {
+						value = "on"
+					}
+					fields.Add(name, value)
+				}
+			default:
+				fields.Add(name, attr(n, "value"))
+			}
+		case "textarea":
+			fields.Add(name, text(n))
+		case "select":
+			if hasAttr(n, "multiple") {
+				fields[name] = append(fields[name], selectedOptions(n)...)
+			} else {
+				fields.Add(name, selectedOption(n))
+			}
+			return false
+		}
+
+		return true
+	})
+
+	return fields
+}
+
+// optionValue returns the value of an option element, its text without a value attribute.
+func optionValue(n *html.Node) string {
+	if !hasAttr(n, "value") {
+		return strings.TrimSpace(text(n))
+	}
+
+	return attr(n, "value")
+}
+
+// selectedOptions returns the values of the selected options of a multiple select.
+func selectedOptions(sel *html.Node) []string {
+	var selected []string
+
+	walk(sel, func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.Data == "option" && hasAttr(n, "selected") {
+			selected = append(selected, optionValue(n))
+		}
+
+		return true
+	})
+
+	return selected
+}
+
+// selectedOption returns the value of the selected option, or of the first one.
+func selectedOption(sel *html.Node) string {
+	first, selected := "", ""
+	seen, found := false, false
+
+	walk(sel, func(n *html.Node) bool {
+		if found {
+			return false
+		}
+		if n.Type != html.ElementNode || n.Data != "option" {
+			return true
+		}
+
+		value := optionValue(n)
+
+		if !seen {
+			first, seen = value, true
+		}
+		if hasAttr(n, "selected") {
+			selected, found = value, true
+			return false
+		}
+
+		return true
+	})
+
+	if found {
+		return selected
+	}
+
+	return first
+}
+
+// walk visits n and its descendants depth-first until fn returns false for a node,
+// which skips that node's children.
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// text returns the concatenated text content of n.
+func text(n *html.Node) string {
+	var sb strings.Builder
+
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+		return true
+	})
+
+	return sb.String()
+}
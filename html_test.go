@@ -0,0 +1,156 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// consentServer is an OAuth-style consent screen: /login starts a session, /consent
+// renders the form for a logged-in user and /approve redirects back to the client
+// with a code once the session, CSRF token and decision check out.
+func consentServer(t *testing.T) string {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "s1", Path: "/"})
+	})
+	mux.HandleFunc("/consent", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("sid"); err != nil || c.Value != "s1" {
+			http.Error(w, "login required", http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "csrf", Value: "c1", Path: "/"})
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><meta name="client" content="demo-app"></head><body>
+<a href="/deny">Cancel</a>
+<form id="consent" action="approve?client_id=demo-app" method="post" enctype="multipart/form-data">
+  <input type="hidden" name="csrf" value="c1">
+  <input type="checkbox" name="scope_email" value="yes" checked>
+  <select name="duration"><option value="1h">1 hour</option><option value="1d" selected>1 day</option></select>
+  <input type="submit" name="decision" value="allow">
+</form></body></html>`)
+	})
+	mux.HandleFunc("/approve", func(w http.ResponseWriter, r *http.Request) {
+		sid, err := r.Cookie("sid")
+		if err != nil || sid.Value != "s1" {
+			http.Error(w, "login required", http.StatusUnauthorized)
+			return
+		}
+		csrf, err := r.Cookie("csrf")
+		if err != nil || r.FormValue("csrf") != csrf.Value {
+			http.Error(w, "bad csrf token", http.StatusForbidden)
+			return
+		}
+		if r.FormValue("decision") != "allow" || r.FormValue("duration") != "1d" || r.FormValue("scope_email") != "yes" {
+			http.Error(w, "unexpected form "+r.Form.Encode(), http.StatusBadRequest)
+			return
+		}
+		http.Redirect(w, r, "/callback?code=abc&client_id="+r.URL.Query().Get("client_id"), http.StatusFound)
+	})
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.Query().Get("code"))
+	})
+
+	return newServer(t, mux.ServeHTTP).URL
+}
+
+func TestSessionConsentFlow(t *testing.T) {
+	base := consentServer(t)
+	ctx := context.Background()
+
+	s := New(require.New(t), WithBaseURL(base)).Session()
+	s.Send(t, ctx, Get("/login"))
+
+	page := s.ParseHTML(t, s.Send(t, ctx, Get("/consent")))
+
+	client, ok := page.Meta("client")
+	require.True(t, ok)
+	require.Equal(t, "demo-app", client)
+	require.Equal(t, []Link{{Href: base + "/deny", Text: "Cancel"}}, page.Links())
+
+	form := page.FindForm("#consent")
+	require.Equal(t, http.MethodPost, form.Method)
+	require.Equal(t, map[string]string{"csrf": "c1", "scope_email": "yes", "duration": "1d"}, form.Fields)
+
+	response := s.SubmitForm(t, ctx, form, map[string]string{"decision": "allow"})
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "/callback", response.Request.URL.Path)
+	require.Equal(t, "demo-app", response.Request.URL.Query().Get("client_id"))
+	require.Equal(t, "abc", string(s.b.requireBody(response)))
+}
+
+func TestSubmitFormCallerCookies(t *testing.T) {
+	base := consentServer(t)
+	ctx := context.Background()
+	b := New(require.New(t))
+
+	_, cookies := b.RequestWithoutBody(t, ctx, http.MethodGet, base, "/login", nil, nil, "")
+	response, cookies := b.RequestWithoutBody(t, ctx, http.MethodGet, base, "/consent", nil, cookies, "")
+	page := b.ParseHTML(t, response, cookies...)
+
+	response, _ = b.SubmitForm(t, ctx, page.FindForm("consent"), map[string]string{"decision": "allow"})
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "abc", string(b.requireBody(response)))
+
+	// Without the caller's cookies only the page's own CSRF cookie is sent.
+	response, _ = b.RequestWithoutBody(t, ctx, http.MethodGet, base, "/consent", nil, cookies, "")
+	page = b.ParseHTML(t, response)
+
+	response, _ = b.SubmitForm(t, ctx, page.FindForm("consent"), map[string]string{"decision": "allow"})
+	require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestSubmitFormRepeatedFields(t *testing.T) {
+	var bodies []string
+	var forms []url.Values
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<form action="/save" method="post" enctype="multipart/form-data">
+  <input type="checkbox" name="topic" value="go" checked>
+  <input type="checkbox" name="topic" value="http" checked>
+  <input type="checkbox" name="topic" value="rust">
+  <select name="lang" multiple><option value="en" selected>English</option><option value="de">German</option><option value="fr" selected>French</option></select>
+  <select name="region"><option value="eu">EU</option><option value="" selected>Any</option></select>
+  <input type="text" name="name" value="ann">
+</form>`)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		forms = append(forms, url.Values(r.MultipartForm.Value))
+	})
+
+	b := New(require.New(t), WithMultipartBoundary("golden"))
+	response, _ := b.Send(t, context.Background(), Get(server.URL+"/edit"))
+	form := b.ParseHTML(t, response).FindForm("")
+
+	require.Equal(t, "", form.Fields["region"], "the selected empty option wins over the first one")
+	require.Equal(t, []string{"go", "http"}, form.Values["topic"])
+
+	for range 5 {
+		b.SubmitForm(t, context.Background(), form, map[string]string{"name": "bob"})
+	}
+
+	require.Equal(t, url.Values{
+		"topic":  {"go", "http"},
+		"lang":   {"en", "fr"},
+		"region": {""},
+		"name":   {"bob"},
+	}, forms[0])
+	for _, body := range bodies[1:] {
+		require.Equal(t, bodies[0], body, "fields are written in a stable order")
+	}
+	require.Less(t, strings.Index(bodies[0], `name="lang"`), strings.Index(bodies[0], `name="name"`))
+	require.Less(t, strings.Index(bodies[0], `name="region"`), strings.Index(bodies[0], `name="topic"`))
+}
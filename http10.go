@@ -0,0 +1,213 @@
+package reqbuilder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"sync"
+)
+
+// http10RoundTripper writes requests with an HTTP/1.0 request line over a dedicated connection.
+// http.Transport always speaks HTTP/1.1 on the wire, so the request is serialized by hand.
+type http10RoundTripper struct {
+	transport *http.Transport
+	capture   *rawCapture
+}
+
+func (rt *http10RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	out := req.Clone(ctx)
+	out.Close = true
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+	}
+
+	var buf bytes.Buffer
+	if err := out.Write(&buf); err != nil {
+		return nil, err
+	}
+	wire := bytes.Replace(buf.Bytes(), []byte(" HTTP/1.1\r\n"), []byte(" HTTP/1.0\r\n"), 1)
+	if rt.capture != nil {
+		rt.capture.store(wire)
+	}
+
+	conn, err := rt.dial(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+
+	response, err := rt.exchange(ctx, conn, req, wire)
+	if err != nil {
+		stop()
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	response.Close = true
+	response.Body = &connClosingBody{ReadCloser: response.Body, conn: conn, stop: stop}
+
+	return response, nil
+}
+
+// exchange writes wire to conn and reads the final response, reporting the first
+// response byte and 1xx responses to the request's client trace like http.Transport.
+func (rt *http10RoundTripper) exchange(ctx context.Context, conn net.Conn, req *http.Request, wire []byte) (*http.Response, error) {
+	trace := httptrace.ContextClientTrace(ctx)
+
+	if _, err := conn.Write(wire); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	if _, err := reader.Peek(1); err != nil {
+		return nil, err
+	}
+	if trace != nil && trace.GotFirstResponseByte != nil {
+		trace.GotFirstResponseByte()
+	}
+
+	for {
+		response, err := http.ReadResponse(reader, req)
+		if err != nil {
+			return nil, err
+		}
+
+		code := response.StatusCode
+		if code >= 200 || code == http.StatusSwitchingProtocols {
+			return response, nil
+		}
+
+		if trace != nil && trace.Got1xxResponse != nil {
+			if err = trace.Got1xxResponse(code, textproto.MIMEHeader(response.Header)); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (rt *http10RoundTripper) dial(ctx context.Context, req *http.Request) (net.Conn, error) {
+	host := req.URL.Hostname()
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	addr := net.JoinHostPort(host, port)
+
+	dial := rt.transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	// The connection is dialed outside http.Transport, so the trace hooks it would
+	// call are called here, keeping ConnInfo, Timings and 1xx capture working.
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace == nil {
+		trace = &httptrace.ClientTrace{}
+	}
+
+	if trace.GetConn != nil {
+		trace.GetConn(addr)
+	}
+	if trace.ConnectStart != nil {
+		trace.ConnectStart("tcp", addr)
+	}
+	conn, err := dial(ctx, "tcp", addr)
+	if trace.ConnectDone != nil {
+		trace.ConnectDone("tcp", addr, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL.Scheme != "https" {
+		if trace.GotConn != nil {
+			trace.GotConn(httptrace.GotConnInfo{Conn: conn})
+		}
+		return conn, nil
+	}
+
+	config := &tls.Config{}
+	if rt.transport.TLSClientConfig != nil {
+		config = rt.transport.TLSClientConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+
+	if trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	tlsConn := tls.Client(conn, config)
+	err = tlsConn.HandshakeContext(ctx)
+	if trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(tlsConn.ConnectionState(), err)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if trace.GotConn != nil {
+		trace.GotConn(httptrace.GotConnInfo{Conn: tlsConn})
+	}
+
+	return tlsConn, nil
+}
+
+// connClosingBody closes the underlying connection together with the response body,
+// or as soon as the body has been read to the end, like http.Transport does.
+type connClosingBody struct {
+	io.ReadCloser
+	conn net.Conn
+	stop func() bool
+	once sync.Once
+}
+
+func (c *connClosingBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if errors.Is(err, io.EOF) {
+		c.closeConn()
+	}
+
+	return n, err
+}
+
+func (c *connClosingBody) Close() error {
+	err := c.ReadCloser.Close()
+	c.closeConn()
+
+	return err
+}
+
+// closeConn closes the connection once.
+func (c *connClosingBody) closeConn() {
+	c.once.Do(func() {
+		c.stop()
+		c.conn.Close()
+	})
+}
@@ -0,0 +1,96 @@
+package reqbuilder
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// connServer records the protocol version and client address of every request.
+type connServer struct {
+	*httptest.Server
+	protoMinor []int
+	remote     []string
+}
+
+func newConnServer(t *testing.T) *connServer {
+	s := &connServer{}
+	s.Server = newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		s.protoMinor = append(s.protoMinor, r.ProtoMinor)
+		s.remote = append(s.remote, r.RemoteAddr)
+		w.Write([]byte("ok"))
+	})
+
+	return s
+}
+
+func TestWithHTTP10(t *testing.T) {
+	server := newConnServer(t)
+	b := New(require.New(t), WithHTTP10())
+
+	for range 2 {
+		response, _ := b.Send(t, context.Background(), Post(server.URL).BodyBytes("text/plain", []byte("hi")))
+		body, err := b.ReadResponseBody(response)
+		require.NoError(t, err)
+		require.Equal(t, "ok", string(body))
+
+		closed, ok := Wrap(response).ConnClosed()
+		require.True(t, ok)
+		require.True(t, closed)
+
+		info, ok := Wrap(response).ConnInfo()
+		require.True(t, ok)
+		require.False(t, info.Reused)
+	}
+
+	require.Equal(t, []int{0, 0}, server.protoMinor)
+	require.NotEqual(t, port(t, server.remote[0]), port(t, server.remote[1]))
+}
+
+func TestWithConnectionClose(t *testing.T) {
+	server := newConnServer(t)
+	b := New(require.New(t), WithConnectionClose())
+
+	for range 2 {
+		response, _ := b.Send(t, context.Background(), Get(server.URL))
+		_, err := b.ReadResponseBody(response)
+		require.NoError(t, err)
+		response.Body.Close()
+
+		require.Eventually(t, func() bool {
+			closed, ok := Wrap(response).ConnClosed()
+			return ok && closed
+		}, time.Second, time.Millisecond)
+	}
+
+	require.Equal(t, []int{1, 1}, server.protoMinor)
+	require.NotEqual(t, port(t, server.remote[0]), port(t, server.remote[1]))
+}
+
+func TestConnClosedKeepAlive(t *testing.T) {
+	server := newConnServer(t)
+	b := New(require.New(t))
+
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+	_, err := b.ReadResponseBody(response)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	closed, ok := Wrap(response).ConnClosed()
+	require.True(t, ok)
+	require.False(t, closed)
+}
+
+func port(t *testing.T, addr string) string {
+	t.Helper()
+
+	_, p, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+
+	return p
+}
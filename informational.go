@@ -0,0 +1,80 @@
+package reqbuilder
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"slices"
+	"testing"
+)
+
+// InfoResponse is an informational (1xx) response received before the final response.
+type InfoResponse struct {
+	StatusCode int
+	Header     http.Header
+}
+
+// InformationalResponses returns the 1xx responses received before the final response
+// of the last attempt, in order. It is nil for responses not sent by a Builder.
+func (r *Response) InformationalResponses() []InfoResponse {
+	if r.meta == nil {
+		return nil
+	}
+
+	r.meta.mu.Lock()
+	defer r.meta.mu.Unlock()
+
+	return slices.Clone(r.meta.info)
+}
+
+// ExpectEarlyHints fails the test unless a 103 Early Hints response preceded the
+// response and carried header key with value.
+func (b *Builder) ExpectEarlyHints(t *testing.T, response *http.Response, key, value string) {
+	t.Helper()
+
+	info := Wrap(response).InformationalResponses()
+	for _, hint := range info {
+		if hint.StatusCode == http.StatusEarlyHints && slices.Contains(hint.Header.Values(key), value) {
+			return
+		}
+	}
+
+	b.require.Failf("missing early hint", "expected 103 Early Hints with %s: %q, got %s",
+		http.CanonicalHeaderKey(key), value, describeInfo(info))
+}
+
+// describeInfo formats informational responses for failure messages.
+func describeInfo(info []InfoResponse) string {
+	if len(info) == 0 {
+		return "no informational responses"
+	}
+
+	description := ""
+	for _, r := range info {
+		description += "\n" + http.StatusText(r.StatusCode) + ":\n" + dumpHeader(r.Header)
+	}
+
+	return description
+}
+
+// informationalHooks adds hooks to trace that record 1xx responses into meta.
+func informationalHooks(meta *requestMeta, trace *httptrace.ClientTrace) {
+	getConn := trace.GetConn
+	trace.GetConn = func(hostPort string) {
+		if getConn != nil {
+			getConn(hostPort)
+		}
+
+		meta.mu.Lock()
+		meta.info = nil
+		meta.mu.Unlock()
+	}
+
+	trace.Got1xxResponse = func(code int, header textproto.MIMEHeader) error {
+		meta.mu.Lock()
+		meta.info = append(meta.info, InfoResponse{StatusCode: code, Header: http.Header(header).Clone()})
+		meta.mu.Unlock()
+
+		return nil
+	}
+}
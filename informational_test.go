@@ -0,0 +1,65 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func earlyHintsServer(t *testing.T) string {
+	return newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+
+		w.Header().Add("Link", "</app.js>; rel=preload; as=script")
+		w.WriteHeader(http.StatusEarlyHints)
+
+		w.Header().Del("Link")
+		w.Header().Set("X-Progress", "50%")
+		w.WriteHeader(http.StatusProcessing)
+
+		w.Header().Del("X-Progress")
+		w.Write([]byte("<html></html>"))
+	}).URL
+}
+
+func TestInformationalResponses(t *testing.T) {
+	url := earlyHintsServer(t)
+	b := New(require.New(t))
+
+	response, _ := b.Send(t, context.Background(), Get(url))
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	info := Wrap(response).InformationalResponses()
+	require.Len(t, info, 3)
+	require.Equal(t, http.StatusEarlyHints, info[0].StatusCode)
+	require.Equal(t, []string{"</style.css>; rel=preload; as=style"}, info[0].Header.Values("Link"))
+	require.Equal(t, http.StatusEarlyHints, info[1].StatusCode)
+	require.Equal(t, []string{"</style.css>; rel=preload; as=style", "</app.js>; rel=preload; as=script"}, info[1].Header.Values("Link"))
+	require.Equal(t, http.StatusProcessing, info[2].StatusCode)
+	require.Equal(t, "50%", info[2].Header.Get("X-Progress"))
+
+	b.ExpectEarlyHints(t, response, "Link", "</style.css>; rel=preload; as=style")
+	b.ExpectEarlyHints(t, response, "link", "</app.js>; rel=preload; as=script")
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.ExpectEarlyHints(t, response, "Link", "</font.woff2>; rel=preload")
+	})
+	require.Contains(t, msg, `expected 103 Early Hints with Link: "</font.woff2>; rel=preload"`)
+	require.Contains(t, msg, "</app.js>")
+
+	require.Nil(t, Wrap(&http.Response{}).InformationalResponses())
+}
+
+func TestInformationalResponsesNone(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	msg := failure(t, nil, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), Get(server.URL))
+		require.Empty(t, Wrap(response).InformationalResponses())
+		b.ExpectEarlyHints(t, response, "Link", "</style.css>; rel=preload")
+	})
+	require.Contains(t, msg, "no informational responses")
+}
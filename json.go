@@ -0,0 +1,161 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// WithJSONNumber makes DecodeJSON decode numbers into json.Number instead of float64,
+// preserving the precision of large integer IDs when decoding into interface values.
+func WithJSONNumber() Option {
+	return func(b *Builder) {
+		b.jsonNumber = true
+	}
+}
+
+// DecodeJSON decodes the (decompressed) response body into v. Responses declaring a
+// non-JSON Content-Type, such as an HTML error page, are rejected with a body excerpt
+// instead of a cryptic syntax error.
+func (b *Builder) DecodeJSON(response *http.Response, v any) error {
+	body, err := b.ReadResponseBodyAs(response, "JSON", isJSONMediaType)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if b.jsonNumber {
+		decoder.UseNumber()
+	}
+
+	return decoder.Decode(v)
+}
+
+// RequestJSON marshals body to JSON and sends it with Content-Type: application/json.
+// Per-request options such as WithQuery compose with the body.
+func (b *Builder) RequestJSON(
+	t *testing.T,
+	ctx context.Context,
+	method,
+	host,
+	endpoint string,
+	body any,
+	cookies []*http.Cookie,
+	headers map[string]string,
+	authorization string,
+	opts ...Option) (*http.Response, []*http.Cookie) {
+	t.Helper()
+
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	return b.Request(t, ctx, method, host, endpoint, reqBody, cookies, withContentType(headers, "application/json"), authorization, opts...)
+}
+
+// RequestJSONRaw sends raw, an already encoded JSON document, as-is with
+// Content-Type: application/json. Unlike RequestJSON it does not marshal raw again,
+// which would send it as a quoted JSON string. raw must be well-formed JSON.
+func (b *Builder) RequestJSONRaw(
+	t *testing.T,
+	ctx context.Context,
+	method,
+	host,
+	endpoint string,
+	raw string,
+	cookies []*http.Cookie,
+	headers map[string]string,
+	authorization string,
+	opts ...Option) (*http.Response, []*http.Cookie) {
+	t.Helper()
+
+	b.require.Truef(json.Valid([]byte(raw)), "malformed JSON body: %s", excerpt([]byte(raw), 512))
+
+	return b.Request(t, ctx, method, host, endpoint, []byte(raw), cookies, withContentType(headers, "application/json"), authorization, opts...)
+}
+
+// withContentType returns a copy of headers with Content-Type set unless the caller set one.
+func withContentType(headers map[string]string, contentType string) map[string]string {
+	merged := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		if http.CanonicalHeaderKey(k) == "Content-Type" {
+			contentType = v
+			continue
+		}
+		merged[k] = v
+	}
+	merged["Content-Type"] = contentType
+
+	return merged
+}
+
+// isJSONMediaType reports whether mediaType is application/json or a +json type.
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// ReadResponseBodyAs reads the body like ReadResponseBody and returns an error with a
+// body excerpt unless accept reports the media type of its Content-Type, after any
+// decryption, as one of the family's. Responses without a Content-Type pass. It is
+// what decoders for other formats, such as the cbor subpackage, build on.
+func (b *Builder) ReadResponseBodyAs(response *http.Response, family string, accept func(mediaType string) bool) ([]byte, error) {
+	body, contentType, err := b.readBody(response, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkContentType(response, contentType, family, accept, body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// checkContentType returns an error when the response body's content type header is
+// outside the expected family. Responses without a Content-Type pass.
+func checkContentType(response *http.Response, header, family string, accept func(mediaType string) bool, body []byte) error {
+	if header == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err == nil && accept(mediaType) {
+		return nil
+	}
+
+	return fmt.Errorf("expected %s but got %s (status %d); body: %s",
+		family, header, response.StatusCode, excerpt(body, 512))
+}
+
+// RequireJSONEq fails the test unless the decoded response body is JSON equal to
+// expected, ignoring formatting and key order. The failure shows both indented.
+func (b *Builder) RequireJSONEq(t *testing.T, response *http.Response, expected string) {
+	t.Helper()
+
+	var want any
+	err := json.Unmarshal([]byte(expected), &want)
+	b.require.NoError(err, "expected value is not JSON")
+
+	body, err := b.decodedBody(response)
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	var got any
+	if json.Unmarshal(body, &got) == nil && reflect.DeepEqual(got, want) {
+		return
+	}
+
+	var indented bytes.Buffer
+	json.Indent(&indented, []byte(expected), "", "  ")
+	b.require.Failf("JSON bodies differ", "expected:\n%s\n%s", indented.String(), b.describe(response))
+}
@@ -0,0 +1,106 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithJSONNumber(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 9007199254740993}`))
+	})
+
+	b := New(require.New(t), WithJSONNumber())
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	var v map[string]any
+	require.NoError(t, b.DecodeJSON(response, &v))
+	require.Equal(t, json.Number("9007199254740993"), v["id"])
+
+	lossy := New(require.New(t))
+	response, _ = lossy.Send(t, context.Background(), Get(server.URL))
+	require.NoError(t, lossy.DecodeJSON(response, &v))
+	require.Equal(t, float64(9007199254740992), v["id"], "float64 rounds 2^53+1 down")
+}
+
+func TestRequestJSONWithQuery(t *testing.T) {
+	var query url.Values
+	var contentType, body string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		query, contentType, body = r.URL.Query(), r.Header.Get("Content-Type"), string(data)
+	})
+
+	b := New(require.New(t))
+	b.RequestJSON(t, context.Background(), http.MethodPost, server.URL, "/items",
+		map[string]any{"name": "widget"}, nil, nil, "",
+		WithQuery(url.Values{"dry_run": {"true"}, "tag": {"a b"}}))
+
+	require.Equal(t, url.Values{"dry_run": {"true"}, "tag": {"a b"}}, query)
+	require.Equal(t, "application/json", contentType)
+	require.JSONEq(t, `{"name":"widget"}`, body)
+}
+
+func TestDecodeJSONRejectsHTML(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("<html><body><h1>502 Bad Gateway</h1></body></html>"))
+	})
+
+	b := New(require.New(t))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	var v map[string]any
+	err := b.DecodeJSON(response, &v)
+	require.EqualError(t, err, "expected JSON but got text/html; charset=utf-8 (status 500); "+
+		"body: <html><body><h1>502 Bad Gateway</h1></body></html>")
+}
+
+func TestDecodeJSONContentTypes(t *testing.T) {
+	for _, contentType := range []string{"", "application/json", "application/problem+json", "application/json; charset=utf-8"} {
+		server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if contentType == "" {
+				w.Header()["Content-Type"] = nil // suppress sniffing
+			} else {
+				w.Header().Set("Content-Type", contentType)
+			}
+			w.Write([]byte(`{"ok":true}`))
+		})
+
+		b := New(require.New(t))
+		response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+		var v map[string]any
+		require.NoError(t, b.DecodeJSON(response, &v), contentType)
+		require.Equal(t, true, v["ok"])
+	}
+}
+
+func TestRequestJSONRaw(t *testing.T) {
+	var contentType, body string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		contentType, body = r.Header.Get("Content-Type"), string(data)
+	})
+
+	raw := `{"name": "widget", "tags": ["a"]}`
+	b := New(require.New(t))
+	b.RequestJSONRaw(t, context.Background(), http.MethodPost, server.URL, "/items", raw, nil,
+		map[string]string{"content-type": "application/merge-patch+json"}, "")
+
+	require.Equal(t, raw, body, "the body must be sent as-is, not as a quoted string")
+	require.Equal(t, "application/merge-patch+json", contentType)
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.RequestJSONRaw(t, context.Background(), http.MethodPost, server.URL, "/items", `{"name": `, nil, nil, "")
+	})
+	require.Contains(t, msg, `malformed JSON body: {"name": `)
+}
@@ -0,0 +1,61 @@
+package reqbuilder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath returns the value at a simple JSONPath ("$.a.b[0].c") in a decoded JSON document.
+// Only child member and array index steps are supported.
+func jsonPath(doc any, path string) (any, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("jsonpath %q: must start with $", path)
+	}
+
+	current := doc
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			rest = rest[end+1:]
+
+			object, ok := current.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("jsonpath %q: %q is not an object member", path, key)
+			}
+			if current, ok = object[key]; !ok {
+				return nil, fmt.Errorf("jsonpath %q: no member %q", path, key)
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath %q: unterminated index", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath %q: invalid index %q", path, rest[1:end])
+			}
+			rest = rest[end+1:]
+
+			array, ok := current.([]any)
+			if !ok {
+				return nil, fmt.Errorf("jsonpath %q: index %d on a non-array", path, index)
+			}
+			if index < 0 || index >= len(array) {
+				return nil, fmt.Errorf("jsonpath %q: index %d out of range (len %d)", path, index, len(array))
+			}
+			current = array[index]
+		default:
+			return nil, fmt.Errorf("jsonpath %q: unexpected %q", path, rest[0])
+		}
+	}
+
+	return current, nil
+}
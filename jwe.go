@@ -0,0 +1,165 @@
+package reqbuilder
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"mime"
+	"slices"
+	"strings"
+)
+
+// ResponseDecryptor decrypts a decoded response body of the given content type and
+// returns the plaintext with its content type.
+type ResponseDecryptor func(contentType string, body []byte) ([]byte, string, error)
+
+// WithResponseDecryptor makes ReadResponseBody and DecodeJSON pass bodies of the listed
+// content types, "application/jose" by default, through decrypt after decompression.
+// DecodeJSON checks the content type decrypt returns.
+func WithResponseDecryptor(decrypt ResponseDecryptor, contentTypes ...string) Option {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{"application/jose"}
+	}
+
+	return func(b *Builder) {
+		b.decryptor = decrypt
+		b.decryptTypes = contentTypes
+	}
+}
+
+// decrypt applies the Builder's decryptor to body if its content type is configured.
+func (b *Builder) decrypt(contentType string, body []byte) ([]byte, string, error) {
+	if b.decryptor == nil {
+		return body, contentType, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !slices.Contains(b.decryptTypes, mediaType) {
+		return body, contentType, nil
+	}
+
+	return b.decryptor(contentType, body)
+}
+
+// jweHeader is the protected header of a JWE.
+type jweHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Cty string `json:"cty,omitempty"`
+	Zip string `json:"zip,omitempty"`
+}
+
+// JWEDecryptor returns a ResponseDecryptor for JWE compact serialization with RSA-OAEP
+// or RSA-OAEP-256 key wrapping and A256GCM content encryption. The plaintext content
+// type is taken from the "cty" header, or is application/json for JSON payloads.
+func JWEDecryptor(key *rsa.PrivateKey) ResponseDecryptor {
+	return func(contentType string, body []byte) ([]byte, string, error) {
+		parts := strings.Split(strings.TrimSpace(string(body)), ".")
+		if len(parts) != 5 {
+			return nil, "", fmt.Errorf("JWE: expected 5 compact serialization parts, got %d", len(parts))
+		}
+
+		var header jweHeader
+		raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+		if err == nil {
+			err = json.Unmarshal(raw, &header)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("JWE: decoding header: %w", err)
+		}
+
+		plaintext, err := decryptJWE(key, header, parts)
+		if err != nil {
+			return nil, "", fmt.Errorf("JWE (alg %s, enc %s): %w", header.Alg, header.Enc, err)
+		}
+
+		return plaintext, jwePayloadType(header.Cty, plaintext), nil
+	}
+}
+
+func decryptJWE(key *rsa.PrivateKey, header jweHeader, parts []string) ([]byte, error) {
+	oaepHash, err := jweOAEPHash(header.Alg)
+	if err != nil {
+		return nil, err
+	}
+	if header.Enc != "A256GCM" {
+		return nil, fmt.Errorf("unsupported content encryption %q", header.Enc)
+	}
+	if header.Zip != "" {
+		return nil, fmt.Errorf("unsupported compression %q", header.Zip)
+	}
+
+	var decoded [4][]byte
+	for i, part := range parts[1:] {
+		if decoded[i], err = base64.RawURLEncoding.DecodeString(part); err != nil {
+			return nil, fmt.Errorf("decoding part %d: %w", i+2, err)
+		}
+	}
+	encryptedKey, iv, ciphertext, tag := decoded[0], decoded[1], decoded[2], decoded[3]
+
+	cek, err := rsa.DecryptOAEP(oaepHash, nil, key, encryptedKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping key: %w", err)
+	}
+
+	gcm, err := jweGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid IV length %d", len(iv))
+	}
+
+	plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("decrypting content: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+func jweOAEPHash(alg string) (hash.Hash, error) {
+	switch alg {
+	case "RSA-OAEP":
+		return sha1.New(), nil
+	case "RSA-OAEP-256":
+		return sha256.New(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key management algorithm %q", alg)
+}
+
+func jweGCM(cek []byte) (cipher.AEAD, error) {
+	if len(cek) != 32 {
+		return nil, errors.New("A256GCM needs a 256-bit key")
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// jwePayloadType returns the media type of a decrypted payload. A cty without a slash
+// is shorthand for application/cty, see RFC 7515 section 4.1.10.
+func jwePayloadType(cty string, payload []byte) string {
+	switch {
+	case cty == "" && json.Valid(payload):
+		return "application/json"
+	case cty == "":
+		return "application/octet-stream"
+	case !strings.Contains(cty, "/"):
+		return "application/" + strings.ToLower(cty)
+	}
+
+	return cty
+}
@@ -0,0 +1,99 @@
+package reqbuilder
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWEDecryptor(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	token, err := jweEncrypt(&key.PublicKey, []byte(`{"account":"acme","balance":30}`), "")
+	require.NoError(t, err)
+	encoded, err := EncodeGzip([]byte(token))
+	require.NoError(t, err)
+
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/plain" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"account":"plain"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/jose")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(encoded)
+	})
+
+	var out struct {
+		Account string
+		Balance int
+	}
+	b := New(require.New(t), WithDisableCompression(), WithResponseDecryptor(JWEDecryptor(key)))
+
+	response, _ := b.Send(t, context.Background(), Get(server.URL+"/jwe"))
+	require.NoError(t, b.DecodeJSON(response, &out))
+	require.Equal(t, "acme", out.Account)
+	require.Equal(t, 30, out.Balance)
+
+	response, _ = b.Send(t, context.Background(), Get(server.URL+"/plain"))
+	require.NoError(t, b.DecodeJSON(response, &out), "other content types are not decrypted")
+	require.Equal(t, "plain", out.Account)
+
+	wrong := New(require.New(t), WithDisableCompression(), WithResponseDecryptor(JWEDecryptor(other)))
+	response, _ = wrong.Send(t, context.Background(), Get(server.URL+"/jwe"))
+	_, err = wrong.ReadResponseBody(response)
+	require.ErrorContains(t, err, "JWE (alg RSA-OAEP, enc A256GCM): unwrapping key")
+}
+
+// jweEncrypt encrypts payload for key as a JWE in compact serialization with RSA-OAEP
+// and A256GCM, the counterpart of JWEDecryptor for the test servers. cty, if set,
+// becomes the "cty" header.
+func jweEncrypt(key *rsa.PublicKey, payload []byte, cty string) (string, error) {
+	header, err := json.Marshal(jweHeader{Alg: "RSA-OAEP", Enc: "A256GCM", Cty: cty})
+	if err != nil {
+		return "", err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(header)
+
+	cek := make([]byte, 32)
+	if _, err := rand.Read(cek); err != nil {
+		return "", err
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, key, cek, nil)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := jweGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, iv, payload, []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return strings.Join([]string{
+		protected,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}, "."), nil
+}
@@ -0,0 +1,107 @@
+package reqbuilder
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+// WithLanguage sets Accept-Language to the given tags in order of preference,
+// see AcceptLanguage.
+func WithLanguage(tags ...string) Option {
+	value := AcceptLanguage(tags...)
+
+	return WithHeaders(http.Header{"Accept-Language": {value}})
+}
+
+// WithLanguageConfidence sets how closely a Content-Language must match in
+// ExpectContentLanguage. The default, language.High, lets "de" satisfy "de-DE";
+// language.Exact requires the same tag.
+func WithLanguageConfidence(confidence language.Confidence) Option {
+	return func(b *Builder) {
+		b.languageConfidence = confidence
+	}
+}
+
+// AcceptLanguage builds a quality-weighted Accept-Language value from tags in order of
+// preference: the first tag has no weight and each following one 0.1 less, down to 0.1.
+// A tag given with an explicit weight, such as "en;q=0.5", is passed through as is:
+//
+//	AcceptLanguage("de-DE", "de", "en;q=0.5") // "de-DE, de;q=0.9, en;q=0.5"
+func AcceptLanguage(tags ...string) string {
+	parts := make([]string, len(tags))
+
+	for i, tag := range tags {
+		q := 10 - i
+		switch {
+		case i == 0, strings.Contains(tag, ";"):
+			parts[i] = tag
+		case q < 1:
+			parts[i] = tag + ";q=0.1"
+		default:
+			parts[i] = tag + ";q=0." + strconv.Itoa(q)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// ForEachLocale runs fn as a subtest per locale, with a copy of the Builder that sends
+// that locale in Accept-Language and fails the subtest on assertion failures.
+func (b *Builder) ForEachLocale(t *testing.T, locales []string, fn func(t *testing.T, b *Builder, lang string)) {
+	t.Helper()
+
+	for _, lang := range locales {
+		t.Run(lang, func(t *testing.T) {
+			sub := b.forTest(t)
+
+			_, err := language.Parse(lang)
+			sub.require.NoErrorf(err, "invalid language tag %q", lang)
+
+			fn(t, sub.With(WithLanguage(lang)), lang)
+		})
+	}
+}
+
+// ExpectContentLanguage fails the test unless one of the tags in the response
+// Content-Language matches tag with at least the configured confidence.
+func (b *Builder) ExpectContentLanguage(t *testing.T, response *http.Response, tag string) {
+	t.Helper()
+
+	want, err := language.Parse(tag)
+	b.require.NoErrorf(err, "invalid language tag %q", tag)
+
+	header := response.Header.Get("Content-Language")
+	if header == "" {
+		b.require.Failf("missing Content-Language", "expected %s\n%s", tag, dumpHeader(response.Header))
+	}
+
+	confidence := b.languageConfidence
+	if confidence == language.No {
+		confidence = language.High
+	}
+
+	matcher := language.NewMatcher([]language.Tag{want})
+
+	var results []string
+	for _, value := range strings.Split(header, ",") {
+		got, err := language.Parse(strings.TrimSpace(value))
+		if err != nil {
+			results = append(results, fmt.Sprintf("%q: %v", value, err))
+			continue
+		}
+
+		_, _, c := matcher.Match(got)
+		if c >= confidence {
+			return
+		}
+		results = append(results, fmt.Sprintf("%s: %s", got, c))
+	}
+
+	b.require.Failf("unexpected content language", "expected %s with confidence %s, got %s (%s)",
+		tag, confidence, header, strings.Join(results, ", "))
+}
@@ -0,0 +1,78 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestAcceptLanguage(t *testing.T) {
+	require.Equal(t, "de-DE", AcceptLanguage("de-DE"))
+	require.Equal(t, "de-DE, de;q=0.9, en;q=0.8", AcceptLanguage("de-DE", "de", "en"))
+	require.Equal(t, "a, b;q=0.9, c;q=0.8, d;q=0.7, e;q=0.6, f;q=0.5, g;q=0.4, h;q=0.3, i;q=0.2, j;q=0.1, k;q=0.1",
+		AcceptLanguage("a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"))
+	require.Equal(t, "de-DE, de;q=0.9, en;q=0.5", AcceptLanguage("de-DE", "de", "en;q=0.5"))
+	require.Equal(t, "*;q=0.2, fr;q=0.9", AcceptLanguage("*;q=0.2", "fr"))
+}
+
+func TestForEachLocale(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("Accept-Language"))
+		mu.Unlock()
+
+		// Answer with the base language only, as many servers do.
+		tag, _ := language.Parse(r.Header.Get("Accept-Language"))
+		base, _ := tag.Base()
+		w.Header().Set("Content-Language", base.String())
+	})
+
+	var ran []string
+	New(require.New(t)).ForEachLocale(t, []string{"en-US", "de-DE", "ja-JP"}, func(t *testing.T, b *Builder, lang string) {
+		ran = append(ran, lang)
+		response, _ := b.Send(t, context.Background(), Get(server.URL))
+		b.ExpectContentLanguage(t, response, lang)
+	})
+
+	require.Equal(t, []string{"en-US", "de-DE", "ja-JP"}, ran)
+	sort.Strings(seen)
+	require.Equal(t, []string{"de-DE", "en-US", "ja-JP"}, seen)
+}
+
+func TestExpectContentLanguage(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Language", r.URL.Query().Get("lang"))
+	})
+
+	send := func(b *Builder, lang string) *http.Response {
+		response, _ := b.Send(t, context.Background(), Get(server.URL+"?lang="+lang))
+		return response
+	}
+
+	b := New(require.New(t))
+	b.ExpectContentLanguage(t, send(b, "de"), "de-DE")
+	b.ExpectContentLanguage(t, send(b, "de-AT"), "de-DE")
+	b.ExpectContentLanguage(t, send(b, "fr,de-DE"), "de-DE")
+
+	msg := failure(t, []Option{WithLanguageConfidence(language.Exact)}, func(b *Builder) {
+		b.ExpectContentLanguage(t, send(b, "de-AT"), "de-DE")
+	})
+	require.Contains(t, msg, "expected de-DE with confidence Exact, got de-AT (de-AT: High)")
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.ExpectContentLanguage(t, send(b, "ja"), "de-DE")
+	})
+	require.Contains(t, msg, "unexpected content language")
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.ExpectContentLanguage(t, send(b, ""), "de-DE")
+	})
+	require.Contains(t, msg, "missing Content-Language")
+}
@@ -0,0 +1,82 @@
+package reqbuilder
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ResponseTooLargeError is returned by ReadResponseBody when a decoded body exceeds the
+// limit set with WithMaxResponseBytes or WithResponseBodyLimitFor.
+type ResponseTooLargeError struct {
+	ContentType string
+	Limit       int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	if e.ContentType == "" {
+		return fmt.Sprintf("response body exceeds %d bytes", e.Limit)
+	}
+
+	return fmt.Sprintf("%s response body exceeds %d bytes", e.ContentType, e.Limit)
+}
+
+// WithMaxResponseBytes limits the decoded size of response bodies read through the
+// Builder. Zero means no limit.
+func WithMaxResponseBytes(max int64) Option {
+	return func(b *Builder) {
+		b.maxResponseBytes = max
+	}
+}
+
+// WithResponseBodyLimitFor overrides WithMaxResponseBytes for responses whose
+// Content-Type is contentType, such as "application/json", or matches a wildcard such
+// as "image/*". Parameters like charset are ignored.
+func WithResponseBodyLimitFor(contentType string, max int64) Option {
+	return func(b *Builder) {
+		limits := maps.Clone(b.responseLimits)
+		if limits == nil {
+			limits = map[string]int64{}
+		}
+		limits[strings.ToLower(contentType)] = max
+		b.responseLimits = limits
+	}
+}
+
+// responseLimit returns the body limit for response and the media type it was chosen for.
+func (b *Builder) responseLimit(response *http.Response) (int64, string) {
+	mediaType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if err == nil {
+		if max, ok := b.responseLimits[mediaType]; ok {
+			return max, mediaType
+		}
+		if major, _, ok := strings.Cut(mediaType, "/"); ok {
+			if max, ok := b.responseLimits[major+"/*"]; ok {
+				return max, mediaType
+			}
+		}
+	}
+
+	return b.maxResponseBytes, mediaType
+}
+
+// readLimited reads r up to the response's body limit.
+func (b *Builder) readLimited(response *http.Response, r io.Reader) ([]byte, error) {
+	max, mediaType := b.responseLimit(response)
+	if max <= 0 {
+		return io.ReadAll(r)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > max {
+		return nil, &ResponseTooLargeError{ContentType: mediaType, Limit: max}
+	}
+
+	return body, nil
+}
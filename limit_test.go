@@ -0,0 +1,48 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithResponseBodyLimitFor(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(`{"data":"` + strings.Repeat("x", 200) + `"}`))
+		case "/download":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(make([]byte, 10_000))
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(make([]byte, 100))
+		}
+	})
+
+	b := New(require.New(t),
+		WithMaxResponseBytes(1000),
+		WithResponseBodyLimitFor("application/json", 100),
+		WithResponseBodyLimitFor("application/octet-stream", 1<<20),
+		WithResponseBodyLimitFor("image/*", 50))
+	read := func(path string) ([]byte, error) {
+		response, _ := b.Send(t, context.Background(), Get(server.URL+path))
+		return b.ReadResponseBody(response)
+	}
+
+	_, err := read("/json")
+	var tooLarge *ResponseTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	require.EqualError(t, err, "application/json response body exceeds 100 bytes")
+
+	body, err := read("/download")
+	require.NoError(t, err)
+	require.Len(t, body, 10_000, "the octet-stream limit overrides the smaller default")
+
+	_, err = read("/image")
+	require.EqualError(t, err, "image/png response body exceeds 50 bytes")
+}
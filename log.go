@@ -0,0 +1,119 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// WithLogger sends the Builder's log output to logger instead of the test log.
+func WithLogger(logger *slog.Logger) Option {
+	return func(b *Builder) {
+		b.logger = logger
+	}
+}
+
+// WithVerbosity sets how much is logged about each request: 0 nothing, 1 one line per
+// request with method, URL, status and duration, 2 additionally the request and
+// response headers with secrets redacted, 3 additionally the start of both bodies.
+// Errors are logged at every level.
+func WithVerbosity(level int) Option {
+	return func(b *Builder) {
+		b.verbosity = level
+	}
+}
+
+// WithVerboseLogging logs a one-line summary of each request to t, as
+// "METHOD url -> status in Nms", without touching the response body.
+func WithVerboseLogging(t testing.TB) Option {
+	return func(b *Builder) {
+		b.summaryLog = t
+	}
+}
+
+// logSummary writes the WithVerboseLogging line for a request.
+func (b *Builder) logSummary(req *http.Request, response *http.Response, err error, elapsed time.Duration) {
+	b.summaryLog.Helper()
+
+	ms := elapsed.Milliseconds()
+	if err != nil {
+		b.summaryLog.Logf("%s %s -> error in %dms: %v", req.Method, redactURL(req.URL), ms, err)
+		return
+	}
+
+	b.summaryLog.Logf("%s %s -> %d in %dms", req.Method, redactURL(req.URL), response.StatusCode, ms)
+}
+
+// logf logs a message with attributes to the logger set with WithLogger or, without
+// one, to t.Log so the output stays attached to the test that produced it.
+func (b *Builder) logf(t *testing.T, level slog.Level, msg string, attrs ...any) {
+	t.Helper()
+
+	if b.logger != nil {
+		b.logger.Log(context.Background(), level, msg, attrs...)
+		return
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})).Log(context.Background(), level, msg, attrs...)
+
+	t.Log(strings.TrimSuffix(buf.String(), "\n"))
+}
+
+// logError logs an error that is about to fail the test.
+func (b *Builder) logError(t *testing.T, err error) {
+	t.Helper()
+
+	b.logf(t, slog.LevelError, err.Error())
+}
+
+// logExchange logs a request and its outcome according to the Builder's verbosity.
+func (b *Builder) logExchange(t *testing.T, req *http.Request, response *http.Response, err error, elapsed time.Duration) {
+	t.Helper()
+
+	if b.verbosity < 1 {
+		return
+	}
+
+	attrs := []any{
+		slog.String("method", req.Method),
+		slog.String("url", redactURL(req.URL)),
+		slog.Duration("duration", elapsed),
+	}
+	if response != nil {
+		attrs = append(attrs, slog.Int("status", response.StatusCode))
+	}
+
+	if b.verbosity >= 2 {
+		attrs = append(attrs, slog.String("request_headers", dumpHeader(req.Header)))
+		if response != nil {
+			attrs = append(attrs, slog.String("response_headers", dumpHeader(response.Header)))
+		}
+	}
+
+	if b.verbosity >= 3 {
+		attrs = append(attrs, slog.String("request_body", excerpt(requestBody(req), maxExcerpt)))
+		if response != nil {
+			body := b.peekBody(response, maxExcerpt)
+			attrs = append(attrs, slog.String("response_body", excerpt(body, maxExcerpt)))
+		}
+	}
+
+	if err != nil {
+		b.logf(t, slog.LevelError, "request failed", append(attrs, slog.Any("error", err))...)
+		return
+	}
+
+	b.logf(t, slog.LevelInfo, "request", attrs...)
+}
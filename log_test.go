@@ -0,0 +1,138 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithVerbosity(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Trace", "abc")
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	for _, tt := range []struct {
+		level    int
+		contains []string
+		excludes []string
+	}{
+		{0, nil, []string{"request"}},
+		{1, []string{"method=GET", "/things", "status=200", "duration="}, []string{"X-Trace", "ok"}},
+		{2, []string{"X-Trace: abc", "Authorization"}, []string{"s3cret", "ok"}},
+		{3, []string{"X-Trace: abc", `{\"ok\":true}`}, []string{"s3cret"}},
+	} {
+		var buf bytes.Buffer
+		b := New(require.New(t), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))), WithVerbosity(tt.level))
+		response, _ := b.Send(t, context.Background(), Get(server.URL+"/things").SetHeader("Authorization", "Bearer s3cret"))
+
+		require.JSONEq(t, `{"ok":true}`, string(b.requireBody(response)), "logging must leave the body to the caller")
+		for _, s := range tt.contains {
+			require.Contains(t, buf.String(), s, "verbosity %d", tt.level)
+		}
+		for _, s := range tt.excludes {
+			require.NotContains(t, buf.String(), s, "verbosity %d", tt.level)
+		}
+	}
+}
+
+func TestWithVerbosityPeeksBody(t *testing.T) {
+	release := make(chan struct{})
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), 2*maxExcerpt))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("end"))
+	})
+	unblock := sync.OnceFunc(func() { close(release) })
+	t.Cleanup(unblock)
+
+	var buf bytes.Buffer
+	b := New(require.New(t), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))), WithVerbosity(3))
+
+	// Logging returns while the server is still holding back the rest of the body.
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+	require.Contains(t, buf.String(), "response_body="+strings.Repeat("a", maxExcerpt))
+	require.NotContains(t, buf.String(), strings.Repeat("a", maxExcerpt+1))
+
+	unblock()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("a", 2*maxExcerpt)+"end", string(body))
+}
+
+// logCapture is a testing.TB that keeps what is logged to it.
+type logCapture struct {
+	testing.TB
+	lines []string
+}
+
+func (c *logCapture) Helper() {}
+
+func (c *logCapture) Logf(format string, args ...any) {
+	c.lines = append(c.lines, fmt.Sprintf(format, args...))
+}
+
+func TestWithVerboseLogging(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	capture := &logCapture{TB: t}
+	b := New(require.New(t), WithVerboseLogging(capture))
+
+	response, _ := b.Send(t, context.Background(), Get(server.URL+"/things?page=2"))
+	require.JSONEq(t, `{"ok":true}`, string(b.requireBody(response)), "the summary must leave the body to the caller")
+	b.Send(t, context.Background(), Post(server.URL+"/missing"))
+
+	require.Len(t, capture.lines, 2)
+	require.Regexp(t, `^GET `+regexp.QuoteMeta(server.URL)+`/things\?page=2 -> 200 in \d+ms$`, capture.lines[0])
+	require.Regexp(t, `^POST `+regexp.QuoteMeta(server.URL)+`/missing -> 404 in \d+ms$`, capture.lines[1])
+}
+
+func TestWithVerbosityRedactsURL(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	})
+	target := strings.Replace(server.URL, "http://", "http://ada:hunter2@", 1) + "/things?access_token=t0ken&page=2"
+
+	var buf bytes.Buffer
+	capture := &logCapture{TB: t}
+	b := New(require.New(t), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))), WithVerbosity(1),
+		WithVerboseLogging(capture), WithBodyTeeToTestLog(16))
+	t.Run("send", func(t *testing.T) {
+		response, _ := b.Send(t, context.Background(), Get(target))
+		_, err := b.ReadResponseBody(response)
+		require.NoError(t, err)
+	})
+
+	logged := buf.String() + strings.Join(capture.lines, "\n")
+	require.Contains(t, logged, "page=2")
+	require.Contains(t, logged, "response body", "the body tee logs through the Builder's logger")
+	require.NotContains(t, logged, "hunter2")
+	require.NotContains(t, logged, "t0ken")
+}
+
+func TestWithVerbosityLogsErrorOnce(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	server.Close()
+
+	var buf bytes.Buffer
+	failure(t, []Option{WithLogger(slog.New(slog.NewTextHandler(&buf, nil))), WithVerbosity(1)}, func(b *Builder) {
+		b.Send(t, context.Background(), Get(server.URL))
+	})
+	require.Equal(t, 1, strings.Count(buf.String(), "level=ERROR"), buf.String())
+}
@@ -0,0 +1,26 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// RequestMatrix sends spec once per variant, one after another, with the variant's
+// headers set over the spec's own, and returns the responses in variant order, e.g.
+// to compare content negotiation across Accept or API-Version values.
+func (b *Builder) RequestMatrix(t *testing.T, ctx context.Context, spec *RequestSpec, variants []map[string]string) []*http.Response {
+	t.Helper()
+
+	responses := make([]*http.Response, len(variants))
+	for i, variant := range variants {
+		variantSpec := spec.clone()
+		for k, v := range variant {
+			variantSpec.Headers.Set(k, v)
+		}
+
+		responses[i], _ = b.Send(t, ctx, variantSpec)
+	}
+
+	return responses
+}
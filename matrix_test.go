@@ -0,0 +1,45 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMatrix(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Vary", "Accept")
+		w.Header().Set("X-Tenant", r.Header.Get("X-Tenant"))
+		switch r.Header.Get("Accept") {
+		case "application/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"ann"}`))
+		case "application/xml":
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<user><name>ann</name></user>`))
+		default:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write([]byte("ann"))
+		}
+	})
+
+	b := New(require.New(t))
+	spec := Get(server.URL+"/users/ann").SetHeader("Accept", "*/*").SetHeader("X-Tenant", "acme")
+	responses := b.RequestMatrix(t, context.Background(), spec, []map[string]string{
+		{"Accept": "application/json"},
+		{"accept": "application/xml"},
+		{"Accept": "text/plain"},
+	})
+
+	require.Len(t, responses, 3)
+	var contentTypes []string
+	for _, response := range responses {
+		contentTypes = append(contentTypes, response.Header.Get("Content-Type"))
+		require.Equal(t, "acme", response.Header.Get("X-Tenant"), "the spec's other headers are kept")
+	}
+	require.Equal(t, []string{"application/json", "application/xml", "text/plain; charset=utf-8"}, contentTypes)
+	require.Equal(t, "*/*", spec.Headers.Get("Accept"), "the spec itself is not modified")
+	require.Equal(t, "<user><name>ann</name></user>", string(b.requireBody(responses[1])))
+}
@@ -0,0 +1,71 @@
+package reqbuilder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// newRequest is http.NewRequestWithContext with a clearer error for invalid methods.
+// Any other token, such as PURGE or REPORT, is sent as given.
+func newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	if err := validateMethod(method); err != nil {
+		return nil, err
+	}
+
+	return http.NewRequestWithContext(ctx, method, url, body)
+}
+
+// validateMethod checks that method is an RFC 9110 token.
+func validateMethod(method string) error {
+	if method == "" {
+		return nil // http.NewRequest defaults to GET
+	}
+
+	for _, c := range method {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return fmt.Errorf("invalid HTTP method %q: %q is not allowed in a method token", method, c)
+		}
+	}
+
+	return nil
+}
+
+// Trace sends TRACE to host+endpoint. If the server answers 200 with the echoed
+// request as message/http, Trace parses and returns it, so a test can check which
+// headers, such as Authorization or cookies, would be reflected; otherwise it returns
+// nil. Pair it with ExpectMethodNotAllowed to require TRACE to be disabled.
+func (b *Builder) Trace(t *testing.T, ctx context.Context, host, endpoint string, opts ...Option) (*http.Request, *http.Response) {
+	t.Helper()
+
+	response, _ := b.With(opts...).Send(t, ctx, NewSpec(http.MethodTrace, host+endpoint))
+	if response.StatusCode != http.StatusOK {
+		return nil, response
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType != "message/http" {
+		return nil, response
+	}
+
+	body, err := b.decodedBody(response)
+	if err == nil {
+		var echoed *http.Request
+		if echoed, err = http.ReadRequest(bufio.NewReader(bytes.NewReader(body))); err == nil {
+			return echoed, response
+		}
+	}
+	b.logError(t, err)
+	b.require.NoError(err, "parsing TRACE echo\n%s", b.describe(response))
+
+	return nil, response
+}
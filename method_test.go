@@ -0,0 +1,88 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtensionMethods(t *testing.T) {
+	var methods []string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+	})
+
+	b := New(require.New(t))
+	for _, method := range []string{"PURGE", "REPORT", "MKCOL", "x-custom"} {
+		b.Send(t, context.Background(), NewSpec(method, server.URL))
+	}
+	b.RequestWithoutBody(t, context.Background(), "PROPFIND", server.URL, "/", nil, nil, "")
+
+	require.Equal(t, []string{"PURGE", "REPORT", "MKCOL", "x-custom", "PROPFIND"}, methods, "methods are sent unmodified")
+}
+
+func TestInvalidMethod(t *testing.T) {
+	calls, url := flakyServer(t, 0, http.StatusOK)
+
+	for _, method := range []string{"GET /", "PUR(GE", "DÉLETE"} {
+		msg := failure(t, nil, func(b *Builder) {
+			b.Send(t, context.Background(), NewSpec(method, url))
+		})
+
+		require.Contains(t, msg, "invalid HTTP method")
+		require.Contains(t, msg, "is not allowed in a method token")
+	}
+	require.Zero(t, calls.Load(), "invalid methods must fail before sending")
+}
+
+func TestTrace(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		echo, _ := httputil.DumpRequest(r, true)
+		w.Header().Set("Content-Type", "message/http")
+		w.Write(echo)
+	})
+
+	b := New(require.New(t))
+	echoed, response := b.Trace(t, context.Background(), server.URL, "/debug",
+		WithHeaders(http.Header{"Authorization": {"Bearer s3cret"}}))
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.NotNil(t, echoed)
+	require.Equal(t, http.MethodTrace, echoed.Method)
+	require.Equal(t, "/debug", echoed.URL.Path)
+	require.Equal(t, "Bearer s3cret", echoed.Header.Get("Authorization"), "the echo shows the reflected credentials")
+}
+
+func TestTraceDisabled(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+
+	b := New(require.New(t))
+	echoed, response := b.Trace(t, context.Background(), server.URL, "/")
+
+	require.Nil(t, echoed)
+	b.ExpectMethodNotAllowed(t, response)
+}
+
+func TestExpectMethodNotAllowedFailures(t *testing.T) {
+	noAllow := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	msg := failure(t, nil, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), NewSpec(http.MethodTrace, noAllow.URL))
+		b.ExpectMethodNotAllowed(t, response)
+	})
+	require.Contains(t, msg, "405 responses must list the allowed methods")
+
+	_, url := flakyServer(t, 0, http.StatusOK)
+	msg = failure(t, nil, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), NewSpec(http.MethodTrace, url))
+		b.ExpectMethodNotAllowed(t, response)
+	})
+	require.Contains(t, msg, "405")
+}
@@ -0,0 +1,224 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+// JSONPart is a multipart field whose value is marshaled to JSON.
+type JSONPart struct {
+	Name  string
+	Value any
+}
+
+// FilePart is a file in a multipart body. ContentType defaults to application/octet-stream.
+type FilePart struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// MultipartJSONRequest sends a `multipart/form-data` body made of JSON fields, each sent
+// with Content-Type: application/json, followed by file parts.
+func (b *Builder) MultipartJSONRequest(
+	t *testing.T,
+	ctx context.Context,
+	method,
+	host,
+	endpoint string,
+	jsonParts []JSONPart,
+	files []FilePart,
+	cookies []*http.Cookie,
+	headers map[string]string,
+	authorization string,
+	opts ...Option) (*http.Response, []*http.Cookie) {
+	t.Helper()
+
+	if len(opts) != 0 {
+		b = b.With(opts...)
+	}
+
+	body := &bytes.Buffer{}
+	writer := b.multipartWriter(body)
+
+	for _, p := range jsonParts {
+		value, err := json.Marshal(p.Value)
+		if err != nil {
+			b.logError(t, err)
+		}
+		b.require.NoErrorf(err, "marshaling multipart field %q", p.Name)
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(p.Name)))
+		header.Set("Content-Type", "application/json")
+
+		b.writePart(t, writer, header, value)
+	}
+
+	for _, f := range files {
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(f.FieldName), escapeQuotes(f.FileName)))
+		header.Set("Content-Type", contentType)
+
+		b.writePart(t, writer, header, f.Content)
+	}
+
+	err := writer.Close()
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	// The boundary is ours, so the multipart Content-Type always wins over the caller's.
+	merged := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		if http.CanonicalHeaderKey(k) != "Content-Type" {
+			merged[k] = v
+		}
+	}
+	merged["Content-Type"] = writer.FormDataContentType()
+
+	return b.Request(t, ctx, method, host, endpoint, body.Bytes(), cookies, merged, authorization)
+}
+
+// WithMultipartBoundary makes multipart request bodies use boundary instead of a random
+// one, so they can be compared with golden files or signed. The boundary must be valid
+// per RFC 2046: 1 to 70 characters from letters, digits and '()+_,-./:=? not ending in
+// a space.
+func WithMultipartBoundary(boundary string) Option {
+	return func(b *Builder) {
+		b.require.NoError(validateBoundary(boundary))
+		b.multipartBoundary = boundary
+	}
+}
+
+// validateBoundary checks boundary against the RFC 2046 grammar.
+func validateBoundary(boundary string) error {
+	if len(boundary) < 1 || len(boundary) > 70 {
+		return fmt.Errorf("multipart boundary %q must be 1 to 70 characters long, got %d", boundary, len(boundary))
+	}
+
+	for _, c := range boundary {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.ContainsRune("'()+_,-./:=? ", c):
+		default:
+			return fmt.Errorf("multipart boundary %q contains invalid character %q", boundary, c)
+		}
+	}
+
+	if strings.HasSuffix(boundary, " ") {
+		return fmt.Errorf("multipart boundary %q must not end with a space", boundary)
+	}
+
+	return nil
+}
+
+// multipartWriter returns a multipart writer using the Builder's boundary, if one is set.
+func (b *Builder) multipartWriter(w io.Writer) *multipart.Writer {
+	writer := multipart.NewWriter(w)
+	if b.multipartBoundary != "" {
+		b.require.NoError(writer.SetBoundary(b.multipartBoundary))
+	}
+
+	return writer
+}
+
+// writePart writes one part to a multipart body.
+func (b *Builder) writePart(t *testing.T, writer *multipart.Writer, header textproto.MIMEHeader, content []byte) {
+	t.Helper()
+
+	part, err := writer.CreatePart(header)
+	if err == nil {
+		_, err = part.Write(content)
+	}
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+}
+
+// escapeQuotes escapes a multipart Content-Disposition parameter value.
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
+
+// StreamMultipart reads a multipart response part by part, calling fn for each part as it
+// arrives instead of buffering the whole body. Iteration stops at the first error returned
+// by fn, which StreamMultipart returns. Use PartBody to read a part with its
+// Content-Encoding removed.
+func (b *Builder) StreamMultipart(response *http.Response, fn func(part *multipart.Part) error) error {
+	mediaType, params, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("parsing Content-Type: %w", err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return fmt.Errorf("expected a multipart response, got %s", mediaType)
+	}
+
+	body, err := b.BodyReader(response)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	reader := multipart.NewReader(body, params["boundary"])
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = fn(part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// PartBody returns the body of a multipart part, decompressed on the fly according to the
+// part's Content-Encoding header.
+func PartBody(part *multipart.Part) (io.ReadCloser, error) {
+	return decodingReader(part.Header.Get("Content-Encoding"), part)
+}
+
+// decodingReader wraps r with a streaming decoder for the given content encoding.
+func decodingReader(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip":
+		return gzip.NewReader(r)
+	case "br":
+		return &brotliBody{reader: brotli.NewReader(r)}, nil
+	case "zstd":
+		return sharedZstd.reader(r)
+	case "deflate":
+		return deflateReader(r)
+	default:
+		return io.NopCloser(r), nil
+	}
+}
@@ -0,0 +1,186 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamMultipartIncremental(t *testing.T) {
+	const parts, size = 3, 1 << 20
+
+	handled := make(chan int)
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writer := multipart.NewWriter(w)
+		w.Header().Set("Content-Type", writer.FormDataContentType())
+
+		for i := range parts {
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Encoding", "gzip")
+			part, _ := writer.CreatePart(header)
+			encoded, _ := EncodeGzip(bytes.Repeat([]byte{byte('a' + i)}, size))
+			part.Write(encoded)
+			w.(http.Flusher).Flush()
+
+			// The next part is only written once the client has started on this one.
+			select {
+			case got := <-handled:
+				if got != i {
+					return
+				}
+			case <-time.After(5 * time.Second):
+				return
+			}
+		}
+		writer.Close()
+	})
+
+	b := New(require.New(t))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	seen := 0
+	err := b.StreamMultipart(response, func(part *multipart.Part) error {
+		body, err := PartBody(part)
+		require.NoError(t, err)
+
+		// The first bytes are readable before the server has sent the rest.
+		first := make([]byte, 1)
+		_, err = io.ReadFull(body, first)
+		require.NoError(t, err)
+		handled <- seen
+
+		rest, err := io.ReadAll(body)
+		require.NoError(t, err)
+		require.Equal(t, bytes.Repeat([]byte{byte('a' + seen)}, size), append(first, rest...))
+
+		seen++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, parts, seen)
+}
+
+func TestStreamMultipartStopsOnError(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writer := multipart.NewWriter(w)
+		w.Header().Set("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+		for range 3 {
+			part, _ := writer.CreatePart(textproto.MIMEHeader{})
+			part.Write([]byte("part"))
+		}
+		writer.Close()
+	})
+
+	b := New(require.New(t))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	stop := errors.New("stop")
+	calls := 0
+	err := b.StreamMultipart(response, func(*multipart.Part) error {
+		calls++
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, 1, calls)
+}
+
+func TestStreamMultipartRejectsOtherTypes(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+	})
+
+	b := New(require.New(t))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	err := b.StreamMultipart(response, func(*multipart.Part) error { return nil })
+	require.EqualError(t, err, "expected a multipart response, got application/json")
+}
+
+func TestMultipartJSONRequest(t *testing.T) {
+	type part struct {
+		name, fileName, contentType string
+		data                        []byte
+	}
+
+	var parts []part
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		reader, err := r.MultipartReader()
+		require.NoError(t, err)
+		for {
+			p, err := reader.NextPart()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			require.NoError(t, err)
+			data, _ := io.ReadAll(p)
+			parts = append(parts, part{p.FormName(), p.FileName(), p.Header.Get("Content-Type"), data})
+		}
+	})
+
+	binary := []byte{0x89, 'P', 'N', 'G', 0, 0xff, '\r', '\n'}
+
+	b := New(require.New(t))
+	response, _ := b.MultipartJSONRequest(t, context.Background(), http.MethodPost, server.URL, "/documents",
+		[]JSONPart{{Name: "metadata", Value: map[string]any{"title": "scan", "pages": 2}}},
+		[]FilePart{
+			{FieldName: "file", FileName: "scan.png", ContentType: "image/png", Content: binary},
+			{FieldName: "raw", FileName: "raw.bin", Content: []byte("x")},
+		},
+		nil, map[string]string{"Content-Type": "text/plain"}, "")
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Len(t, parts, 3)
+
+	require.Equal(t, "metadata", parts[0].name)
+	require.Equal(t, "application/json", parts[0].contentType)
+	require.JSONEq(t, `{"title":"scan","pages":2}`, string(parts[0].data))
+
+	require.Equal(t, part{"file", "scan.png", "image/png", binary}, parts[1])
+	require.Equal(t, part{"raw", "raw.bin", "application/octet-stream", []byte("x")}, parts[2])
+}
+
+func TestWithMultipartBoundaryGolden(t *testing.T) {
+	var body []byte
+	var contentType string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		contentType = r.Header.Get("Content-Type")
+	})
+
+	b := New(require.New(t), WithMultipartBoundary("golden-boundary"))
+	b.MultipartJSONRequest(t, context.Background(), http.MethodPost, server.URL, "/documents",
+		[]JSONPart{
+			{Name: "metadata", Value: map[string]any{"title": "scan"}},
+			{Name: "tags", Value: []string{"a", "b"}},
+		}, nil, nil, nil, "")
+
+	golden, err := os.ReadFile(filepath.Join("testdata", "multipart_two_fields.golden"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/form-data; boundary=golden-boundary", contentType)
+	require.Equal(t, string(golden), string(body))
+}
+
+func TestWithMultipartBoundaryInvalid(t *testing.T) {
+	for boundary, want := range map[string]string{
+		"":                      "must be 1 to 70 characters long, got 0",
+		strings.Repeat("x", 71): "must be 1 to 70 characters long, got 71",
+		"semi;colon":            `contains invalid character ';'`,
+		"trailing ":             "must not end with a space",
+	} {
+		msg := failure(t, nil, func(b *Builder) {
+			b.With(WithMultipartBoundary(boundary))
+		})
+		require.Contains(t, msg, want, boundary)
+	}
+}
@@ -0,0 +1,83 @@
+package reqbuilder
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// NegotiationCase is one Accept header and the response it should produce.
+// WantContentType is compared as a media type, so parameters such as charset are ignored;
+// leave it empty for responses without a representation, such as 406.
+type NegotiationCase struct {
+	Accept          string
+	WantStatus      int
+	WantContentType string
+}
+
+// NegotiationTest requests url once per case as a subtest, sending Accept verbatim, and
+// checks the status and Content-Type. When the cases expect more than one representation,
+// every successful response must also list Accept in Vary.
+func (b *Builder) NegotiationTest(t *testing.T, ctx context.Context, url string, cases []NegotiationCase) {
+	t.Helper()
+
+	representations := make(map[string]bool)
+	for _, c := range cases {
+		if c.WantContentType != "" {
+			representations[c.WantContentType] = true
+		}
+	}
+
+	for _, c := range cases {
+		t.Run("Accept: "+c.Accept, func(t *testing.T) {
+			sub := b.forTest(t)
+
+			response, _ := sub.RequestWithoutBody(t, ctx, http.MethodGet, url, "", map[string]string{"Accept": c.Accept}, nil, "")
+			defer response.Body.Close()
+
+			sub.ExpectStatus(t, response, c.WantStatus)
+
+			if c.WantContentType != "" {
+				sub.expectMediaType(t, response, c.WantContentType)
+			}
+
+			if len(representations) > 1 && response.StatusCode < 300 && !varies(response, "Accept") {
+				sub.require.Failf("missing Vary: Accept", "the endpoint has %d representations\n%s",
+					len(representations), dumpHeader(response.Header))
+			}
+		})
+	}
+}
+
+// expectMediaType fails unless the response Content-Type has the given media type.
+func (b *Builder) expectMediaType(t *testing.T, response *http.Response, want string) {
+	t.Helper()
+
+	header := response.Header.Get("Content-Type")
+
+	got, _, err := mime.ParseMediaType(header)
+	b.require.NoErrorf(err, "invalid Content-Type %q", header)
+
+	wanted, _, err := mime.ParseMediaType(want)
+	b.require.NoErrorf(err, "invalid expected content type %q", want)
+
+	if got != wanted {
+		b.require.Failf("unexpected content type", "expected %s, got %s\n%s", wanted, header, b.describe(response))
+	}
+}
+
+// varies reports whether the response Vary header covers the request header key.
+func varies(response *http.Response, key string) bool {
+	for _, value := range response.Header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "*" || strings.EqualFold(field, key) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
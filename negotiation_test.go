@@ -0,0 +1,79 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiationTest(t *testing.T) {
+	var mu sync.Mutex
+	var accepts []string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header.Get("Accept")
+		mu.Lock()
+		accepts = append(accepts, accept)
+		mu.Unlock()
+
+		w.Header().Set("Vary", "Accept-Encoding, Accept")
+		switch {
+		case strings.Contains(accept, "application/xml"):
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.Write([]byte("<item/>"))
+		case strings.Contains(accept, "application/json"), strings.Contains(accept, "*/*"):
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte("{}"))
+		default:
+			w.WriteHeader(http.StatusNotAcceptable)
+		}
+	})
+
+	cases := []NegotiationCase{
+		{Accept: "application/json", WantStatus: http.StatusOK, WantContentType: "application/json"},
+		{Accept: "application/xml", WantStatus: http.StatusOK, WantContentType: "application/xml"},
+		{Accept: "text/html;q=0.9, */*;q=0.1", WantStatus: http.StatusOK, WantContentType: "application/json"},
+		{Accept: "image/png", WantStatus: http.StatusNotAcceptable},
+	}
+
+	New(require.New(t)).NegotiationTest(t, context.Background(), server.URL+"/item", cases)
+
+	require.Equal(t, []string{"application/json", "application/xml", "text/html;q=0.9, */*;q=0.1", "image/png"}, accepts,
+		"Accept must be sent verbatim")
+}
+
+func TestExpectMediaType(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	})
+
+	b := New(require.New(t))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+	b.expectMediaType(t, response, "text/html")
+	b.expectMediaType(t, response, "TEXT/HTML; charset=latin1")
+
+	msg := failure(t, nil, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), Get(server.URL))
+		b.expectMediaType(t, response, "application/json")
+	})
+	require.Contains(t, msg, "expected application/json, got text/html; charset=utf-8")
+}
+
+func TestVaries(t *testing.T) {
+	for vary, want := range map[string]bool{
+		"":                        false,
+		"Accept":                  true,
+		"accept-encoding, accept": true,
+		"Accept-Encoding":         false,
+		"*":                       true,
+	} {
+		response := &http.Response{Header: http.Header{}}
+		if vary != "" {
+			response.Header.Set("Vary", vary)
+		}
+		require.Equal(t, want, varies(response, "Accept"), vary)
+	}
+}
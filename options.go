@@ -0,0 +1,197 @@
+package reqbuilder
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"time"
+)
+
+// Option configures a Builder.
+type Option func(*Builder)
+
+// WithMethodOverride sends PUT, PATCH, DELETE and other non-standard methods as a POST
+// carrying the intended method in the X-HTTP-Method-Override header.
+func WithMethodOverride() Option {
+	return func(b *Builder) {
+		b.methodOverride = true
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used for HTTPS requests and raw TLS connections.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(b *Builder) {
+		b.ownTransport().TLSClientConfig = config.Clone()
+	}
+}
+
+// WithConnectionClose sends every request with Connection: close, forcing a fresh connection per request.
+func WithConnectionClose() Option {
+	return func(b *Builder) {
+		b.connectionClose = true
+	}
+}
+
+// WithHTTP10 sends requests with an HTTP/1.0 request line, Connection: close and a known
+// Content-Length instead of chunked encoding.
+func WithHTTP10() Option {
+	return func(b *Builder) {
+		b.connectionClose = true
+		b.http10 = true
+	}
+}
+
+// WithClient sends requests with the given client instead of the Builder's own one.
+// Combined with With it overrides the client for a single request:
+//
+//	b.With(reqbuilder.WithClient(shortTimeoutClient)).RequestWithoutBody(...)
+//
+// The client's timeout, jar and redirect policy apply, and its Transport, or
+// http.DefaultTransport, ends the Builder's round-tripper chain, so options such as
+// WithBodyChecksum, WithBandwidthLimit and WithMaxRedirects still take effect.
+// Options that configure the Builder's own transport, such as WithTLSConfig, do not.
+func WithClient(client *http.Client) Option {
+	return func(b *Builder) {
+		b.customClient = client
+	}
+}
+
+// WithAutoContentType sets Content-Type from http.DetectContentType when a request
+// with a body is sent without one.
+func WithAutoContentType() Option {
+	return func(b *Builder) {
+		b.autoContentType = true
+	}
+}
+
+// WithoutRedirects returns 3xx responses to the caller instead of following them.
+func WithoutRedirects() Option {
+	return func(b *Builder) {
+		b.client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+}
+
+// WithHeaders adds headers to every request using Header.Add semantics, so repeated
+// keys such as X-Forwarded-For are sent once per value, in order.
+func WithHeaders(header http.Header) Option {
+	return func(b *Builder) {
+		b.headers = append(b.headers[:len(b.headers):len(b.headers)], func(h http.Header) {
+			for k, vs := range header {
+				for _, v := range vs {
+					h.Add(k, v)
+				}
+			}
+		})
+	}
+}
+
+// WithRawHeader adds a header by assigning to the header map directly, preserving the
+// exact, possibly non-canonical, casing of key on the wire. It is deliberately unsafe:
+// the key is not validated and Header.Get will not find it unless it is canonical.
+func WithRawHeader(key, value string) Option {
+	return func(b *Builder) {
+		b.headers = append(b.headers[:len(b.headers):len(b.headers)], func(h http.Header) {
+			h[key] = append(h[key], value)
+		})
+	}
+}
+
+// WithCookieJar stores cookies set by responses in a jar and sends them with later
+// requests to matching URLs, like a browser session.
+func WithCookieJar() Option {
+	return func(b *Builder) {
+		jar, _ := cookiejar.New(nil)
+		b.client.Jar = jar
+	}
+}
+
+// WithUnixSocket sends all requests over the Unix domain socket at path. Request URLs
+// still need a host, which is only used for the Host header, e.g. "http://unix".
+func WithUnixSocket(path string) Option {
+	return func(b *Builder) {
+		dialer := &net.Dialer{}
+		b.ownTransport().DialContext = b.countDials(func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		})
+	}
+}
+
+// WithLocalAddr sends requests from the local address addr, an IP address or
+// IP:port, for hosts with several network interfaces.
+func WithLocalAddr(addr string) Option {
+	return func(b *Builder) {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "0")
+		}
+
+		local, err := net.ResolveTCPAddr("tcp", addr)
+		b.require.NoErrorf(err, "invalid local address %q", addr)
+
+		dialer := &net.Dialer{LocalAddr: local, Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		b.ownTransport().DialContext = b.countDials(dialer.DialContext)
+	}
+}
+
+// WithHostOverride connects to addr whenever a request goes to host, like an
+// /etc/hosts entry: the URL, Host header and TLS server name keep host. addr is an IP
+// or host, optionally with a port; without one the request's port is kept. Apply it
+// after WithLocalAddr or WithUnixSocket.
+func WithHostOverride(host, addr string) Option {
+	return func(b *Builder) {
+		transport := b.ownTransport()
+		dial := transport.DialContext
+		transport.DialContext = func(ctx context.Context, network, target string) (net.Conn, error) {
+			targetHost, port, err := net.SplitHostPort(target)
+			if err == nil && strings.EqualFold(targetHost, host) {
+				target = addr
+				if _, _, err := net.SplitHostPort(addr); err != nil {
+					target = net.JoinHostPort(addr, port)
+				}
+			}
+
+			return dial(ctx, network, target)
+		}
+	}
+}
+
+// WithDisableCompression stops the transport from asking for gzip and transparently
+// decompressing responses, and removes any Accept-Encoding header set so far. The
+// transport only decompresses when it added Accept-Encoding itself, so without this
+// option a gzip body may arrive already decoded or still encoded depending on the
+// request headers; with it, every encoded body reaches ReadResponseBody as sent.
+// Headers added by options applied after this one, e.g. WithHeaders, are kept, so
+// a specific encoding can still be requested.
+func WithDisableCompression() Option {
+	return func(b *Builder) {
+		b.ownTransport().DisableCompression = true
+		b.headers = append(b.headers[:len(b.headers):len(b.headers)], func(h http.Header) {
+			h.Del("Accept-Encoding")
+		})
+	}
+}
+
+// WithCookieEncoder passes the value of every cookie sent by the Builder through
+// encode, for example to sign or encrypt it the way the server does. Cookies set by
+// responses and sent back are encoded again, so encode should return values it has
+// already encoded unchanged.
+func WithCookieEncoder(encode func(name, value string) string) Option {
+	return func(b *Builder) {
+		b.cookieEncoder = encode
+	}
+}
+
+// addCookie adds cookie to req, encoded with the Builder's cookie encoder.
+func (b *Builder) addCookie(req *http.Request, cookie *http.Cookie) {
+	if b.cookieEncoder != nil {
+		encoded := *cookie
+		encoded.Value = b.cookieEncoder(cookie.Name, cookie.Value)
+		cookie = &encoded
+	}
+
+	req.AddCookie(cookie)
+}
@@ -0,0 +1,288 @@
+package reqbuilder
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMethodOverride(t *testing.T) {
+	var method, override string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		method, override = r.Method, r.Header.Get("X-HTTP-Method-Override")
+	})
+
+	b := New(require.New(t), WithMethodOverride())
+
+	for _, m := range []string{http.MethodPut, http.MethodDelete, http.MethodPatch} {
+		b.Request(t, context.Background(), m, server.URL, "/items/1", nil, nil, nil, "")
+		require.Equal(t, http.MethodPost, method)
+		require.Equal(t, m, override)
+	}
+
+	b.Request(t, context.Background(), http.MethodGet, server.URL, "/items/1", nil, nil, nil, "")
+	require.Equal(t, http.MethodGet, method)
+	require.Empty(t, override)
+}
+
+func TestWithClientPerRequest(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Set("X-Checksum-Seen", r.Header.Get("Content-MD5"))
+	})
+
+	b := New(require.New(t), WithBodyChecksum("md5", "Content-MD5"))
+	ctx := context.Background()
+	short := &http.Client{Timeout: 20 * time.Millisecond}
+
+	msg := failure(t, nil, func(fb *Builder) {
+		fb.With(WithClient(short)).RequestWithoutBody(t, ctx, http.MethodGet, server.URL, "/slow", nil, nil, "")
+	})
+	require.Contains(t, msg, "Client.Timeout exceeded")
+
+	response, _ := b.RequestWithoutBody(t, ctx, http.MethodGet, server.URL, "/slow", nil, nil, "")
+	b.ExpectStatus(t, response, http.StatusOK)
+
+	response, _ = b.With(WithClient(short)).Request(t, ctx, http.MethodPost, server.URL, "/fast", []byte("x"), nil, nil, "")
+	require.NotEmpty(t, response.Header.Get("X-Checksum-Seen"), "the round-tripper chain applies to custom clients")
+	require.Nil(t, b.customClient, "With must leave the original Builder's client alone")
+	require.Zero(t, b.client.Timeout)
+}
+
+func TestWithAutoContentType(t *testing.T) {
+	var contentType string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+	})
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	b := New(require.New(t), WithAutoContentType())
+	ctx := context.Background()
+
+	spec := Post(server.URL)
+	spec.Body = png
+	b.Send(t, ctx, spec)
+	require.Equal(t, "image/png", contentType)
+
+	b.Request(t, ctx, http.MethodPost, server.URL, "/", png, nil, map[string]string{"Content-Type": "application/octet-stream"}, "")
+	require.Equal(t, "application/octet-stream", contentType, "an explicit Content-Type wins")
+}
+
+func TestWithHeadersRepeatedValues(t *testing.T) {
+	var got [][]string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/final", http.StatusTemporaryRedirect)
+			return
+		}
+		got = append(got, r.Header.Values("X-Forwarded-For"))
+	})
+
+	b := New(require.New(t), WithHeaders(http.Header{"X-Forwarded-For": {"10.0.0.1", "10.0.0.2"}}))
+
+	b.RequestWithoutBody(t, context.Background(), http.MethodGet, server.URL, "/start", nil, nil, "")
+	require.Equal(t, [][]string{{"10.0.0.1", "10.0.0.2"}}, got, "the redirected request keeps both values in order")
+
+	spec := Get(server.URL + "/final")
+	spec.Headers.Add("X-Forwarded-For", "10.0.0.3")
+	spec.Headers.Add("X-Forwarded-For", "10.0.0.4")
+	New(require.New(t)).Send(t, context.Background(), spec)
+	require.Equal(t, []string{"10.0.0.3", "10.0.0.4"}, got[1])
+}
+
+func TestWithRawHeaderCasing(t *testing.T) {
+	b := New(require.New(t), WithRawHeader("x-weird-Key", "1"), WithCaptureRawRequest())
+
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	b.RequestWithoutBody(t, context.Background(), http.MethodGet, server.URL, "/", nil, nil, "")
+
+	require.Contains(t, string(b.LastRawRequest()), "\r\nx-weird-Key: 1\r\n")
+}
+
+func TestWithRawHeaderLegacyServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	// A hand-rolled server sees the header lines exactly as sent; net/http would
+	// canonicalize them before the handler could look.
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var lines []string
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+		received <- lines
+		io.WriteString(conn, "HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n")
+	}()
+
+	b := New(require.New(t), WithRawHeader("X-API-KEY", "k"))
+	response, _ := b.RequestWithoutBody(t, context.Background(), http.MethodGet, "http://"+listener.Addr().String(), "/", nil, nil, "")
+
+	require.Equal(t, http.StatusNoContent, response.StatusCode)
+	lines := <-received
+	require.Contains(t, lines, "X-API-KEY: k")
+	require.NotContains(t, lines, "X-Api-Key: k")
+}
+
+func TestWithUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + " " + r.URL.Path))
+	}))
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	b := New(require.New(t), WithUnixSocket(socket))
+	response, _ := b.RequestWithoutBody(t, context.Background(), http.MethodGet, "http://unix", "/status", nil, nil, "")
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "unix /status", string(b.requireBody(response)))
+}
+
+func TestWithDisableCompression(t *testing.T) {
+	encoded, err := EncodeGzip([]byte("hello"))
+	require.NoError(t, err)
+
+	var acceptEncoding []string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Values("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(encoded)
+	})
+	ctx := context.Background()
+
+	transparent := New(require.New(t))
+	response, _ := transparent.RequestWithoutBody(t, ctx, http.MethodGet, server.URL, "/", nil, nil, "")
+	require.Equal(t, []string{"gzip"}, acceptEncoding)
+	require.True(t, response.Uncompressed, "the transport decodes when it asked for gzip itself")
+
+	b := New(require.New(t), WithHeaders(http.Header{"Accept-Encoding": {"gzip, br"}}), WithDisableCompression())
+	response, _ = b.RequestWithoutBody(t, ctx, http.MethodGet, server.URL, "/", nil, nil, "")
+	require.Empty(t, acceptEncoding)
+	require.False(t, response.Uncompressed)
+	require.Equal(t, "gzip", response.Header.Get("Content-Encoding"))
+
+	raw, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.Equal(t, encoded, raw, "the body must arrive encoded")
+
+	response, _ = b.RequestWithoutBody(t, ctx, http.MethodGet, server.URL, "/", nil, nil, "")
+	body, err := b.ReadResponseBody(response)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(body))
+
+	b = New(require.New(t), WithDisableCompression(), WithHeaders(http.Header{"Accept-Encoding": {"gzip"}}))
+	b.RequestWithoutBody(t, ctx, http.MethodGet, server.URL, "/", nil, nil, "")
+	require.Equal(t, []string{"gzip"}, acceptEncoding, "headers added afterwards are kept")
+}
+
+func TestWithLocalAddr(t *testing.T) {
+	// Linux routes all of 127.0.0.0/8 to loopback; elsewhere only 127.0.0.1 may be bindable.
+	source := "127.0.0.2"
+	if l, err := net.Listen("tcp", source+":0"); err != nil {
+		source = "127.0.0.1"
+	} else {
+		l.Close()
+	}
+
+	var remote string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		remote = r.RemoteAddr
+	})
+
+	b := New(require.New(t), WithLocalAddr(source))
+	b.RequestWithoutBody(t, context.Background(), http.MethodGet, server.URL, "/", nil, nil, "")
+
+	host, _, err := net.SplitHostPort(remote)
+	require.NoError(t, err)
+	require.Equal(t, source, host)
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.With(WithLocalAddr("127.0.0.1:http-alt-x"))
+	})
+	require.Contains(t, msg, `invalid local address "127.0.0.1:http-alt-x"`)
+}
+
+func TestWithCookieEncoder(t *testing.T) {
+	key := []byte("cookie-secret")
+	sign := func(value string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(value))
+		return value + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			http.Error(w, "no session", http.StatusUnauthorized)
+			return
+		}
+		value, _, _ := strings.Cut(cookie.Value, ".")
+		if !hmac.Equal([]byte(sign(value)), []byte(cookie.Value)) {
+			http.Error(w, "bad signature", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(value))
+	})
+
+	b := New(require.New(t), WithCookieEncoder(func(name, value string) string {
+		if strings.Contains(value, ".") {
+			return value
+		}
+		return sign(value)
+	}))
+	spec := Get(server.URL)
+	spec.Cookies = []*http.Cookie{{Name: "session", Value: "user-42"}}
+	response, _ := b.Send(t, context.Background(), spec)
+
+	require.Equal(t, http.StatusOK, response.StatusCode, string(b.requireBody(response)))
+	require.Equal(t, "user-42", string(b.requireBody(response)))
+
+	response, _ = New(require.New(t)).Send(t, context.Background(), spec)
+	require.Equal(t, http.StatusForbidden, response.StatusCode, "unsigned cookies are rejected")
+}
+
+func TestWithHostOverride(t *testing.T) {
+	var host string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+	})
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+
+	for _, addr := range []string{"127.0.0.1", "127.0.0.1:" + port} {
+		b := New(require.New(t), WithHostOverride("api.example.test", addr))
+		response, _ := b.Send(t, context.Background(), Get("http://api.example.test:"+port+"/ping"))
+
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, "api.example.test:"+port, host, "the server must see the overridden hostname")
+	}
+}
@@ -0,0 +1,166 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// PatchOp is a JSON Patch (RFC 6902) operation.
+type PatchOp struct {
+	Op    string
+	Path  string
+	Value any
+	From  string
+}
+
+// MarshalJSON writes value only for operations that take one, including a nil value
+// as null, and from only for move and copy.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	doc := map[string]any{"op": op.Op, "path": op.Path}
+	switch op.Op {
+	case "add", "replace", "test":
+		doc["value"] = op.Value
+	case "move", "copy":
+		doc["from"] = op.From
+	}
+
+	return json.Marshal(doc)
+}
+
+// validate checks the operation name and JSON Pointer syntax.
+func (op PatchOp) validate() error {
+	switch op.Op {
+	case "add", "remove", "replace", "test":
+	case "move", "copy":
+		if err := validatePointer(op.From); err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+
+	if err := validatePointer(op.Path); err != nil {
+		return fmt.Errorf("path: %w", err)
+	}
+
+	return nil
+}
+
+// validatePointer checks a JSON Pointer (RFC 6901): empty, or "/"-separated tokens in
+// which "~" only appears as "~0" or "~1".
+func validatePointer(pointer string) error {
+	if pointer == "" {
+		return nil
+	}
+	if pointer[0] != '/' {
+		return fmt.Errorf("%q must start with /", pointer)
+	}
+
+	for i := 0; i < len(pointer); i++ {
+		if pointer[i] == '~' && (i+1 == len(pointer) || (pointer[i+1] != '0' && pointer[i+1] != '1')) {
+			return fmt.Errorf("%q: ~ must be escaped as ~0", pointer)
+		}
+	}
+
+	return nil
+}
+
+// PatchJSON sends ops as an application/json-patch+json PATCH to url. The test fails
+// before sending if an operation has an unknown name or a malformed path.
+func (b *Builder) PatchJSON(t *testing.T, ctx context.Context, url string, ops []PatchOp, opts ...Option) (*http.Response, []*http.Cookie) {
+	t.Helper()
+
+	var errs []error
+	for i, op := range ops {
+		if err := op.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("op %d: %w", i, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		b.logError(t, err)
+		b.require.NoError(err, "invalid JSON Patch")
+	}
+
+	if ops == nil {
+		ops = []PatchOp{}
+	}
+	spec := Patch(url).JSONBody(ops).SetHeader("Content-Type", "application/json-patch+json")
+
+	return b.With(opts...).Send(t, ctx, spec)
+}
+
+// MergePatch sends partial as an application/merge-patch+json (RFC 7386) PATCH to url.
+func (b *Builder) MergePatch(t *testing.T, ctx context.Context, url string, partial any, opts ...Option) (*http.Response, []*http.Cookie) {
+	t.Helper()
+
+	spec := Patch(url).JSONBody(partial).SetHeader("Content-Type", "application/merge-patch+json")
+
+	return b.With(opts...).Send(t, ctx, spec)
+}
+
+// DiffToMergePatch returns the merge patch that turns before into after, both values
+// that marshal to JSON objects: changed members with their new value, removed members
+// as null, nested objects diffed recursively. Members that become null in after
+// cannot be told apart from removed ones in a merge patch and are removed.
+func DiffToMergePatch(before, after any) (map[string]any, error) {
+	from, err := normalizeJSON(before)
+	if err != nil {
+		return nil, fmt.Errorf("before: %w", err)
+	}
+	to, err := normalizeJSON(after)
+	if err != nil {
+		return nil, fmt.Errorf("after: %w", err)
+	}
+
+	fromObject, ok := from.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("before is not a JSON object: %s", strings.TrimSpace(captureString(from)))
+	}
+	toObject, ok := to.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("after is not a JSON object: %s", strings.TrimSpace(captureString(to)))
+	}
+
+	return mergeDiff(fromObject, toObject), nil
+}
+
+func mergeDiff(from, to map[string]any) map[string]any {
+	patch := map[string]any{}
+
+	for key, old := range from {
+		value, ok := to[key]
+		if !ok || value == nil {
+			if old != nil {
+				patch[key] = nil
+			}
+			continue
+		}
+
+		oldObject, oldIsObject := old.(map[string]any)
+		newObject, newIsObject := value.(map[string]any)
+		if oldIsObject && newIsObject {
+			if nested := mergeDiff(oldObject, newObject); len(nested) != 0 {
+				patch[key] = nested
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(old, value) {
+			patch[key] = value
+		}
+	}
+
+	for key, value := range to {
+		if _, ok := from[key]; !ok && value != nil {
+			patch[key] = value
+		}
+	}
+
+	return patch
+}
@@ -0,0 +1,122 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// patchServer holds a flat JSON document and applies JSON Patch and merge patch
+// requests to it, answering with the result.
+func patchServer(t *testing.T, doc map[string]any) string {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Content-Type") {
+		case "application/json-patch+json":
+			var ops []map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ops))
+			for _, op := range ops {
+				key := strings.TrimPrefix(op["path"].(string), "/")
+				switch op["op"] {
+				case "add", "replace":
+					doc[key] = op["value"]
+				case "remove":
+					delete(doc, key)
+				case "move":
+					from := strings.TrimPrefix(op["from"].(string), "/")
+					doc[key] = doc[from]
+					delete(doc, from)
+				}
+			}
+		case "application/merge-patch+json":
+			var patch map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&patch))
+			for key, value := range patch {
+				if value == nil {
+					delete(doc, key)
+				} else {
+					doc[key] = value
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(doc)
+	})
+
+	return server.URL
+}
+
+func TestPatchJSON(t *testing.T) {
+	url := patchServer(t, map[string]any{"name": "ann", "nick": "a", "role": "user"})
+
+	b := New(require.New(t))
+	response, _ := b.PatchJSON(t, context.Background(), url, []PatchOp{
+		{Op: "replace", Path: "/name", Value: "bob"},
+		{Op: "remove", Path: "/role"},
+		{Op: "move", From: "/nick", Path: "/alias"},
+		{Op: "add", Path: "/deleted", Value: nil},
+	})
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.JSONEq(t, `{"name":"bob","alias":"a","deleted":null}`, string(b.requireBody(response)))
+}
+
+func TestPatchJSONInvalid(t *testing.T) {
+	calls, url := flakyServer(t, 0, http.StatusOK)
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.PatchJSON(t, context.Background(), url, []PatchOp{
+			{Op: "rename", Path: "/name"},
+			{Op: "add", Path: "name", Value: 1},
+			{Op: "copy", From: "/a~2", Path: "/b"},
+		})
+	})
+
+	require.Contains(t, msg, `op 0: unknown op "rename"`)
+	require.Contains(t, msg, `op 1: path: "name" must start with /`)
+	require.Contains(t, msg, `op 2: from: "/a~2": ~ must be escaped as ~0`)
+	require.Zero(t, calls.Load(), "an invalid patch must not be sent")
+}
+
+func TestMergePatch(t *testing.T) {
+	type user struct {
+		Name  string  `json:"name"`
+		Email *string `json:"email,omitempty"`
+		Role  string  `json:"role"`
+	}
+	email := "ann@example.com"
+	before := user{Name: "ann", Email: &email, Role: "user"}
+	after := user{Name: "ann", Role: "admin"}
+
+	patch, err := DiffToMergePatch(before, after)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"email": nil, "role": "admin"}, patch)
+
+	url := patchServer(t, map[string]any{"name": "ann", "email": email, "role": "user"})
+
+	b := New(require.New(t))
+	response, _ := b.MergePatch(t, context.Background(), url, patch)
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.JSONEq(t, `{"name":"ann","role":"admin"}`, string(b.requireBody(response)))
+}
+
+func TestDiffToMergePatchNested(t *testing.T) {
+	patch, err := DiffToMergePatch(
+		map[string]any{"address": map[string]any{"city": "Oslo", "zip": "0150"}, "tags": []any{"a"}},
+		map[string]any{"address": map[string]any{"city": "Bergen", "zip": "0150"}, "tags": []any{"a", "b"}},
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"address": map[string]any{"city": "Bergen"}, "tags": []any{"a", "b"}}, patch)
+
+	_, err = DiffToMergePatch([]int{1}, map[string]any{})
+	require.ErrorContains(t, err, "before is not a JSON object")
+}
@@ -0,0 +1,47 @@
+package reqbuilder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// RequestUntil sends the request described by spec every interval until cond holds for
+// the response, and returns that response. The test fails with the last response
+// described when cond still does not hold after timeout. Both durations must be positive.
+func (b *Builder) RequestUntil(
+	t *testing.T,
+	ctx context.Context,
+	spec *RequestSpec,
+	cond func(*http.Response) bool,
+	interval,
+	timeout time.Duration) *http.Response {
+	t.Helper()
+
+	b.require.Positivef(interval, "poll interval must be positive, got %s", interval)
+	b.require.Positivef(timeout, "poll timeout must be positive, got %s", timeout)
+
+	deadline := b.clock.Now().Add(timeout)
+
+	for attempt := 1; ; attempt++ {
+		response, _ := b.Send(t, ctx, spec)
+		if cond(response) {
+			return response
+		}
+
+		if !b.clock.Now().Add(interval).Before(deadline) {
+			b.require.Failf("condition not met", "gave up after %d attempts in %s\nlast response: %s",
+				attempt, timeout, b.describe(response))
+		}
+
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+
+		if err := b.clock.Sleep(ctx, interval); err != nil {
+			b.logError(t, err)
+			b.require.NoError(err)
+		}
+	}
+}
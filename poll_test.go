@@ -0,0 +1,59 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestUntil(t *testing.T) {
+	calls, url := flakyServer(t, 2, http.StatusNotFound)
+	clock := NewFakeClock(time.Unix(0, 0))
+	sleeps := advanceSleepers(t, clock)
+
+	b := New(require.New(t), WithClock(clock))
+	response := b.RequestUntil(t, context.Background(), Get(url), func(r *http.Response) bool {
+		return r.StatusCode == http.StatusOK
+	}, time.Second, time.Minute)
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.EqualValues(t, 3, calls.Load())
+	require.Equal(t, []time.Duration{time.Second, time.Second}, sleeps())
+}
+
+func TestRequestUntilTimeout(t *testing.T) {
+	calls, url := flakyServer(t, 100, http.StatusNotFound)
+	clock := NewFakeClock(time.Unix(0, 0))
+	advanceSleepers(t, clock)
+
+	msg := failure(t, []Option{WithClock(clock)}, func(b *Builder) {
+		b.RequestUntil(t, context.Background(), Get(url), func(r *http.Response) bool {
+			return r.StatusCode == http.StatusOK
+		}, time.Second, 5*time.Second)
+	})
+
+	require.Contains(t, msg, "gave up after 5 attempts in 5s")
+	require.Contains(t, msg, "404 Not Found")
+	require.EqualValues(t, 5, calls.Load())
+}
+
+func TestRequestUntilInvalidDurations(t *testing.T) {
+	for _, tt := range []struct {
+		interval, timeout time.Duration
+		want              string
+	}{
+		{0, time.Minute, "poll interval must be positive"},
+		{-time.Second, time.Minute, "poll interval must be positive"},
+		{time.Second, 0, "poll timeout must be positive"},
+	} {
+		msg := failure(t, nil, func(b *Builder) {
+			b.RequestUntil(t, context.Background(), Get("http://127.0.0.1:1"), func(*http.Response) bool {
+				return true
+			}, tt.interval, tt.timeout)
+		})
+		require.Contains(t, msg, tt.want)
+	}
+}
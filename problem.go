@@ -0,0 +1,110 @@
+package reqbuilder
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// ProblemDetails is an RFC 7807 problem details document. Members other than the
+// standard ones are kept in Extensions.
+type ProblemDetails struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// ParseProblem decodes the (decompressed) body of an application/problem+json response
+// and fails the test unless the Content-Type is problem+json and the document's status,
+// when present, matches the response status code.
+func (b *Builder) ParseProblem(t *testing.T, response *http.Response) ProblemDetails {
+	t.Helper()
+
+	b.require.NotNil(response, "no response")
+
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType != "application/problem+json" {
+		b.require.Failf("not a problem details response", "expected Content-Type application/problem+json\n%s", b.describe(response))
+	}
+
+	body := b.requireBody(response)
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		b.require.Failf("malformed problem details", "%v\n%s", err, b.describe(response))
+	}
+
+	var problem ProblemDetails
+	standard := map[string]any{
+		"type":     &problem.Type,
+		"title":    &problem.Title,
+		"status":   &problem.Status,
+		"detail":   &problem.Detail,
+		"instance": &problem.Instance,
+	}
+
+	for name, raw := range members {
+		target, ok := standard[name]
+		if !ok {
+			var value any
+			if err := json.Unmarshal(raw, &value); err == nil {
+				if problem.Extensions == nil {
+					problem.Extensions = map[string]any{}
+				}
+				problem.Extensions[name] = value
+			}
+			continue
+		}
+
+		if err := json.Unmarshal(raw, target); err != nil {
+			b.require.Failf("malformed problem details", "member %q: %v\n%s", name, err, b.describe(response))
+		}
+	}
+
+	if problem.Status != 0 && problem.Status != response.StatusCode {
+		b.require.Failf("problem status mismatch", "problem details status %d differs from response status %d\n%s",
+			problem.Status, response.StatusCode, b.describe(response))
+	}
+
+	return problem
+}
+
+// ExpectProblem fails the test unless the response is a problem details document
+// matching expected. Only the fields set in expected are compared; for Extensions,
+// only the listed members.
+func (b *Builder) ExpectProblem(t *testing.T, response *http.Response, expected ProblemDetails) {
+	t.Helper()
+
+	problem := b.ParseProblem(t, response)
+
+	var mismatches []string
+	compare := func(name string, want, got any, set bool) {
+		if set && fmt.Sprint(want) != fmt.Sprint(got) {
+			mismatches = append(mismatches, fmt.Sprintf("%s: expected %v, got %v", name, want, got))
+		}
+	}
+
+	compare("type", expected.Type, problem.Type, expected.Type != "")
+	compare("title", expected.Title, problem.Title, expected.Title != "")
+	compare("status", expected.Status, problem.Status, expected.Status != 0)
+	compare("detail", expected.Detail, problem.Detail, expected.Detail != "")
+	compare("instance", expected.Instance, problem.Instance, expected.Instance != "")
+	for name, want := range expected.Extensions {
+		got, ok := problem.Extensions[name]
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s: expected %v, got nothing", name, want))
+			continue
+		}
+		compare(name, want, got, true)
+	}
+
+	if len(mismatches) != 0 {
+		b.require.Failf("unexpected problem details", "%s\n%s", strings.Join(mismatches, "\n"), b.describe(response))
+	}
+}
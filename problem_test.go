@@ -0,0 +1,67 @@
+package reqbuilder
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func problemServer(t *testing.T, contentType string, status int, body string) string {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(status)
+
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(body))
+		gz.Close()
+	})
+
+	return server.URL
+}
+
+func TestParseProblem(t *testing.T) {
+	url := problemServer(t, "application/problem+json; charset=utf-8", http.StatusUnprocessableEntity,
+		`{"type":"https://example.com/probs/out-of-credit","title":"Out of credit","status":422,"balance":30,"accounts":["a","b"]}`)
+	b := New(require.New(t))
+
+	response, _ := b.Send(t, context.Background(), Get(url))
+	problem := b.ParseProblem(t, response)
+
+	require.Equal(t, "Out of credit", problem.Title)
+	require.Equal(t, http.StatusUnprocessableEntity, problem.Status)
+	require.Equal(t, map[string]any{"balance": float64(30), "accounts": []any{"a", "b"}}, problem.Extensions)
+
+	b.ExpectProblem(t, response, ProblemDetails{Status: 422, Extensions: map[string]any{"balance": 30}})
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.ExpectProblem(t, response, ProblemDetails{Type: "about:blank", Extensions: map[string]any{"currency": "EUR"}})
+	})
+	require.Contains(t, msg, "type: expected about:blank, got https://example.com/probs/out-of-credit")
+	require.Contains(t, msg, "currency: expected EUR, got nothing")
+}
+
+func TestParseProblemRejects(t *testing.T) {
+	for _, tt := range []struct {
+		name, contentType string
+		status            int
+		body, want        string
+	}{
+		{"content type", "application/json", http.StatusBadRequest, `{"status":400}`, "expected Content-Type application/problem+json"},
+		{"status", "application/problem+json", http.StatusBadRequest, `{"status":404}`, "problem details status 404 differs from response status 400"},
+		{"malformed", "application/problem+json", http.StatusBadRequest, `{"status":"400"}`, `member "status"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			url := problemServer(t, tt.contentType, tt.status, tt.body)
+
+			msg := failure(t, nil, func(b *Builder) {
+				response, _ := b.Send(t, context.Background(), Get(url))
+				b.ParseProblem(t, response)
+			})
+			require.Contains(t, msg, tt.want)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package reqbuilder
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// WithQuery adds query parameters to the request URL, keeping those already in the endpoint.
+func WithQuery(values url.Values) Option {
+	return func(b *Builder) {
+		b.queries = append(b.queries[:len(b.queries):len(b.queries)], func() (url.Values, error) {
+			return values, nil
+		})
+	}
+}
+
+// WithQueryStruct adds query parameters encoded from a struct, see EncodeQuery.
+func WithQueryStruct(v any) Option {
+	return func(b *Builder) {
+		b.queries = append(b.queries[:len(b.queries):len(b.queries)], func() (url.Values, error) {
+			return EncodeQuery(v)
+		})
+	}
+}
+
+// applyQuery adds the configured query parameters to the request URL.
+func (b *Builder) applyQuery(t *testing.T, req *http.Request) {
+	t.Helper()
+
+	if len(b.queries) == 0 {
+		return
+	}
+
+	query := req.URL.Query()
+
+	for _, build := range b.queries {
+		values, err := build()
+		if err != nil {
+			b.logError(t, err)
+		}
+		b.require.NoError(err)
+
+		for k, vs := range values {
+			for _, v := range vs {
+				query.Add(k, v)
+			}
+		}
+	}
+
+	req.URL.RawQuery = query.Encode()
+}
+
+// EncodeQuery encodes a struct into query parameters. Fields are named by their
+// `url:"name"` tag (or the field name), `url:"-"` skips a field, `omitempty` drops zero
+// values and slices become repeated parameters. Embedded structs are flattened.
+func EncodeQuery(v any) (url.Values, error) {
+	values := url.Values{}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("query: expected a struct, got %s", rv.Kind())
+	}
+
+	return values, encodeQueryStruct(values, rv)
+}
+
+func encodeQueryStruct(values url.Values, rv reflect.Value) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("url"), ",")
+		if name == "-" {
+			continue
+		}
+
+		fv := rv.Field(i)
+
+		if field.Anonymous && name == "" && indirect(fv).Kind() == reflect.Struct {
+			if fv = indirect(fv); fv.IsValid() {
+				if err := encodeQueryStruct(values, fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if strings.Contains(","+opts+",", ",omitempty,") && fv.IsZero() {
+			continue
+		}
+
+		fv = indirect(fv)
+		if !fv.IsValid() {
+			values.Add(name, "")
+			continue
+		}
+
+		if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && fv.Type().Elem().Kind() != reflect.Uint8 {
+			for j := 0; j < fv.Len(); j++ {
+				s, err := queryValue(indirect(fv.Index(j)))
+				if err != nil {
+					return fmt.Errorf("query: field %s: %w", field.Name, err)
+				}
+				values.Add(name, s)
+			}
+			continue
+		}
+
+		s, err := queryValue(fv)
+		if err != nil {
+			return fmt.Errorf("query: field %s: %w", field.Name, err)
+		}
+		values.Add(name, s)
+	}
+
+	return nil
+}
+
+// indirect dereferences pointers, returning the zero Value for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// queryValue formats a single value for a query string.
+func queryValue(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "", nil
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Time:
+		return value.Format(time.RFC3339), nil
+	case fmt.Stringer:
+		return value.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
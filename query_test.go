@@ -0,0 +1,35 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithQueryStruct(t *testing.T) {
+	type filter struct {
+		Tags    []string `url:"tag"`
+		Owner   string   `url:"owner,omitempty"`
+		Limit   int      `url:"limit"`
+		Ignored string   `url:"-"`
+	}
+
+	var rawQuery string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		rawQuery = r.URL.RawQuery
+	})
+
+	b := New(require.New(t))
+	q := filter{Tags: []string{"go", "http"}, Limit: 10, Ignored: "x"}
+	b.RequestWithoutBody(t, context.Background(), http.MethodGet, server.URL, "/search?page=2", nil, nil, "",
+		WithQueryStruct(q))
+
+	require.Equal(t, "limit=10&page=2&tag=go&tag=http", rawQuery)
+}
+
+func TestEncodeQueryRejectsNonStruct(t *testing.T) {
+	_, err := EncodeQuery(42)
+	require.EqualError(t, err, "query: expected a struct, got int")
+}
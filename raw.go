@@ -0,0 +1,158 @@
+package reqbuilder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RawResponse is a minimally parsed HTTP response received over a raw connection.
+type RawResponse struct {
+	Proto      string
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+// maxRawResponse caps how much RawRequest reads, so a server that never stops
+// sending cannot exhaust memory.
+const maxRawResponse = 16 << 20
+
+// RawRequest writes raw bytes to addr without going through http.Client and returns
+// everything the server sends back until EOF or until readTimeout, which must be
+// positive, passes without data. Responses larger than 16 MiB are cut off with an error.
+// The addr may be host:port or an http:// or https:// URL, with the scheme's default
+// port if it has none; https uses the Builder's TLS config.
+func (b *Builder) RawRequest(
+	t *testing.T,
+	ctx context.Context,
+	addr string,
+	raw []byte,
+	readTimeout time.Duration) ([]byte, error) {
+	t.Helper()
+
+	b.require.Positivef(readTimeout, "raw request read timeout must be positive, got %s", readTimeout)
+
+	useTLS := false
+	if strings.Contains(addr, "://") {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return nil, err
+		}
+		useTLS = u.Scheme == "https"
+		if addr, err = dialAddress(addr); err != nil {
+			return nil, err
+		}
+	}
+
+	var conn net.Conn
+	var err error
+
+	dialer := &net.Dialer{}
+	if useTLS {
+		config := &tls.Config{}
+		if b.transport.TLSClientConfig != nil {
+			config = b.transport.TLSClientConfig.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: config}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if _, err = conn.Write(raw); err != nil {
+		return nil, err
+	}
+
+	var response bytes.Buffer
+	buf := make([]byte, 4096)
+
+	for {
+		if err = conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+			return response.Bytes(), err
+		}
+
+		n, readErr := conn.Read(buf)
+		response.Write(buf[:n])
+		if response.Len() > maxRawResponse {
+			return response.Bytes()[:maxRawResponse], fmt.Errorf("raw response exceeds %d bytes", maxRawResponse)
+		}
+
+		if readErr != nil {
+			if ctx.Err() != nil {
+				return response.Bytes(), ctx.Err()
+			}
+
+			var netErr net.Error
+			if errors.Is(readErr, io.EOF) || (errors.As(readErr, &netErr) && netErr.Timeout()) {
+				return response.Bytes(), nil
+			}
+
+			return response.Bytes(), readErr
+		}
+	}
+}
+
+// ParseRawResponse parses the status line, headers and body of a raw HTTP response.
+// The body is returned as received, without chunked decoding.
+func ParseRawResponse(raw []byte) (*RawResponse, error) {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(raw)))
+
+	line, err := reader.ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("reading status line: %w", err)
+	}
+
+	proto, status, ok := strings.Cut(line, " ")
+	if !ok || !strings.HasPrefix(proto, "HTTP/") {
+		return nil, fmt.Errorf("malformed status line %q", line)
+	}
+
+	codeText, _, _ := strings.Cut(status, " ")
+	code, err := strconv.Atoi(codeText)
+	if err != nil || len(codeText) != 3 {
+		return nil, fmt.Errorf("malformed status code in %q", line)
+	}
+
+	header, err := reader.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("reading headers: %w", err)
+	}
+
+	body, err := io.ReadAll(reader.R)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RawResponse{
+		Proto:      proto,
+		StatusCode: code,
+		Status:     status,
+		Header:     http.Header(header),
+		Body:       body,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package reqbuilder
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawRequestDuplicateContentLength(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	b := New(require.New(t))
+	raw := "POST / HTTP/1.1\r\nHost: example\r\nContent-Length: 3\r\nContent-Length: 5\r\n\r\nabcde"
+
+	reply, err := b.RawRequest(t, context.Background(), server.URL, []byte(raw), time.Second)
+	require.NoError(t, err)
+
+	response, err := ParseRawResponse(reply)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, response.StatusCode)
+	require.Equal(t, "HTTP/1.1", response.Proto)
+}
+
+func TestRawRequestReadTimeout(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.RawRequest(t, context.Background(), server.URL, []byte("GET / HTTP/1.1\r\n\r\n"), 0)
+	})
+	require.Contains(t, msg, "read timeout must be positive")
+}
+
+func TestRawRequestSizeCap(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		chunk := make([]byte, 64<<10)
+		for {
+			if _, err := conn.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+
+	reply, err := New(require.New(t)).RawRequest(t, context.Background(), listener.Addr().String(), []byte("GET / HTTP/1.1\r\n\r\n"), time.Second)
+	require.ErrorContains(t, err, "raw response exceeds")
+	require.Len(t, reply, maxRawResponse)
+}
+
+func TestParseRawResponse(t *testing.T) {
+	response, err := ParseRawResponse([]byte("HTTP/1.1 404 Not Found\r\nX-A: 1\r\nX-A: 2\r\n\r\nmissing"))
+	require.NoError(t, err)
+	require.Equal(t, 404, response.StatusCode)
+	require.Equal(t, []string{"1", "2"}, response.Header.Values("X-A"))
+	require.Equal(t, "missing", string(response.Body))
+
+	_, err = ParseRawResponse([]byte("garbage\r\n\r\n"))
+	require.True(t, err != nil && strings.Contains(err.Error(), "malformed status line"))
+}
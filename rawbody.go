@@ -0,0 +1,97 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// errTransportDecompressed is returned for raw access to a body the transport has
+// already decompressed, which also removes its Content-Encoding header.
+var errTransportDecompressed = errors.New(
+	"the transport decompressed this response transparently; send it with WithRawBodyAccess to read the wire bytes")
+
+// WithRawBodyAccess keeps response bodies as sent, so ReadRawBody and Response.RawBody
+// can return the compressed wire bytes: the transport no longer decompresses gzip
+// transparently, which would also remove Content-Encoding. Requests without an
+// Accept-Encoding header still ask for gzip, as the transport would.
+func WithRawBodyAccess() Option {
+	return func(b *Builder) {
+		b.ownTransport().DisableCompression = true
+		b.headers = append(b.headers[:len(b.headers):len(b.headers)], func(h http.Header) {
+			if h.Get("Accept-Encoding") == "" {
+				h.Set("Accept-Encoding", "gzip")
+			}
+		})
+	}
+}
+
+// ReadRawBody returns the response body as received, still compressed, without
+// consuming it: ReadResponseBody and assertions can read the decoded body afterwards.
+// Gzip bodies the transport decompressed are gone; send with WithRawBodyAccess.
+func (b *Builder) ReadRawBody(response *http.Response) ([]byte, error) {
+	return readRawBody(response)
+}
+
+func readRawBody(response *http.Response) ([]byte, error) {
+	if response.Uncompressed {
+		return nil, errTransportDecompressed
+	}
+
+	return bufferBody(response)
+}
+
+// RawBody returns the body as received, see ReadRawBody. It is buffered on first use,
+// so Decoded and CompressionRatio do not read the network again.
+func (r *Response) RawBody() ([]byte, error) {
+	return readRawBody(r.Response)
+}
+
+// Decoded returns the body with its Content-Encoding removed, derived from RawBody and
+// decoded the way the Builder that sent it decodes bodies, see WithStrictEncoding and
+// WithEncodingSniffing.
+func (r *Response) Decoded() ([]byte, error) {
+	raw, err := r.RawBody()
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.ReadCloser
+	if r.meta != nil {
+		reader, err = r.meta.b.responseDecoder(r.Response)
+	} else {
+		reader, err = decodingReader(r.Encoding(), bytes.NewReader(raw))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// Encoding returns the response's Content-Encoding, "" for an unencoded body.
+func (r *Response) Encoding() string {
+	return r.Header.Get("Content-Encoding")
+}
+
+// CompressionRatio returns the decoded size divided by the size on the wire, 1 for
+// unencoded or empty bodies.
+func (r *Response) CompressionRatio() (float64, error) {
+	raw, err := r.RawBody()
+	if err != nil {
+		return 0, err
+	}
+
+	decoded, err := r.Decoded()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(raw) == 0 {
+		return 1, nil
+	}
+
+	return float64(len(decoded)) / float64(len(raw)), nil
+}
@@ -0,0 +1,86 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// gzipServer answers with content, gzip-encoded when the request accepts gzip.
+func gzipServer(t *testing.T, content []byte) string {
+	encoded, err := EncodeGzip(content)
+	require.NoError(t, err)
+
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write(content)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(encoded)
+	})
+
+	return server.URL
+}
+
+func TestWithRawBodyAccess(t *testing.T) {
+	content := bytes.Repeat([]byte(`{"event":"page_view"}`), 100)
+	url := gzipServer(t, content)
+
+	b := New(require.New(t), WithRawBodyAccess())
+	response, _ := b.Send(t, context.Background(), Get(url))
+	r := Wrap(response)
+
+	raw, err := r.RawBody()
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x1f, 0x8b}, raw[:2], "gzip magic bytes")
+	require.Equal(t, "gzip", r.Encoding())
+
+	decoded, err := r.Decoded()
+	require.NoError(t, err)
+	require.Equal(t, content, decoded)
+
+	ratio, err := r.CompressionRatio()
+	require.NoError(t, err)
+	require.InDelta(t, float64(len(content))/float64(len(raw)), ratio, 1e-9)
+	require.Greater(t, ratio, 10.0)
+
+	body, err := b.ReadResponseBody(response)
+	require.NoError(t, err)
+	require.Equal(t, content, body, "raw access must leave the decoded body readable")
+
+	response, _ = b.Send(t, context.Background(), Get(url).SetHeader("Accept-Encoding", "identity"))
+	raw, err = b.ReadRawBody(response)
+	require.NoError(t, err)
+	require.Equal(t, content, raw, "an explicit Accept-Encoding is kept")
+}
+
+func TestReadRawBodyTransportDecompressed(t *testing.T) {
+	url := gzipServer(t, []byte("hello"))
+
+	b := New(require.New(t))
+	response, _ := b.Send(t, context.Background(), Get(url))
+
+	_, err := b.ReadRawBody(response)
+	require.ErrorIs(t, err, errTransportDecompressed)
+}
+
+func TestDecodedUsesBuilderDecoder(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "compress")
+		w.Write([]byte("LZW"))
+	})
+
+	b := New(require.New(t), WithRawBodyAccess(), WithStrictEncoding())
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	_, err := Wrap(response).Decoded()
+	var unsupported *UnsupportedEncodingError
+	require.True(t, errors.As(err, &unsupported), "got %v", err)
+	require.Equal(t, "compress", unsupported.Encoding)
+}
@@ -0,0 +1,170 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// ReadinessSpec describes when a service counts as ready, see WaitForReady.
+type ReadinessSpec struct {
+	// Endpoint is the path probed on the host, such as "/healthz".
+	Endpoint string
+	// ExpectStatus is the status of a ready service, 200 when zero.
+	ExpectStatus int
+	// BodyContains, if set, must occur in the response body.
+	BodyContains string
+	// Timeout bounds the wait, 30s when zero.
+	Timeout time.Duration
+	// Interval is the first pause between probes, 500ms when zero. It doubles after
+	// each failed probe up to MaxInterval, 5s when zero.
+	Interval    time.Duration
+	MaxInterval time.Duration
+	// TCP only dials the host's port, for services without an HTTP health endpoint.
+	TCP bool
+}
+
+// WaitForReady probes host until it is ready as described by spec. Connection errors
+// count as not ready yet; the test fails with the last error, status or body once the
+// timeout expires.
+func (b *Builder) WaitForReady(t *testing.T, ctx context.Context, host string, spec ReadinessSpec) {
+	t.Helper()
+
+	if err := waitReady(ctx, b.httpClient(), b.clock, host, spec); err != nil {
+		b.logError(t, err)
+		b.require.NoError(err)
+	}
+}
+
+// WaitUntilReady is WaitForReady returning an error instead of failing a test, for use
+// in TestMain.
+func WaitUntilReady(ctx context.Context, host string, spec ReadinessSpec) error {
+	return waitReady(ctx, &http.Client{}, realClock{}, host, spec)
+}
+
+func waitReady(ctx context.Context, client *http.Client, clock Clock, host string, spec ReadinessSpec) error {
+	if spec.Timeout < 0 || spec.Interval < 0 || spec.MaxInterval < 0 {
+		return fmt.Errorf("readiness durations must not be negative: timeout %s, interval %s, max interval %s",
+			spec.Timeout, spec.Interval, spec.MaxInterval)
+	}
+	if spec.ExpectStatus == 0 {
+		spec.ExpectStatus = http.StatusOK
+	}
+	if spec.Timeout == 0 {
+		spec.Timeout = 30 * time.Second
+	}
+	if spec.Interval == 0 {
+		spec.Interval = 500 * time.Millisecond
+	}
+	if spec.MaxInterval == 0 {
+		spec.MaxInterval = 5 * time.Second
+	}
+
+	// The probes and the pauses between them share the deadline, so a health endpoint
+	// that hangs cannot hold the wait past the timeout.
+	parent := ctx
+	ctx, cancel := context.WithTimeout(parent, spec.Timeout)
+	defer cancel()
+
+	target := host + spec.Endpoint
+	probe := func() error { return probeHTTP(ctx, client, target, spec) }
+	if spec.TCP {
+		addr, err := dialAddress(host)
+		if err != nil {
+			return err
+		}
+		target = addr
+		probe = func() error { return probeTCP(ctx, addr, spec.Interval) }
+	}
+
+	deadline := clock.Now().Add(spec.Timeout)
+	wait := spec.Interval
+
+	for attempt := 1; ; attempt++ {
+		last := probe()
+		if last == nil {
+			return nil
+		}
+
+		if parent.Err() != nil {
+			return fmt.Errorf("%s not ready: %w (last: %v)", target, parent.Err(), last)
+		}
+		if ctx.Err() != nil || !clock.Now().Add(wait).Before(deadline) {
+			return fmt.Errorf("%s not ready after %s (%d attempts): %w", target, spec.Timeout, attempt, last)
+		}
+
+		if err := clock.Sleep(ctx, wait); err != nil && parent.Err() != nil {
+			return fmt.Errorf("%s not ready: %w (last: %v)", target, parent.Err(), last)
+		} else if err != nil {
+			return fmt.Errorf("%s not ready after %s (%d attempts): %w", target, spec.Timeout, attempt, last)
+		}
+
+		wait = min(2*wait, spec.MaxInterval)
+	}
+}
+
+// probeHTTP returns why target is not ready, or nil.
+func probeHTTP(ctx context.Context, client *http.Client, target string, spec ReadinessSpec) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != spec.ExpectStatus {
+		return fmt.Errorf("status %d, expected %d, body: %s", response.StatusCode, spec.ExpectStatus, excerpt(body, maxExcerpt))
+	}
+	if !bytes.Contains(body, []byte(spec.BodyContains)) {
+		return fmt.Errorf("body does not contain %q: %s", spec.BodyContains, excerpt(body, maxExcerpt))
+	}
+
+	return nil
+}
+
+// probeTCP returns why addr does not accept connections, or nil.
+func probeTCP(ctx context.Context, addr string, timeout time.Duration) error {
+	dialer := &net.Dialer{Timeout: max(timeout, time.Second)}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// dialAddress returns host:port for host, which is either an address or a URL.
+func dialAddress(host string) (string, error) {
+	u, err := url.Parse(host)
+	if err != nil || u.Host == "" {
+		return host, nil
+	}
+
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+
+	switch u.Scheme {
+	case "http", "ws":
+		return net.JoinHostPort(u.Hostname(), "80"), nil
+	case "https", "wss":
+		return net.JoinHostPort(u.Hostname(), "443"), nil
+	}
+
+	return "", fmt.Errorf("no port in %s", host)
+}
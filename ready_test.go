@@ -0,0 +1,86 @@
+package reqbuilder
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForReady(t *testing.T) {
+	var probes atomic.Int32
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if probes.Add(1) < 3 {
+			http.Error(w, `{"status":"starting"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	clock := NewFakeClock(time.Unix(0, 0))
+	sleeps := advanceSleepers(t, clock)
+
+	b := New(require.New(t), WithClock(clock))
+	b.WaitForReady(t, context.Background(), server.URL, ReadinessSpec{Endpoint: "/healthz", BodyContains: `"status":"ok"`, Interval: time.Second})
+
+	require.EqualValues(t, 3, probes.Load())
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second}, sleeps(), "probes back off")
+}
+
+func TestWaitForReadyTimeout(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "database unreachable", http.StatusServiceUnavailable)
+	})
+	clock := NewFakeClock(time.Unix(0, 0))
+	advanceSleepers(t, clock)
+
+	msg := failure(t, []Option{WithClock(clock)}, func(b *Builder) {
+		b.WaitForReady(t, context.Background(), server.URL, ReadinessSpec{Endpoint: "/healthz", Timeout: 10 * time.Second, Interval: time.Second})
+	})
+
+	require.Contains(t, msg, "/healthz not ready after 10s (4 attempts): status 503, expected 200, body: database unreachable")
+}
+
+func TestWaitUntilReadyHangingProbe(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	start := time.Now()
+	err := WaitUntilReady(context.Background(), server.URL, ReadinessSpec{Endpoint: "/healthz", Timeout: 100 * time.Millisecond})
+
+	require.ErrorContains(t, err, "not ready after 100ms (1 attempts)")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 2*time.Second, "a hanging probe must not outlive the timeout")
+}
+
+func TestWaitUntilReadyTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	// Nothing listens yet: connection refused counts as not ready.
+	err = WaitUntilReady(context.Background(), addr, ReadinessSpec{TCP: true, Timeout: 100 * time.Millisecond, Interval: 10 * time.Millisecond})
+	require.ErrorContains(t, err, "connection refused")
+
+	listener, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	require.NoError(t, WaitUntilReady(context.Background(), "http://"+addr, ReadinessSpec{TCP: true, Timeout: time.Second}))
+}
+
+func TestWaitUntilReadyNegativeDurations(t *testing.T) {
+	for _, spec := range []ReadinessSpec{
+		{Timeout: -time.Second},
+		{Interval: -time.Second},
+		{MaxInterval: -time.Second},
+	} {
+		err := WaitUntilReady(context.Background(), "http://127.0.0.1:1", spec)
+		require.ErrorContains(t, err, "must not be negative")
+	}
+}
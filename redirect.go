@@ -0,0 +1,112 @@
+package reqbuilder
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// RedirectHook is called for every redirect followed, with the request that was
+// redirected and the one about to be sent, which it may modify. Returning an error
+// stops following redirects and fails the request.
+type RedirectHook func(prev, next *http.Request) error
+
+// WithRedirectHook adds hook to the redirects followed by the Builder.
+func WithRedirectHook(hook RedirectHook) Option {
+	return func(b *Builder) {
+		b.redirectHooks = append(b.redirectHooks[:len(b.redirectHooks):len(b.redirectHooks)], hook)
+	}
+}
+
+// StripSensitiveHeadersOnCrossOrigin restores the default redirect behaviour, undoing
+// ForwardHeadersOnRedirect: Authorization, Cookie and WWW-Authenticate set on the
+// original request are not sent to a host that is neither the original one nor its
+// subdomain. Cookies from WithCookieJar follow the jar's domain rules on every hop.
+// See ExpectHeaderDroppedOnRedirect.
+func StripSensitiveHeadersOnCrossOrigin() Option {
+	return func(b *Builder) {
+		b.redirectForward = nil
+	}
+}
+
+// ForwardHeadersOnRedirect sends the listed headers of the original request on every
+// redirect hop, including ones the client strips when the redirect leaves the origin.
+func ForwardHeadersOnRedirect(names ...string) Option {
+	return func(b *Builder) {
+		forward := b.redirectForward[:len(b.redirectForward):len(b.redirectForward)]
+		for _, name := range names {
+			forward = append(forward, http.CanonicalHeaderKey(name))
+		}
+		b.redirectForward = forward
+	}
+}
+
+// WithMaxRedirects follows at most n redirects, instead of the client's 10, failing
+// the request once the server redirects again. n must be positive; use
+// WithoutRedirects not to follow redirects at all.
+func WithMaxRedirects(n int) Option {
+	return func(b *Builder) {
+		b.require.Positive(n, "WithMaxRedirects needs a positive limit")
+		b.maxRedirects = n
+	}
+}
+
+// checkRedirect runs the client's own policy first and stops on any error it returns,
+// including http.ErrUseLastResponse from WithoutRedirects. Only a redirect that passes
+// it and the redirect limit gets forwarded headers and is shown to the redirect hooks.
+func (b *Builder) checkRedirect(check func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if check != nil {
+			if err := check(req, via); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case b.maxRedirects != 0 && len(via) > b.maxRedirects:
+			return fmt.Errorf("stopped after %d redirects, the WithMaxRedirects limit; next redirect to %s", b.maxRedirects, req.URL)
+		case b.maxRedirects == 0 && len(via) >= 10:
+			return errors.New("stopped after 10 redirects")
+		}
+
+		for _, name := range b.redirectForward {
+			if values := via[0].Header.Values(name); len(values) != 0 {
+				req.Header[name] = values
+			}
+		}
+
+		for _, hook := range b.redirectHooks {
+			if err := hook(via[len(via)-1], req); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// ExpectHeaderDroppedOnRedirect fails the test unless the original request had the
+// header and the final request of the redirect chain did not.
+func (b *Builder) ExpectHeaderDroppedOnRedirect(t *testing.T, response *http.Response, header string) {
+	t.Helper()
+
+	b.require.NotNil(response, "no response")
+
+	final := response.Request
+	original := final
+	for original.Response != nil && original.Response.Request != nil {
+		original = original.Response.Request
+	}
+
+	if original == final {
+		b.require.Failf("no redirect", "expected %s to be dropped on a redirect\n%s", header, b.describe(response))
+	}
+	if original.Header.Get(header) == "" {
+		b.require.Failf("header not sent", "the original request to %s had no %s header", original.URL, header)
+	}
+	if final.Header.Get(header) != "" {
+		b.require.Failf("header forwarded", "%s was sent to %s after redirect from %s\n%s",
+			header, final.URL, original.URL, dumpHeader(final.Header))
+	}
+}
@@ -0,0 +1,134 @@
+package reqbuilder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// redirectServers starts an auth server recording the headers it receives and an api
+// server on another origin that redirects /login there. The api server sets a session
+// cookie and, for /home, redirects to itself.
+func redirectServers(t *testing.T) (api string, authHeaders *[]http.Header) {
+	var headers []http.Header
+	auth := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+	})
+	authURL := strings.Replace(auth.URL, "127.0.0.1", "localhost", 1)
+
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+			http.Redirect(w, r, authURL+"/authorize", http.StatusFound)
+		case "/home":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+			http.Redirect(w, r, "/dashboard", http.StatusFound)
+		default:
+			w.Header().Set("X-Cookie", r.Header.Get("Cookie"))
+		}
+	})
+
+	return server.URL, &headers
+}
+
+func TestRedirectStripsSensitiveHeaders(t *testing.T) {
+	api, headers := redirectServers(t)
+
+	b := New(require.New(t), WithCookieJar())
+	response, _ := b.Send(t, context.Background(), Get(api+"/login").
+		SetHeader("Authorization", "Bearer s3cret").SetHeader("X-Custom", "1"))
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	b.ExpectHeaderDroppedOnRedirect(t, response, "Authorization")
+	require.Len(t, *headers, 1)
+	require.Empty(t, (*headers)[0].Get("Authorization"))
+	require.Empty(t, (*headers)[0].Get("Cookie"), "the jar must not send api cookies to the auth origin")
+	require.Equal(t, "1", (*headers)[0].Get("X-Custom"))
+
+	response, _ = b.Send(t, context.Background(), Get(api+"/home"))
+	require.Equal(t, "session=s1", response.Header.Get("X-Cookie"), "the jar sends cookies back to their own origin")
+}
+
+func TestForwardHeadersOnRedirect(t *testing.T) {
+	api, headers := redirectServers(t)
+
+	b := New(require.New(t), ForwardHeadersOnRedirect("authorization"))
+	b.Send(t, context.Background(), Get(api+"/login").SetHeader("Authorization", "Bearer s3cret"))
+	require.Equal(t, "Bearer s3cret", (*headers)[0].Get("Authorization"))
+
+	msg := failure(t, []Option{ForwardHeadersOnRedirect("Authorization")}, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), Get(api+"/login").SetHeader("Authorization", "Bearer s3cret"))
+		b.ExpectHeaderDroppedOnRedirect(t, response, "Authorization")
+	})
+	require.Contains(t, msg, "Authorization was sent to http://localhost")
+	require.NotContains(t, msg, "s3cret")
+
+	b = b.With(StripSensitiveHeadersOnCrossOrigin())
+	response, _ := b.Send(t, context.Background(), Get(api+"/login").SetHeader("Authorization", "Bearer s3cret"))
+	b.ExpectHeaderDroppedOnRedirect(t, response, "Authorization")
+}
+
+func TestWithRedirectHook(t *testing.T) {
+	api, headers := redirectServers(t)
+
+	var hops []string
+	b := New(require.New(t), WithRedirectHook(func(prev, next *http.Request) error {
+		hops = append(hops, prev.URL.Path+" -> "+next.URL.Path)
+		next.Header.Set("X-Hop", prev.URL.Path)
+		return nil
+	}))
+	b.Send(t, context.Background(), Get(api+"/login"))
+
+	require.Equal(t, []string{"/login -> /authorize"}, hops)
+	require.Equal(t, "/login", (*headers)[0].Get("X-Hop"))
+
+	msg := failure(t, []Option{WithRedirectHook(func(prev, next *http.Request) error {
+		return errors.New("no redirects to auth")
+	})}, func(b *Builder) {
+		b.Send(t, context.Background(), Get(api+"/login"))
+	})
+	require.Contains(t, msg, "no redirects to auth")
+}
+
+func TestRedirectHookRespectsClientPolicy(t *testing.T) {
+	api, headers := redirectServers(t)
+
+	var hooked bool
+	b := New(require.New(t), WithoutRedirects(), ForwardHeadersOnRedirect("Authorization"),
+		WithRedirectHook(func(prev, next *http.Request) error {
+			hooked = true
+			return nil
+		}))
+	response, _ := b.Send(t, context.Background(), Get(api+"/login").SetHeader("Authorization", "Bearer s3cret"))
+
+	require.Equal(t, http.StatusFound, response.StatusCode, "WithoutRedirects must still return the redirect")
+	require.False(t, hooked, "hooks only run for redirects the client follows")
+	require.Empty(t, *headers)
+}
+
+func TestWithMaxRedirects(t *testing.T) {
+	// /a -> /b -> /c -> /a -> ...
+	var visited []string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		visited = append(visited, r.URL.Path)
+		next := map[string]string{"/a": "/b", "/b": "/c", "/c": "/a"}[r.URL.Path]
+		http.Redirect(w, r, next, http.StatusFound)
+	})
+
+	msg := failure(t, []Option{WithMaxRedirects(3)}, func(b *Builder) {
+		b.Send(t, context.Background(), Get(server.URL+"/a"))
+	})
+
+	require.Contains(t, msg, "stopped after 3 redirects, the WithMaxRedirects limit; next redirect to "+server.URL+"/b")
+	require.Equal(t, []string{"/a", "/b", "/c", "/a"}, visited, "the request and 3 redirects")
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.With(WithMaxRedirects(0))
+	})
+	require.Contains(t, msg, "WithMaxRedirects needs a positive limit")
+}
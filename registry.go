@@ -0,0 +1,193 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// EndpointSpec describes an endpoint once, so tests can call it by name instead of
+// repeating its method, path and headers.
+type EndpointSpec struct {
+	// Extends names a defined spec whose fields are used where this one leaves them
+	// zero. Headers are merged, with this spec's values winning.
+	Extends string
+	Method  string
+	// Path is a URL or path with {name} placeholders filled from CallArgs.PathParams.
+	// Paths starting with "/" are resolved against the base URL, see WithBaseURL.
+	Path    string
+	Headers http.Header
+	// SuccessStatuses are the statuses a call must return; empty means any status.
+	SuccessStatuses []int
+	// Encode turns CallArgs.Body into the request body and its content type. The
+	// default encodes JSON.
+	Encode func(body any) ([]byte, string, error)
+}
+
+// CallArgs are the per-call parts of a request to a defined endpoint.
+type CallArgs struct {
+	PathParams map[string]string
+	Query      url.Values
+	// Headers override the spec's headers.
+	Headers       http.Header
+	Body          any
+	Cookies       []*http.Cookie
+	Authorization string
+	// ExpectStatuses overrides the spec's SuccessStatuses, e.g. for negative tests.
+	ExpectStatuses []int
+}
+
+// endpointRegistry holds the endpoints defined on a Builder and its copies.
+type endpointRegistry struct {
+	mu    sync.RWMutex
+	specs map[string]EndpointSpec
+}
+
+// Define registers spec under name for Call. Defining a name again replaces it.
+func (b *Builder) Define(name string, spec EndpointSpec) {
+	b.endpoints.mu.Lock()
+	defer b.endpoints.mu.Unlock()
+
+	b.endpoints.specs[name] = spec
+}
+
+// resolve returns the spec defined as name with the specs it extends merged in.
+func (b *Builder) resolve(name string) EndpointSpec {
+	b.endpoints.mu.RLock()
+	defer b.endpoints.mu.RUnlock()
+
+	var chain []EndpointSpec
+	seen := map[string]bool{}
+
+	for next := name; next != ""; {
+		spec, ok := b.endpoints.specs[next]
+		if !ok {
+			defined := make([]string, 0, len(b.endpoints.specs))
+			for n := range b.endpoints.specs {
+				defined = append(defined, n)
+			}
+			sort.Strings(defined)
+			b.require.Failf("unknown endpoint", "no endpoint named %q; defined: %s", next, strings.Join(defined, ", "))
+		}
+		if seen[next] {
+			b.require.Failf("endpoint cycle", "endpoint %q extends itself", next)
+		}
+		seen[next] = true
+
+		chain = append(chain, spec)
+		next = spec.Extends
+	}
+
+	var merged EndpointSpec
+	merged.Headers = http.Header{}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		spec := chain[i]
+		if spec.Method != "" {
+			merged.Method = spec.Method
+		}
+		if spec.Path != "" {
+			merged.Path = spec.Path
+		}
+		if spec.SuccessStatuses != nil {
+			merged.SuccessStatuses = spec.SuccessStatuses
+		}
+		if spec.Encode != nil {
+			merged.Encode = spec.Encode
+		}
+		for k, vs := range spec.Headers {
+			merged.Headers[k] = vs
+		}
+	}
+
+	return merged
+}
+
+// pathParam matches {name} placeholders in endpoint paths.
+var pathParam = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// Call sends a request to the endpoint defined as name and fails the test if the
+// response status is not one of the expected ones.
+func (b *Builder) Call(t *testing.T, ctx context.Context, name string, args CallArgs) (*http.Response, []*http.Cookie) {
+	t.Helper()
+
+	endpoint := b.resolve(name)
+
+	var missing []string
+	path := pathParam.ReplaceAllStringFunc(endpoint.Path, func(placeholder string) string {
+		param := placeholder[1 : len(placeholder)-1]
+		value, ok := args.PathParams[param]
+		if !ok {
+			missing = append(missing, param)
+		}
+		return url.PathEscape(value)
+	})
+	if len(missing) != 0 {
+		b.require.Failf("missing path parameters", "endpoint %q (%s) needs %s", name, endpoint.Path, strings.Join(missing, ", "))
+	}
+
+	if len(args.Query) != 0 {
+		separator := "?"
+		if strings.Contains(path, "?") {
+			separator = "&"
+		}
+		path += separator + args.Query.Encode()
+	}
+
+	method := endpoint.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	spec := NewSpec(method, path).Named(name)
+	spec.Headers = endpoint.Headers.Clone()
+	for k, vs := range args.Headers {
+		spec.Headers[http.CanonicalHeaderKey(k)] = vs
+	}
+	spec.Cookies = args.Cookies
+	spec.Authorization = args.Authorization
+
+	if args.Body != nil {
+		encode := endpoint.Encode
+		if encode == nil {
+			encode = encodeJSON
+		}
+
+		body, contentType, err := encode(args.Body)
+		if err != nil {
+			b.logError(t, err)
+		}
+		b.require.NoErrorf(err, "encoding body for endpoint %q", name)
+
+		spec.Body = body
+		if spec.Headers.Get("Content-Type") == "" {
+			spec.SetHeader("Content-Type", contentType)
+		}
+	}
+
+	response, cookies := b.Send(t, ctx, spec)
+
+	expected := endpoint.SuccessStatuses
+	if args.ExpectStatuses != nil {
+		expected = args.ExpectStatuses
+	}
+	if len(expected) != 0 && !slices.Contains(expected, response.StatusCode) {
+		b.require.Failf("unexpected status", "endpoint %q: expected one of %v\n%s", name, expected, b.describe(response))
+	}
+
+	return response, cookies
+}
+
+// encodeJSON is the default EndpointSpec body encoder.
+func encodeJSON(body any) ([]byte, string, error) {
+	data, err := json.Marshal(body)
+
+	return data, "application/json", err
+}
@@ -0,0 +1,87 @@
+package reqbuilder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefineAndCall(t *testing.T) {
+	var method, uri, contentType, version, tenant string
+	var body []byte
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		method, uri = r.Method, r.URL.RequestURI()
+		contentType, version, tenant = r.Header.Get("Content-Type"), r.Header.Get("X-Api-Version"), r.Header.Get("X-Tenant")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	b := New(require.New(t), WithBaseURL(server.URL))
+	b.Define("api", EndpointSpec{Headers: http.Header{"X-Api-Version": {"2"}, "X-Tenant": {"acme"}}})
+	b.Define("CreateUser", EndpointSpec{
+		Extends:         "api",
+		Method:          http.MethodPost,
+		Path:            "/orgs/{org}/users",
+		SuccessStatuses: []int{http.StatusCreated},
+	})
+
+	b.Call(t, context.Background(), "CreateUser", CallArgs{
+		PathParams: map[string]string{"org": "a b"},
+		Query:      url.Values{"notify": {"false"}},
+		Headers:    http.Header{"x-tenant": {"other"}},
+		Body:       map[string]string{"name": "ann"},
+	})
+
+	require.Equal(t, http.MethodPost, method)
+	require.Equal(t, "/orgs/a%20b/users?notify=false", uri)
+	require.Equal(t, "application/json", contentType)
+	require.Equal(t, "2", version, "headers are inherited from the extended spec")
+	require.Equal(t, "other", tenant, "call headers override the spec's")
+	require.JSONEq(t, `{"name":"ann"}`, string(body))
+}
+
+func TestCallFailures(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+	define := func(b *Builder) {
+		b.Define("GetUser", EndpointSpec{Path: server.URL + "/users/{id}", SuccessStatuses: []int{http.StatusOK}})
+		b.Define("ListUsers", EndpointSpec{Path: server.URL + "/users"})
+	}
+
+	for _, tt := range []struct {
+		name string
+		call func(b *Builder)
+		want string
+	}{
+		{"unknown", func(b *Builder) {
+			b.Call(t, context.Background(), "DeleteUser", CallArgs{})
+		}, `no endpoint named "DeleteUser"; defined: GetUser, ListUsers`},
+		{"missing param", func(b *Builder) {
+			b.Call(t, context.Background(), "GetUser", CallArgs{})
+		}, `endpoint "GetUser" (` + server.URL + `/users/{id}) needs id`},
+		{"status", func(b *Builder) {
+			b.Call(t, context.Background(), "GetUser", CallArgs{PathParams: map[string]string{"id": "1"}})
+		}, `endpoint "GetUser": expected one of [200]`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := failure(t, nil, func(b *Builder) {
+				define(b)
+				tt.call(b)
+			})
+			require.Contains(t, msg, tt.want)
+		})
+	}
+
+	b := New(require.New(t))
+	define(b)
+	response, _ := b.Call(t, context.Background(), "GetUser", CallArgs{
+		PathParams:     map[string]string{"id": "1"},
+		ExpectStatuses: []int{http.StatusConflict},
+	})
+	require.Equal(t, http.StatusConflict, response.StatusCode)
+}
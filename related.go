@@ -0,0 +1,205 @@
+package reqbuilder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// RelatedPart is one part of a multipart/related body. The first part is the root.
+type RelatedPart struct {
+	ContentType string
+	ContentID   string
+	Header      http.Header
+	Content     []byte
+}
+
+// RelatedBody builds a multipart/related body, as used by resumable upload protocols
+// for a JSON metadata part followed by the payload. It returns the Content-Type, with
+// the type parameter set to the root part's type and start to its Content-ID, and the
+// body, ready for RequestSpec.BodyBytes. The test fails if a part contains the
+// boundary.
+func (b *Builder) RelatedBody(t *testing.T, parts ...RelatedPart) (contentType string, body []byte) {
+	t.Helper()
+
+	b.require.NotEmpty(parts, "multipart/related needs at least one part")
+
+	buf := &bytes.Buffer{}
+	writer := b.multipartWriter(buf)
+
+	for _, p := range parts {
+		header := textproto.MIMEHeader{}
+		for k, vs := range p.Header {
+			header[textproto.CanonicalMIMEHeaderKey(k)] = vs
+		}
+		if p.ContentType != "" {
+			header.Set("Content-Type", p.ContentType)
+		}
+		if p.ContentID != "" {
+			header.Set("Content-ID", angleBracketed(p.ContentID))
+		}
+
+		b.writeBoundedPart(t, writer, header, p.Content)
+	}
+
+	err := writer.Close()
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	params := map[string]string{"boundary": writer.Boundary()}
+	if root := parts[0]; root.ContentType != "" {
+		mediaType, _, err := mime.ParseMediaType(root.ContentType)
+		if err != nil {
+			b.logError(t, err)
+		}
+		b.require.NoError(err, "root part Content-Type")
+		params["type"] = mediaType
+	}
+	if parts[0].ContentID != "" {
+		params["start"] = angleBracketed(parts[0].ContentID)
+	}
+
+	return mime.FormatMediaType("multipart/related", params), buf.Bytes()
+}
+
+// MixedBatch sends subRequests as application/http parts of a multipart/mixed POST,
+// the batch format of Google-style APIs, and parses the multipart/mixed response back
+// into one SubResponse per part. The test fails when the batch request is not
+// successful or its response cannot be parsed.
+func (b *Builder) MixedBatch(
+	t *testing.T,
+	ctx context.Context,
+	host,
+	endpoint string,
+	subRequests []SubRequest,
+	cookies []*http.Cookie,
+	headers map[string]string,
+	authorization string,
+	opts ...Option) ([]SubResponse, *http.Response, []*http.Cookie) {
+	t.Helper()
+
+	if len(opts) != 0 {
+		b = b.With(opts...)
+	}
+
+	body := &bytes.Buffer{}
+	writer := b.multipartWriter(body)
+
+	for i, sub := range subRequests {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", "application/http")
+		header.Set("Content-ID", angleBracketed("item-"+strconv.Itoa(i+1)))
+
+		b.writeBoundedPart(t, writer, header, serializeSubRequest(sub))
+	}
+
+	err := writer.Close()
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	merged := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		if http.CanonicalHeaderKey(k) != "Content-Type" {
+			merged[k] = v
+		}
+	}
+	merged["Content-Type"] = "multipart/mixed; boundary=" + writer.Boundary()
+
+	response, cookies := b.Request(t, ctx, http.MethodPost, host, endpoint, body.Bytes(), cookies, merged, authorization)
+	b.ExpectSuccess(t, response)
+
+	var subResponses []SubResponse
+	err = b.StreamMultipart(response, func(part *multipart.Part) error {
+		sub, err := parseSubResponse(part)
+		if err != nil {
+			return fmt.Errorf("part %d: %w", len(subResponses)+1, err)
+		}
+		subResponses = append(subResponses, sub)
+		return nil
+	})
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err, "parsing multipart/mixed batch response")
+
+	return subResponses, response, cookies
+}
+
+// serializeSubRequest writes sub as an HTTP/1.1 request message.
+func serializeSubRequest(sub SubRequest) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s HTTP/1.1\r\n", sub.Method, sub.Path)
+
+	keys := make([]string, 0, len(sub.Headers))
+	for k := range sub.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", http.CanonicalHeaderKey(k), sub.Headers[k])
+	}
+	if len(sub.Body) != 0 {
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n", len(sub.Body))
+	}
+
+	buf.WriteString("\r\n")
+	buf.Write(sub.Body)
+
+	return buf.Bytes()
+}
+
+// parseSubResponse reads an application/http part as an HTTP response message.
+func parseSubResponse(part *multipart.Part) (SubResponse, error) {
+	response, err := http.ReadResponse(bufio.NewReader(part), nil)
+	if err != nil {
+		return SubResponse{}, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return SubResponse{}, err
+	}
+
+	headers := make(map[string]string, len(response.Header))
+	for k := range response.Header {
+		headers[k] = response.Header.Get(k)
+	}
+
+	return SubResponse{Status: response.StatusCode, Headers: headers, Body: body}, nil
+}
+
+// writeBoundedPart writes a part, failing the test if its content contains the
+// boundary delimiter, which would split the part when parsed.
+func (b *Builder) writeBoundedPart(t *testing.T, writer *multipart.Writer, header textproto.MIMEHeader, content []byte) {
+	t.Helper()
+
+	if bytes.Contains(content, []byte("--"+writer.Boundary())) {
+		b.require.Failf("multipart boundary collision", "part contains the boundary %q\n%s",
+			writer.Boundary(), dumpHeader(http.Header(header)))
+	}
+
+	b.writePart(t, writer, header, content)
+}
+
+// angleBracketed returns a Content-ID in angle brackets.
+func angleBracketed(id string) string {
+	if len(id) > 1 && id[0] == '<' && id[len(id)-1] == '>' {
+		return id
+	}
+
+	return "<" + id + ">"
+}
@@ -0,0 +1,105 @@
+package reqbuilder
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelatedBody(t *testing.T) {
+	type part struct{ contentType, contentID, content string }
+
+	var params map[string]string
+	var parts []part
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var mediaType string
+		mediaType, params, _ = mime.ParseMediaType(r.Header.Get("Content-Type"))
+		require.Equal(t, "multipart/related", mediaType)
+
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			p, err := reader.NextPart()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			require.NoError(t, err)
+			content, _ := io.ReadAll(p)
+			parts = append(parts, part{p.Header.Get("Content-Type"), p.Header.Get("Content-ID"), string(content)})
+		}
+	})
+
+	b := New(require.New(t))
+	contentType, body := b.RelatedBody(t,
+		RelatedPart{ContentType: "application/json; charset=UTF-8", ContentID: "metadata", Content: []byte(`{"name":"scan.png"}`)},
+		RelatedPart{ContentType: "image/png", ContentID: "<media>", Content: []byte{0x89, 'P', 'N', 'G'}},
+	)
+	b.Send(t, context.Background(), Post(server.URL+"/upload?uploadType=multipart").BodyBytes(contentType, body))
+
+	require.Equal(t, "application/json", params["type"])
+	require.Equal(t, "<metadata>", params["start"])
+	require.Equal(t, []part{
+		{"application/json; charset=UTF-8", "<metadata>", `{"name":"scan.png"}`},
+		{"image/png", "<media>", "\x89PNG"},
+	}, parts)
+}
+
+func TestMixedBatch(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		reader := multipart.NewReader(r.Body, params["boundary"])
+
+		out := multipart.NewWriter(w)
+		w.Header().Set("Content-Type", "multipart/mixed; boundary="+out.Boundary())
+
+		for {
+			p, err := reader.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			require.Equal(t, "application/http", p.Header.Get("Content-Type"))
+
+			inner, err := http.ReadRequest(bufio.NewReader(p))
+			require.NoError(t, err)
+			body, _ := io.ReadAll(inner.Body)
+
+			part, _ := out.CreatePart(map[string][]string{"Content-Type": {"application/http"}})
+			if inner.Method == http.MethodPost {
+				fmt.Fprintf(part, "HTTP/1.1 201 Created\r\nContent-Type: application/json\r\nX-Tenant: %s\r\nContent-Length: %d\r\n\r\n%s",
+					inner.Header.Get("X-Tenant"), len(body), body)
+			} else {
+				fmt.Fprintf(part, "HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n")
+			}
+		}
+		out.Close()
+	})
+
+	b := New(require.New(t))
+	results, response, _ := b.MixedBatch(t, context.Background(), server.URL, "/batch", []SubRequest{
+		{Method: http.MethodPost, Path: "/users", Headers: map[string]string{"x-tenant": "acme"}, Body: []byte(`{"name":"ann"}`)},
+		{Method: http.MethodGet, Path: "/users/404"},
+	}, nil, nil, "")
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Len(t, results, 2)
+	require.Equal(t, http.StatusCreated, results[0].Status)
+	require.Equal(t, "acme", results[0].Headers["X-Tenant"])
+	require.JSONEq(t, `{"name":"ann"}`, string(results[0].Body))
+	require.Equal(t, http.StatusNotFound, results[1].Status)
+}
+
+func TestRelatedBodyBoundaryCollision(t *testing.T) {
+	msg := failure(t, []Option{WithMultipartBoundary("fixed")}, func(b *Builder) {
+		b.RelatedBody(t, RelatedPart{ContentType: "text/plain", Content: []byte("line\r\n--fixed\r\nmore")})
+	})
+
+	require.Contains(t, msg, `part contains the boundary "fixed"`)
+}
@@ -0,0 +1,270 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// requestNameKey is the context key a request's report name is stored under.
+type requestNameKey struct{}
+
+// WithReport writes a report of the requests each test sent to dir when the test
+// finishes: an HTML page for people and a JSON file for tools, one of each per test,
+// named after the test. Requests are listed under the name given with
+// RequestSpec.Named, or an automatic name such as "POST /orders #3". Secrets in
+// headers and JSON bodies are redacted.
+func WithReport(dir string) Option {
+	return func(b *Builder) {
+		b.report = &reporter{dir: dir, tests: map[*testing.T]*testReport{}}
+	}
+}
+
+// ReportEntry is one request in a test report.
+type ReportEntry struct {
+	Name            string        `json:"name"`
+	Method          string        `json:"method"`
+	URL             string        `json:"url"`
+	Status          int           `json:"status"`
+	Duration        time.Duration `json:"duration_ns"`
+	Error           string        `json:"error,omitempty"`
+	RequestHeaders  string        `json:"request_headers"`
+	RequestBody     string        `json:"request_body,omitempty"`
+	ResponseHeaders string        `json:"response_headers,omitempty"`
+	ResponseBody    string        `json:"response_body,omitempty"`
+}
+
+// reporter collects report entries per test. It is shared by a Builder and its copies.
+type reporter struct {
+	dir string
+
+	mu    sync.Mutex
+	tests map[*testing.T]*testReport
+}
+
+// testReport holds the entries of one test.
+type testReport struct {
+	mu      sync.Mutex
+	entries []ReportEntry
+}
+
+// record adds a request to the report of t, registering the report to be written
+// when t finishes.
+func (b *Builder) record(t *testing.T, req *http.Request, response *http.Response, err error, elapsed time.Duration) {
+	t.Helper()
+
+	r := b.report
+
+	r.mu.Lock()
+	report, ok := r.tests[t]
+	if !ok {
+		report = &testReport{}
+		r.tests[t] = report
+		t.Cleanup(func() {
+			r.mu.Lock()
+			delete(r.tests, t)
+			r.mu.Unlock()
+
+			if err := r.write(t.Name(), report); err != nil {
+				t.Errorf("writing request report: %v", err)
+			}
+		})
+	}
+	r.mu.Unlock()
+
+	entry := ReportEntry{
+		Method:         req.Method,
+		URL:            redactURL(req.URL),
+		Duration:       elapsed,
+		RequestHeaders: dumpHeader(req.Header),
+		RequestBody:    redactBody(requestBody(req)),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if response != nil {
+		entry.Status = response.StatusCode
+		entry.ResponseHeaders = dumpHeader(response.Header)
+		entry.ResponseBody = redactBody(b.peekBody(response, maxExcerpt))
+	}
+
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	entry.Name, _ = req.Context().Value(requestNameKey{}).(string)
+	if entry.Name == "" {
+		entry.Name = fmt.Sprintf("%s %s #%d", req.Method, req.URL.Path, len(report.entries)+1)
+	}
+	report.entries = append(report.entries, entry)
+}
+
+// unsafeFileChars matches characters not kept in report file names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// reportFileName turns a test name into a file name. Names that had to be changed get
+// a hash of the original appended, so that tests such as "a/b c" and "a/b_c" running
+// in parallel do not overwrite each other's reports.
+func reportFileName(testName string) string {
+	name := unsafeFileChars.ReplaceAllString(testName, "_")
+	if name == testName {
+		return name
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(testName))
+
+	return fmt.Sprintf("%s-%08x", name, h.Sum32())
+}
+
+// write stores the report of the named test as HTML and JSON.
+func (r *reporter) write(testName string, report *testReport) error {
+	report.mu.Lock()
+	entries := append([]ReportEntry(nil), report.entries...)
+	report.mu.Unlock()
+
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return err
+	}
+
+	base := filepath.Join(r.dir, reportFileName(testName))
+
+	data, err := marshalReadable(struct {
+		Test     string        `json:"test"`
+		Requests []ReportEntry `json:"requests"`
+	}{testName, entries})
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(base+".json", data, 0o644); err != nil {
+		return err
+	}
+
+	var page strings.Builder
+	if err = reportTemplate.Execute(&page, struct {
+		Test     string
+		Requests []ReportEntry
+	}{testName, entries}); err != nil {
+		return err
+	}
+
+	return os.WriteFile(base+".html", []byte(page.String()), 0o644)
+}
+
+// sensitiveJSONKey matches JSON field names whose values are redacted in reports.
+var sensitiveJSONKey = regexp.MustCompile(`(?i)password|secret|token|api[_-]?key`)
+
+// redactURL returns u as text without its userinfo password and with the values of
+// query parameters named like sensitive JSON fields redacted.
+func redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		key, _, hasValue := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); hasValue && err == nil && sensitiveJSONKey.MatchString(name) {
+			params[i] = key + "=" + url.QueryEscape("<redacted>")
+		}
+	}
+
+	redacted := *u
+	redacted.RawQuery = strings.Join(params, "&")
+
+	return redacted.Redacted()
+}
+
+// sensitiveJSONString matches a string field with a sensitive name in JSON text,
+// including one whose value is cut off at the end of a truncated body.
+var sensitiveJSONString = regexp.MustCompile(`(?i)("[^"]*(?:password|secret|token|api[_-]?key)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+
+// redactBody returns body as text for a report, with sensitive JSON fields redacted.
+// Bodies that do not parse, such as JSON cut off at the excerpt limit, have sensitive
+// string fields redacted textually.
+func redactBody(body []byte) string {
+	var doc any
+	if json.Unmarshal(body, &doc) != nil {
+		return excerpt(sensitiveJSONString.ReplaceAll(body, []byte(`$1"<redacted>"`)), maxExcerpt)
+	}
+
+	redacted, err := marshalReadable(redactJSON(doc))
+	if err != nil {
+		return excerpt(body, maxExcerpt)
+	}
+
+	return excerpt(bytes.TrimSuffix(redacted, []byte("\n")), maxExcerpt)
+}
+
+// marshalReadable encodes v as indented JSON without escaping HTML characters.
+func marshalReadable(v any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// redactJSON replaces the values of sensitive fields in a decoded JSON document.
+func redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if sensitiveJSONKey.MatchString(key) {
+				v[key] = "<redacted>"
+			} else {
+				v[key] = redactJSON(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redactJSON(value)
+		}
+	}
+
+	return v
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Test}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+pre { margin: 0; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Test}}</h1>
+<table>
+<tr><th>Request</th><th>Method</th><th>URL</th><th>Status</th><th>Duration</th><th>Details</th></tr>
+{{range .Requests}}<tr>
+<td>{{.Name}}</td><td>{{.Method}}</td><td>{{.URL}}</td>
+<td>{{if .Error}}{{.Error}}{{else}}{{.Status}}{{end}}</td><td>{{.Duration}}</td>
+<td><details><summary>request</summary><pre>{{.RequestHeaders}}
+{{.RequestBody}}</pre></details>
+<details><summary>response</summary><pre>{{.ResponseHeaders}}
+{{.ResponseBody}}</pre></details></td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,140 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readReport decodes the JSON report written for the named test in dir.
+func readReport(t *testing.T, dir, testName string) []ReportEntry {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, reportFileName(testName)+".json"))
+	require.NoError(t, err)
+
+	var report struct {
+		Test     string        `json:"test"`
+		Requests []ReportEntry `json:"requests"`
+	}
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Equal(t, testName, report.Test)
+
+	return report.Requests
+}
+
+func TestWithReport(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":7,"token":"t0ken"}`))
+	})
+	dir := t.TempDir()
+	b := New(require.New(t), WithReport(dir))
+
+	var name string
+	t.Run("create order", func(t *testing.T) {
+		name = t.Name()
+		b.Send(t, context.Background(), Post(server.URL+"/orders").JSONBody(map[string]string{"password": "hunter2"}).Named("create order"))
+		b.Send(t, context.Background(), Post(server.URL+"/orders"))
+	})
+
+	entries := readReport(t, dir, name)
+	require.Len(t, entries, 2)
+	require.Equal(t, "create order", entries[0].Name)
+	require.Equal(t, "POST /orders #2", entries[1].Name)
+	require.Equal(t, http.StatusOK, entries[0].Status)
+	require.Contains(t, entries[0].ResponseBody, `"id": 7`)
+
+	for _, file := range []string{".json", ".html"} {
+		data, err := os.ReadFile(filepath.Join(dir, reportFileName(name)+file))
+		require.NoError(t, err)
+		require.NotContains(t, string(data), "hunter2")
+		require.NotContains(t, string(data), "t0ken")
+	}
+}
+
+func TestWithReportParallelFileNames(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	dir := t.TempDir()
+	b := New(require.New(t), WithReport(dir))
+
+	// Both names sanitize to the same file name.
+	names := []string{"a b", "a_b", "a/b"}
+	t.Run("group", func(t *testing.T) {
+		for _, name := range names {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				b.Send(t, context.Background(), Get(server.URL+"/"+strings.ReplaceAll(name, " ", "")))
+			})
+		}
+	})
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, len(names))
+}
+
+func TestWithReportPeeksBody(t *testing.T) {
+	large := strings.Repeat("a", 4*maxExcerpt)
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(large))
+	})
+	dir := t.TempDir()
+	b := New(require.New(t), WithReport(dir))
+
+	var name string
+	t.Run("large", func(t *testing.T) {
+		name = t.Name()
+		response, _ := b.Send(t, context.Background(), Get(server.URL))
+		require.Equal(t, large, string(b.requireBody(response)), "reporting must leave the body to the caller")
+	})
+
+	entries := readReport(t, dir, name)
+	require.Len(t, entries, 1)
+	require.Equal(t, strings.Repeat("a", maxExcerpt), entries[0].ResponseBody)
+}
+
+func TestRedactBodyTruncated(t *testing.T) {
+	redacted := redactBody([]byte(`{"user":"ann","password":"hunter2","api_key":"k-12`))
+
+	require.Equal(t, `{"user":"ann","password":"<redacted>","api_key":"<redacted>"`, redacted)
+}
+
+func TestReportFileName(t *testing.T) {
+	require.Equal(t, "TestPlain", reportFileName("TestPlain"))
+	require.NotEqual(t, reportFileName("T/a b"), reportFileName("T/a_b"))
+	require.Regexp(t, `^T_a_b-[0-9a-f]{8}$`, reportFileName("T/a b"))
+}
+
+func TestWithReportRedactsURL(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	dir := t.TempDir()
+	b := New(require.New(t), WithReport(dir))
+
+	target := strings.Replace(server.URL, "http://", "http://ann:hunter2@", 1) + "/export?page=2&access_token=t0ken&API-Key=k3y"
+
+	var name string
+	t.Run("export", func(t *testing.T) {
+		name = t.Name()
+		b.Send(t, context.Background(), Get(target))
+	})
+
+	entries := readReport(t, dir, name)
+	require.Len(t, entries, 1)
+	require.Equal(t, strings.Replace(server.URL, "http://", "http://ann:xxxxx@", 1)+
+		"/export?page=2&access_token=%3Credacted%3E&API-Key=%3Credacted%3E", entries[0].URL)
+
+	for _, file := range []string{".json", ".html"} {
+		data, err := os.ReadFile(filepath.Join(dir, reportFileName(name)+file))
+		require.NoError(t, err)
+		for _, secret := range []string{"hunter2", "t0ken", "k3y"} {
+			require.NotContains(t, string(data), secret)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WithReproOnFailure appends a standalone net/http reproducer of the request to the
+// failure messages of the Builder's response assertions.
+func WithReproOnFailure() Option {
+	return func(b *Builder) {
+		b.reproOnFailure = true
+	}
+}
+
+// AsGoSource returns a gofmt-clean, standalone Go program that recreates the request
+// with net/http alone: method, URL, headers in sorted order, cookies and body. Values of
+// sensitive headers and cookies are read from environment variables instead of being
+// embedded.
+func AsGoSource(req *http.Request, body []byte) string {
+	var sb strings.Builder
+	usesOS := false
+
+	getenv := func(name string) string {
+		usesOS = true
+		name = strings.ToUpper(strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return '_'
+		}, name))
+
+		return fmt.Sprintf("os.Getenv(%q)", name)
+	}
+
+	bodyArg := "nil"
+	if len(body) != 0 {
+		bodyArg = "bytes.NewReader(body)"
+		sb.WriteString("\tbody := " + goBytesLiteral(body) + "\n")
+	}
+
+	fmt.Fprintf(&sb, "\treq, err := http.NewRequest(%q, %q, %s)\n", req.Method, req.URL.String(), bodyArg)
+	sb.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+
+	if req.Host != "" && req.Host != req.URL.Host {
+		fmt.Fprintf(&sb, "\treq.Host = %q\n", req.Host)
+	}
+
+	keys := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		if http.CanonicalHeaderKey(k) != "Cookie" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range req.Header[k] {
+			value := strconv.Quote(v)
+			if sensitiveHeaders[http.CanonicalHeaderKey(k)] {
+				value = getenv(k)
+			}
+			if k == http.CanonicalHeaderKey(k) {
+				fmt.Fprintf(&sb, "\treq.Header.Add(%q, %s)\n", k, value)
+			} else {
+				fmt.Fprintf(&sb, "\treq.Header[%q] = append(req.Header[%q], %s)\n", k, k, value)
+			}
+		}
+	}
+
+	for _, c := range req.Cookies() {
+		fmt.Fprintf(&sb, "\treq.AddCookie(&http.Cookie{Name: %q, Value: %s})\n", c.Name, getenv("COOKIE_"+c.Name))
+	}
+
+	var src bytes.Buffer
+	src.WriteString("package main\n\nimport (\n")
+	if len(body) != 0 {
+		src.WriteString("\t\"bytes\"\n")
+	}
+	src.WriteString("\t\"fmt\"\n\t\"io\"\n\t\"net/http\"\n")
+	if usesOS {
+		src.WriteString("\t\"os\"\n")
+	}
+	src.WriteString(")\n\nfunc main() {\n")
+	src.WriteString(sb.String())
+	src.WriteString(`
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(resp.Status)
+	fmt.Println(string(respBody))
+}
+`)
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return src.String()
+	}
+
+	return string(formatted)
+}
+
+// goBytesLiteral renders body as a []byte literal: a quoted string for text and
+// hex bytes for binary content.
+func goBytesLiteral(body []byte) string {
+	if !isBinary(body) {
+		return "[]byte(" + strconv.Quote(string(body)) + ")"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[]byte{")
+	for i, c := range body {
+		if i%16 == 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "0x%02x,", c)
+	}
+	sb.WriteString("\n}")
+
+	return sb.String()
+}
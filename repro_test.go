@@ -0,0 +1,83 @@
+package reqbuilder
+
+import (
+	"context"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// requireGoSource fails unless src parses as a Go file and is already gofmt-formatted.
+func requireGoSource(t *testing.T, src string) {
+	t.Helper()
+
+	_, err := parser.ParseFile(token.NewFileSet(), "main.go", src, parser.AllErrors)
+	require.NoError(t, err, src)
+
+	formatted, err := format.Source([]byte(src))
+	require.NoError(t, err)
+	require.Equal(t, string(formatted), src, "not gofmt-clean")
+}
+
+func TestAsGoSource(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/v1/items?dry_run=1", nil)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Add("Accept", "application/json")
+	req.Header["x-lower"] = []string{"kept"}
+	req.Host = "internal.example.com"
+	req.AddCookie(&http.Cookie{Name: "session-id", Value: "s3cr3t"})
+
+	src := AsGoSource(req, []byte(`{"name":"widget"}`))
+	requireGoSource(t, src)
+
+	require.Contains(t, src, `http.NewRequest("POST", "https://api.example.com/v1/items?dry_run=1", bytes.NewReader(body))`)
+	require.Contains(t, src, `body := []byte("{\"name\":\"widget\"}")`)
+	require.Contains(t, src, `req.Host = "internal.example.com"`)
+	require.Contains(t, src, `req.Header.Add("Authorization", os.Getenv("AUTHORIZATION"))`)
+	require.Contains(t, src, `req.AddCookie(&http.Cookie{Name: "session-id", Value: os.Getenv("COOKIE_SESSION_ID")})`)
+	require.Contains(t, src, `req.Header["x-lower"] = append(req.Header["x-lower"], "kept")`)
+	require.NotContains(t, src, "secret-token")
+	require.NotContains(t, src, "s3cr3t")
+	require.Less(t, strings.Index(src, `"Accept"`), strings.Index(src, `"Authorization"`))
+	require.Less(t, strings.Index(src, `"Authorization"`), strings.Index(src, `"Content-Type"`))
+}
+
+func TestAsGoSourceBinaryBody(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPut, "http://localhost/blob", nil)
+	require.NoError(t, err)
+
+	src := AsGoSource(req, []byte{0x89, 'P', 'N', 'G', 0, 1, 2, 0xff})
+	requireGoSource(t, src)
+	require.Contains(t, src, "0x89, 0x50, 0x4e, 0x47, 0x00, 0x01, 0x02, 0xff,")
+	require.NotContains(t, src, `"os"`)
+
+	req, err = http.NewRequest(http.MethodGet, "http://localhost/", nil)
+	require.NoError(t, err)
+	src = AsGoSource(req, nil)
+	requireGoSource(t, src)
+	require.NotContains(t, src, `"bytes"`)
+}
+
+func TestWithReproOnFailure(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	msg := failure(t, []Option{WithReproOnFailure()}, func(b *Builder) {
+		response, _ := b.Request(t, context.Background(), http.MethodPost, server.URL, "/brew", []byte("earl grey"),
+			nil, map[string]string{"X-Api-Key": "k-123"}, "")
+		b.ExpectStatus(t, response, http.StatusOK)
+	})
+
+	require.Contains(t, msg, "reproducer:")
+	require.Contains(t, msg, `body := []byte("earl grey")`)
+	require.Contains(t, msg, `os.Getenv("X_API_KEY")`)
+	require.NotContains(t, msg, "k-123")
+}
@@ -2,30 +2,377 @@ package reqbuilder
 
 import (
 	"bytes"
-	"compress/gzip"
 	"context"
+	"errors"
 	"github.com/andybalholm/brotli"
-	"github.com/klauspost/compress/flate"
-	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
 	"io"
-	"mime/multipart"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Builder is a helper for sending HTTP requests in tests.
 type Builder struct {
-	client  *http.Client
-	require *require.Assertions
+	client        *http.Client
+	customClient  *http.Client
+	transport     *http.Transport
+	ownsTransport bool
+	require       *require.Assertions
+	conns         *connCounter
+	zstd          *zstdPool
+	cleanups      *sync.Map
+	endpoints     *endpointRegistry
+
+	methodOverride  bool
+	connectionClose bool
+	http10          bool
+	jsonNumber      bool
+	autoContentType bool
+	requestEncoding string
+	queries         []func() (url.Values, error)
+	headers         []func(http.Header)
+	rawCapture      *rawCapture
+	baseURL         string
+	retry           *retryPolicy
+	retryJitter     RetryJitter
+	jitterRand      *lockedRand
+	retryPredicate  RetryPredicate
+	noRetryKeys     []any
+	reproOnFailure  bool
+	validators      []func(*http.Response) error
+	bodyReplay      bool
+	bodyTee         io.Writer
+	bodyTeeLog      int
+	bodyTeeRaw      bool
+	faults          *FaultTransport
+	bandwidth       *bandwidthLimit
+	bodyFactory     func() io.Reader
+	responseCache   *responseCache
+	logger          *slog.Logger
+	verbosity       int
+	report          *reporter
+	errorDecoder    func(status int, body []byte) error
+	stickyHeaders   []string
+	cookieEncoder   func(name, value string) string
+	decryptor       ResponseDecryptor
+	decryptTypes    []string
+	checksum        *bodyChecksum
+	summaryLog      testing.TB
+	redirectHooks   []RedirectHook
+	redirectForward []string
+	maxRedirects    int
+	trackReferer    bool
+	referer         string
+	noReferer       bool
+
+	failureBodyLimit int
+	maxResponseBytes int64
+	responseLimits   map[string]int64
+	strictEncoding   bool
+	sniffEncoding    bool
+
+	languageConfidence language.Confidence
+	multipartBoundary  string
+
+	clock Clock
 }
 
-func New(require *require.Assertions) *Builder {
-	return &Builder{
-		client:  &http.Client{},
-		require: require,
+func New(require *require.Assertions, opts ...Option) *Builder {
+	b := &Builder{
+		client:        &http.Client{},
+		transport:     http.DefaultTransport.(*http.Transport).Clone(),
+		ownsTransport: true,
+		require:       require,
+		conns:         &connCounter{},
+		zstd:          &zstdPool{},
+		cleanups:      &sync.Map{},
+		endpoints:     &endpointRegistry{specs: map[string]EndpointSpec{}},
+		clock:         realClock{},
 	}
 
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	b.transport.DialContext = b.countDials(dialer.DialContext)
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// With returns a copy of the Builder with additional options applied, leaving the
+// original untouched. It is useful for one-off configuration of a single request.
+func (b *Builder) With(opts ...Option) *Builder {
+	clone := *b
+	client := *b.client
+	clone.client = &client
+	clone.ownsTransport = false
+
+	for _, opt := range opts {
+		opt(&clone)
+	}
+
+	return &clone
+}
+
+// forTest returns a copy of the Builder whose assertions fail t, for use in subtests.
+func (b *Builder) forTest(t *testing.T) *Builder {
+	clone := b.With()
+	clone.require = require.New(t)
+
+	return clone
+}
+
+// ownTransport returns the Builder's transport, first copying it if it is shared with
+// the Builder this one was cloned from.
+func (b *Builder) ownTransport() *http.Transport {
+	if !b.ownsTransport {
+		b.transport = b.transport.Clone()
+		b.ownsTransport = true
+	}
+
+	return b.transport
+}
+
+// httpClient returns the client used to send a request: the Builder's own or the one
+// set with WithClient, sending through the Builder's round-tripper chain.
+func (b *Builder) httpClient() *http.Client {
+	client := *b.client
+	var transport http.RoundTripper = b.transport
+	if b.customClient != nil {
+		client = *b.customClient
+		transport = client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+	}
+
+	client.Transport = b.roundTripper(transport)
+	if len(b.redirectHooks) != 0 || len(b.redirectForward) != 0 || b.maxRedirects != 0 {
+		client.CheckRedirect = b.checkRedirect(client.CheckRedirect)
+	}
+
+	return &client
+}
+
+// roundTripper returns the chain requests are sent through, ending in transport.
+func (b *Builder) roundTripper(transport http.RoundTripper) http.RoundTripper {
+	rt := transport
+	if b.http10 {
+		dialer, ok := transport.(*http.Transport)
+		if !ok {
+			dialer = b.transport
+		}
+		rt = &http10RoundTripper{transport: dialer, capture: b.rawCapture}
+	} else if b.rawCapture != nil {
+		rt = &captureRoundTripper{capture: b.rawCapture, next: rt}
+	}
+
+	if b.checksum != nil {
+		rt = &checksumRoundTripper{checksum: b.checksum, next: rt}
+	}
+
+	if b.bandwidth != nil {
+		rt = &bandwidthRoundTripper{limit: *b.bandwidth, next: rt, clock: b.clock}
+	}
+
+	if b.faults != nil {
+		rt = &faultRoundTripper{faults: b.faults, next: rt, clock: b.clock}
+	}
+
+	if b.responseCache != nil {
+		rt = &cacheRoundTripper{cache: b.responseCache, next: rt, clock: b.clock, zstd: b.zstd}
+	}
+
+	return rt
+}
+
+// do sends the request with the Builder's client and fails the test on transport errors.
+func (b *Builder) do(t *testing.T, req *http.Request) *http.Response {
+	t.Helper()
+
+	response, _ := b.doWith(t, req, doHooks{})
+
+	return response
+}
+
+// doHooks adjust a single send for callers such as SendAndCancel.
+type doHooks struct {
+	// prepared is called with the prepared request just before it is sent.
+	prepared func(req *http.Request)
+	// tolerate reports whether an error, for example the cancellation a test caused on
+	// purpose, is returned to the caller instead of failing the test.
+	tolerate func(err error) bool
+}
+
+// doWith is do with hooks. A tolerated error is returned, with the response if it
+// happened while buffering the body for WithBodyReplay or the validators.
+func (b *Builder) doWith(t *testing.T, req *http.Request, hooks doHooks) (*http.Response, error) {
+	t.Helper()
+
+	b.closeIdleOnCleanup(t)
+
+	req = b.prepare(t, req)
+	if hooks.prepared != nil {
+		hooks.prepared(req)
+	}
+	start := b.clock.Now()
+	response, err := b.send(req)
+	elapsed := b.clock.Now().Sub(start)
+	b.logExchange(t, req, response, err, elapsed)
+	if b.summaryLog != nil {
+		b.logSummary(req, response, err, elapsed)
+	}
+	if b.report != nil {
+		b.record(t, req, response, err, elapsed)
+	}
+	if err != nil && hooks.tolerate != nil && hooks.tolerate(err) {
+		return nil, err
+	}
+	if err != nil && b.verbosity < 1 {
+		// With a verbosity set, logExchange has already logged the failure.
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	b.teeBody(t, req, response)
+
+	if meta := metaOf(response); meta != nil && response.Body != nil {
+		response.Body = &timedBody{ReadCloser: response.Body, b: b, meta: meta}
+	}
+
+	if b.bodyReplay || len(b.validators) != 0 {
+		_, err = bufferBody(response)
+		if err != nil && hooks.tolerate != nil && hooks.tolerate(err) {
+			return response, err
+		}
+		if err != nil {
+			b.logError(t, err)
+		}
+		b.require.NoError(err)
+	}
+
+	b.validate(t, response)
+
+	return response, nil
+}
+
+// prepare applies the Builder's per-request configuration and attaches tracing.
+func (b *Builder) prepare(t *testing.T, req *http.Request) *http.Request {
+	t.Helper()
+
+	if b.bodyFactory != nil {
+		b.applyBodyFactory(req)
+	}
+
+	if b.methodOverride {
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodPost:
+		default:
+			req.Header.Set("X-HTTP-Method-Override", req.Method)
+			req.Method = http.MethodPost
+		}
+	}
+
+	if b.connectionClose {
+		req.Close = true
+	}
+
+	b.applyQuery(t, req)
+
+	for _, apply := range b.headers {
+		apply(req.Header)
+	}
+
+	b.applyReferer(req)
+
+	if b.autoContentType && req.Header.Get("Content-Type") == "" {
+		b.sniffContentType(t, req)
+	}
+
+	if b.requestEncoding != "" {
+		b.compressBody(t, req)
+	}
+
+	meta := &requestMeta{b: b}
+	if b.reproOnFailure {
+		meta.body = requestBody(req)
+	}
+	ctx := context.WithValue(req.Context(), requestMetaKey{}, meta)
+
+	return req.WithContext(httptrace.WithClientTrace(ctx, b.trace(meta)))
+}
+
+// sniffContentType sets the request Content-Type from the first bytes of its body.
+func (b *Builder) sniffContentType(t *testing.T, req *http.Request) {
+	t.Helper()
+
+	if req.ContentLength == 0 || req.GetBody == nil {
+		return
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+	defer body.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		b.logError(t, err)
+		b.require.NoError(err)
+	}
+
+	req.Header.Set("Content-Type", http.DetectContentType(head[:n]))
+}
+
+// requestBody returns a copy of the request body without consuming it, or nil when
+// the body cannot be replayed.
+func requestBody(req *http.Request) []byte {
+	if req.GetBody == nil {
+		return nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+
+	data, _ := io.ReadAll(body)
+
+	return data
+}
+
+// mergeCookies returns the response cookies plus those sent cookies the server did not replace.
+func mergeCookies(response *http.Response, cookies []*http.Cookie) []*http.Cookie {
+	cookieMap := make(map[string]*http.Cookie)
+
+	for _, c := range response.Cookies() {
+		cookieMap[c.Name] = c
+	}
+
+	for _, c := range cookies {
+		if _, exists := cookieMap[c.Name]; !exists {
+			cookieMap[c.Name] = c
+		}
+	}
+
+	allCookies := make([]*http.Cookie, 0, len(cookieMap))
+	for _, c := range cookieMap {
+		allCookies = append(allCookies, c)
+	}
+
+	return allCookies
 }
 
 // Request sends a POST request to the specified endpoint.
@@ -38,12 +385,17 @@ func (b *Builder) Request(
 	reqBody []byte,
 	cookies []*http.Cookie,
 	headers map[string]string,
-	authorization string) (*http.Response, []*http.Cookie) {
+	authorization string,
+	opts ...Option) (*http.Response, []*http.Cookie) {
 	t.Helper()
 
-	req, err := http.NewRequestWithContext(ctx, method, host+endpoint, bytes.NewReader(reqBody))
+	if len(opts) != 0 {
+		b = b.With(opts...)
+	}
+
+	req, err := newRequest(ctx, method, host+endpoint, bytes.NewReader(reqBody))
 	if err != nil {
-		t.Log(err)
+		b.logError(t, err)
 	}
 
 	b.require.NoError(err)
@@ -56,7 +408,7 @@ func (b *Builder) Request(
 
 	if cookies != nil && len(cookies) != 0 {
 		for _, cookie := range cookies {
-			req.AddCookie(cookie)
+			b.addCookie(req, cookie)
 		}
 	}
 
@@ -64,37 +416,59 @@ func (b *Builder) Request(
 		req.Header.Set("Authorization", authorization)
 	}
 
-	response, err := b.client.Do(req)
-	if err != nil {
-		t.Log(err)
-	}
-	b.require.NoError(err)
+	response := b.do(t, req)
 
-	cookieMap := make(map[string]*http.Cookie)
+	return response, mergeCookies(response, cookies)
+}
 
-	for _, c := range response.Cookies() {
-		cookieMap[c.Name] = c
-	}
+// BrotliReadCloser pairs a brotli decoder with the Closer of the stream it reads, so
+// it can be used as a value wherever an io.ReadCloser is expected. For response bodies
+// use BodyReader, which picks the decoder from the Content-Encoding and releases it on
+// Close.
+type BrotliReadCloser struct {
+	*brotli.Reader
+	io.Closer
+}
 
-	if cookies != nil && len(cookies) != 0 {
-		for _, c := range cookies {
-			if _, exists := cookieMap[c.Name]; !exists {
-				cookieMap[c.Name] = c
-			}
-		}
-	}
+// errBrotliClosed is returned by reads from a closed brotliBody.
+var errBrotliClosed = errors.New("brotli: read after Close")
 
-	allCookies := make([]*http.Cookie, 0, len(cookieMap))
-	for _, c := range cookieMap {
-		allCookies = append(allCookies, c)
+// brotliBody decodes brotli from reader and closes closer, if set, on Close. Close also
+// drops the brotli reader, and with it the decoder's window and state, and is safe to
+// call more than once; reads after Close fail.
+type brotliBody struct {
+	mu     sync.Mutex // held by Read while the decoder runs
+	reader *brotli.Reader
+	closer io.Closer
+
+	once sync.Once
+	err  error
+}
+
+func (r *brotliBody) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.reader == nil {
+		return 0, errBrotliClosed
 	}
 
-	return response, allCookies
+	return r.reader.Read(p)
 }
 
-type BrotliReadCloser struct {
-	*brotli.Reader
-	io.Closer
+func (r *brotliBody) Close() error {
+	r.once.Do(func() {
+		// Closing the source first unblocks a Read waiting on it, so the lock is free.
+		if r.closer != nil {
+			r.err = r.closer.Close()
+		}
+
+		r.mu.Lock()
+		r.reader = nil
+		r.mu.Unlock()
+	})
+
+	return r.err
 }
 
 // TODO handler
@@ -109,30 +483,35 @@ func (b *Builder) MultipartRequest(
 	formData string,
 	cookies []*http.Cookie,
 	headers map[string]string,
-	authorization string) (*http.Response, []*http.Cookie) {
+	authorization string,
+	opts ...Option) (*http.Response, []*http.Cookie) {
 	t.Helper()
 
+	if len(opts) != 0 {
+		b = b.With(opts...)
+	}
+
 	var req *http.Request
 	var err error
 
 	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+	writer := b.multipartWriter(body)
 
 	err = writer.WriteField(formData, string(requestBody))
 	if err != nil {
-		t.Log(err)
+		b.logError(t, err)
 		b.require.NoError(err)
 	}
 	func() {
 		if err = writer.Close(); err != nil {
-			t.Log(err)
+			b.logError(t, err)
 			b.require.NoError(err)
 		}
 	}()
 
-	req, err = http.NewRequestWithContext(ctx, method, host+endpoint, body)
+	req, err = newRequest(ctx, method, host+endpoint, body)
 	if err != nil {
-		t.Log(err)
+		b.logError(t, err)
 		b.require.NoError(err)
 	}
 
@@ -146,7 +525,7 @@ func (b *Builder) MultipartRequest(
 
 	if cookies != nil && len(cookies) != 0 {
 		for _, cookie := range cookies {
-			req.AddCookie(cookie)
+			b.addCookie(req, cookie)
 		}
 	}
 
@@ -154,34 +533,9 @@ func (b *Builder) MultipartRequest(
 		req.Header.Set("Authorization", authorization)
 	}
 
-	response, err := b.client.Do(req)
-	b.require.NoError(err)
-
-	// Create a map for quick cookie search
-	cookieMap := make(map[string]*http.Cookie)
-
-	// Add all cookies from the server response
-	for _, c := range response.Cookies() {
-		cookieMap[c.Name] = c
-	}
-
-	// Add only those cookies from `cookies` that are not yet in `cookieMap`
-	if cookies != nil && len(cookies) != 0 {
-		for _, c := range cookies {
-			if _, exists := cookieMap[c.Name]; !exists {
-				cookieMap[c.Name] = c
-			}
-		}
-	}
-
-	// Convert the map back to a slice
-	allCookies := make([]*http.Cookie, 0, len(cookieMap))
-	for _, c := range cookieMap {
-		allCookies = append(allCookies, c)
-	}
-
-	return response, allCookies
+	response := b.do(t, req)
 
+	return response, mergeCookies(response, cookies)
 }
 
 // RequestWithoutBody sends a request without a body to the specified endpoint.
@@ -193,12 +547,17 @@ func (b *Builder) RequestWithoutBody(
 	endpoint string,
 	headers map[string]string,
 	cookies []*http.Cookie,
-	authorization string) (*http.Response, []*http.Cookie) {
+	authorization string,
+	opts ...Option) (*http.Response, []*http.Cookie) {
 	t.Helper()
 
-	req, err := http.NewRequestWithContext(ctx, method, host+endpoint, nil)
+	if len(opts) != 0 {
+		b = b.With(opts...)
+	}
+
+	req, err := newRequest(ctx, method, host+endpoint, nil)
 	if err != nil {
-		t.Log(err)
+		b.logError(t, err)
 	}
 
 	b.require.NoError(err)
@@ -211,7 +570,7 @@ func (b *Builder) RequestWithoutBody(
 
 	if cookies != nil && len(cookies) != 0 {
 		for _, cookie := range cookies {
-			req.AddCookie(cookie)
+			b.addCookie(req, cookie)
 		}
 	}
 
@@ -219,32 +578,9 @@ func (b *Builder) RequestWithoutBody(
 		req.Header.Set("Authorization", authorization)
 	}
 
-	response, err := b.client.Do(req)
-	if err != nil {
-		t.Log(err)
-		b.require.NoError(err)
-	}
-
-	cookieMap := make(map[string]*http.Cookie)
-
-	for _, c := range response.Cookies() {
-		cookieMap[c.Name] = c
-	}
-
-	if cookies != nil && len(cookies) != 0 {
-		for _, c := range cookies {
-			if _, exists := cookieMap[c.Name]; !exists {
-				cookieMap[c.Name] = c
-			}
-		}
-	}
-
-	allCookies := make([]*http.Cookie, 0, len(cookieMap))
-	for _, c := range cookieMap {
-		allCookies = append(allCookies, c)
-	}
+	response := b.do(t, req)
 
-	return response, allCookies
+	return response, mergeCookies(response, cookies)
 }
 
 // SignIn sends a request to the specified endpoint and returns the response and cookies.
@@ -255,12 +591,17 @@ func (b *Builder) SignIn(
 	host,
 	endpoint string,
 	requestBody []byte,
-	headers map[string]string) (*http.Response, []*http.Cookie) {
+	headers map[string]string,
+	opts ...Option) (*http.Response, []*http.Cookie) {
 	t.Helper()
 
-	req, err := http.NewRequestWithContext(ctx, method, host+endpoint, bytes.NewReader(requestBody))
+	if len(opts) != 0 {
+		b = b.With(opts...)
+	}
+
+	req, err := newRequest(ctx, method, host+endpoint, bytes.NewReader(requestBody))
 	if err != nil {
-		t.Log(err)
+		b.logError(t, err)
 	}
 
 	b.require.NoError(err)
@@ -271,11 +612,7 @@ func (b *Builder) SignIn(
 		}
 	}
 
-	response, err := b.client.Do(req)
-	if err != nil {
-		t.Log(err)
-		b.require.NoError(err)
-	}
+	response := b.do(t, req)
 
 	return response, response.Cookies()
 }
@@ -314,36 +651,51 @@ func (b *Builder) GetHeadersFirstly(response *http.Response, keys []string) map[
 	return m
 }
 
-// ReadResponseBody decodes the response body and returns it as a byte slice.
-func (b *Builder) ReadResponseBody(response *http.Response) ([]byte, error) {
-	var reader io.ReadCloser
-	var err error
+// CookiesFor returns the named cookies set by the response, ready to be sent with the next request.
+func (b *Builder) CookiesFor(response *http.Response, names ...string) []*http.Cookie {
+	cookies := make([]*http.Cookie, 0, len(names))
 
-	switch response.Header.Get("Content-Encoding") {
-	case "gzip":
-		reader, err = gzip.NewReader(response.Body)
-		if err != nil {
-			return nil, err
-		}
-		defer reader.Close()
-	case "br":
-		reader = BrotliReadCloser{
-			Reader: brotli.NewReader(response.Body),
-			Closer: response.Body,
-		}
-	case "zstd":
-		decoder, err := zstd.NewReader(response.Body)
-		if err != nil {
-			return nil, err
+	if response == nil {
+		return cookies
+	}
+
+	responseCookies := response.Cookies()
+
+	for _, name := range names {
+		for _, c := range responseCookies {
+			if c.Name == name {
+				cookies = append(cookies, c)
+				break
+			}
 		}
-		defer decoder.Close()
-		reader = io.NopCloser(decoder)
-	case "deflate":
-		reader = flate.NewReader(response.Body)
-		defer reader.Close()
-	default:
-		reader = response.Body
 	}
 
-	return io.ReadAll(reader)
+	return cookies
+}
+
+// ReadResponseBody decodes the response body and returns it as a byte slice. Bodies
+// over the limit set with WithMaxResponseBytes return a *ResponseTooLargeError.
+// Encrypted bodies are decrypted, see WithResponseDecryptor. A buffered body, see
+// WithBodyReplay, is read from the start and stays readable.
+func (b *Builder) ReadResponseBody(response *http.Response) ([]byte, error) {
+	body, _, err := b.readBody(response, true)
+
+	return body, err
+}
+
+// readBody decodes, and if configured decrypts, the response body and returns it with
+// its content type, copying it to the body tee if tee is set.
+func (b *Builder) readBody(response *http.Response, tee bool) ([]byte, string, error) {
+	reader, err := b.decodedReader(response, tee)
+	if err != nil {
+		return nil, "", err
+	}
+	defer reader.Close()
+
+	body, err := b.readLimited(response, reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return b.decrypt(response.Header.Get("Content-Type"), body)
 }
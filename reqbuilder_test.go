@@ -0,0 +1,86 @@
+package reqbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records assertion failures instead of failing the test.
+type recorder struct {
+	failed bool
+	msg    string
+}
+
+// errFailNow unwinds a function whose assertion failed against a recorder.
+var errFailNow = fmt.Errorf("FailNow")
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed = true
+	r.msg += fmt.Sprintf(format, args...)
+}
+
+func (r *recorder) FailNow() {
+	panic(errFailNow)
+}
+
+// failure runs fn with a Builder whose assertions are recorded instead of failing t
+// and returns the failure message. t fails if fn does not fail.
+func failure(t *testing.T, opts []Option, fn func(b *Builder)) string {
+	t.Helper()
+
+	rec := &recorder{}
+	func() {
+		defer func() {
+			if r := recover(); r != nil && r != errFailNow {
+				panic(r)
+			}
+		}()
+		fn(New(require.New(rec), opts...))
+	}()
+
+	require.True(t, rec.failed, "expected an assertion failure")
+
+	return rec.msg
+}
+
+// newServer starts an httptest server running handler, closed when t finishes.
+func newServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestCookiesFor(t *testing.T) {
+	var sent []*http.Cookie
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+			http.SetCookie(w, &http.Cookie{Name: "csrf", Value: "c1"})
+			http.SetCookie(w, &http.Cookie{Name: "tracking", Value: "t1"})
+			return
+		}
+		sent = r.Cookies()
+	})
+
+	b := New(require.New(t))
+	ctx := context.Background()
+
+	login, _ := b.RequestWithoutBody(t, ctx, http.MethodPost, server.URL, "/login", nil, nil, "")
+	cookies := b.CookiesFor(login, "session", "csrf", "missing")
+	require.Len(t, cookies, 2)
+
+	b.RequestWithoutBody(t, ctx, http.MethodGet, server.URL, "/me", nil, cookies, "")
+	require.Len(t, sent, 2)
+	require.Equal(t, "session", sent[0].Name)
+	require.Equal(t, "s1", sent[0].Value)
+	require.Equal(t, "csrf", sent[1].Name)
+	require.Equal(t, "c1", sent[1].Value)
+}
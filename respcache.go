@@ -0,0 +1,174 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// responseCache holds decoded responses to GET and HEAD requests.
+type responseCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+// cachedResponse is a stored response with its decoded body.
+type cachedResponse struct {
+	status     int
+	proto      string
+	protoMajor int
+	protoMinor int
+	header     http.Header
+	body       []byte
+	expires    time.Time
+
+	// vary holds the request header values named by the response's Vary header.
+	vary http.Header
+}
+
+// WithResponseCache serves repeated GET and HEAD requests for the same URL from memory
+// for ttl instead of sending them again, for suites that keep fetching slowly changing
+// reference data. Requests with different Authorization or Cookie headers, or different
+// values of the headers the response lists in Vary, are cached separately. Responses
+// are stored with their body decoded; 5xx responses and responses marked
+// Cache-Control: no-store or Vary: * are not stored. The cache is shared by the Builder
+// and the copies made from it.
+func WithResponseCache(ttl time.Duration) Option {
+	return func(b *Builder) {
+		b.responseCache = &responseCache{ttl: ttl, entries: map[string]cachedResponse{}}
+	}
+}
+
+// cacheRoundTripper answers requests from a responseCache before sending them through next.
+type cacheRoundTripper struct {
+	cache *responseCache
+	next  http.RoundTripper
+	clock Clock
+	zstd  *zstdPool
+}
+
+func (rt *cacheRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return rt.next.RoundTrip(req)
+	}
+
+	// The credentials are part of the key so that one user's response is never served
+	// to another.
+	key := req.Method + " " + req.URL.String() +
+		"\nAuthorization: " + strings.Join(req.Header.Values("Authorization"), ", ") +
+		"\nCookie: " + strings.Join(req.Header.Values("Cookie"), "; ")
+	now := rt.clock.Now()
+
+	rt.cache.mu.Lock()
+	entry, ok := rt.cache.entries[key]
+	rt.cache.mu.Unlock()
+
+	if ok && now.Before(entry.expires) && entry.matches(req) {
+		return entry.response(req), nil
+	}
+
+	response, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return response, err
+	}
+
+	cc, _ := ParseCacheControl(response)
+	vary := varyHeaders(response)
+	if response.StatusCode >= 500 || cc.NoStore || slices.Contains(vary, "*") {
+		return response, nil
+	}
+
+	raw, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	body := raw
+	if encoding := response.Header.Get("Content-Encoding"); encoding != "" {
+		decoder, err := rt.zstd.decoder(encoding, bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		body, err = io.ReadAll(decoder)
+		decoder.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entry = cachedResponse{
+		status:     response.StatusCode,
+		proto:      response.Proto,
+		protoMajor: response.ProtoMajor,
+		protoMinor: response.ProtoMinor,
+		header:     response.Header.Clone(),
+		body:       body,
+		expires:    now.Add(rt.cache.ttl),
+		vary:       http.Header{},
+	}
+	for _, name := range vary {
+		entry.vary[name] = req.Header.Values(name)
+	}
+	entry.header.Del("Content-Encoding")
+	entry.header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	rt.cache.mu.Lock()
+	for k, stored := range rt.cache.entries {
+		if !now.Before(stored.expires) {
+			delete(rt.cache.entries, k)
+		}
+	}
+	rt.cache.entries[key] = entry
+	rt.cache.mu.Unlock()
+
+	return entry.response(req), nil
+}
+
+// varyHeaders returns the canonical header names listed in the response's Vary header.
+func varyHeaders(response *http.Response) []string {
+	var names []string
+	for _, value := range response.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	return names
+}
+
+// matches reports whether req has the header values the entry was stored for.
+func (e cachedResponse) matches(req *http.Request) bool {
+	for name, values := range e.vary {
+		if !slices.Equal(req.Header.Values(name), values) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// response returns a fresh *http.Response for the stored entry.
+func (e cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(e.status) + " " + http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         e.proto,
+		ProtoMajor:    e.protoMajor,
+		ProtoMinor:    e.protoMinor,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Uncompressed:  true,
+		Request:       req,
+	}
+}
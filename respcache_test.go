@@ -0,0 +1,89 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithResponseCache(t *testing.T) {
+	var calls atomic.Int32
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte("reference"))
+	})
+	clock := NewFakeClock(time.Unix(0, 0))
+
+	b := New(require.New(t), WithResponseCache(time.Minute), WithClock(clock))
+	get := func() string {
+		response, _ := b.RequestWithoutBody(t, context.Background(), http.MethodGet, server.URL, "/countries", nil, nil, "")
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		return string(b.requireBody(response))
+	}
+
+	require.Equal(t, "reference", get())
+	require.Equal(t, "reference", get())
+	require.EqualValues(t, 1, calls.Load(), "second GET within the TTL must be served from the cache")
+
+	clock.Advance(time.Minute)
+	require.Equal(t, "reference", get())
+	require.EqualValues(t, 2, calls.Load(), "GET after the TTL must reach the server")
+}
+
+func TestWithResponseCacheNoStore(t *testing.T) {
+	var calls atomic.Int32
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "no-store")
+	})
+
+	b := New(require.New(t), WithResponseCache(time.Minute))
+	for range 2 {
+		b.RequestWithoutBody(t, context.Background(), http.MethodGet, server.URL, "/", nil, nil, "")
+	}
+
+	require.EqualValues(t, 2, calls.Load())
+}
+
+func TestWithResponseCacheCredentialsAndVary(t *testing.T) {
+	var calls atomic.Int32
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("Cookie") + "|" + r.Header.Get("Accept-Language")))
+	})
+
+	b := New(require.New(t), WithResponseCache(time.Minute))
+	get := func(spec *RequestSpec) string {
+		response, _ := b.Send(t, context.Background(), spec)
+		return string(b.requireBody(response))
+	}
+
+	require.Equal(t, "Bearer a||", get(Get(server.URL).SetHeader("Authorization", "Bearer a")))
+	require.Equal(t, "Bearer b||", get(Get(server.URL).SetHeader("Authorization", "Bearer b")))
+	require.Equal(t, "|session=1|", get(Get(server.URL).SetHeader("Cookie", "session=1")))
+	require.Equal(t, "||de", get(Get(server.URL).SetHeader("Accept-Language", "de")))
+	require.Equal(t, "||en", get(Get(server.URL).SetHeader("Accept-Language", "en")))
+	require.EqualValues(t, 5, calls.Load(), "each credential and Vary variant reaches the server")
+
+	require.Equal(t, "Bearer b||", get(Get(server.URL).SetHeader("Authorization", "Bearer b")))
+	require.EqualValues(t, 5, calls.Load(), "a repeated variant is served from the cache")
+}
+
+func TestWithResponseCacheEvictsExpired(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	clock := NewFakeClock(time.Unix(0, 0))
+
+	b := New(require.New(t), WithResponseCache(time.Minute), WithClock(clock))
+	for _, path := range []string{"/a", "/b"} {
+		b.Send(t, context.Background(), Get(server.URL+path))
+	}
+	clock.Advance(time.Minute)
+	b.Send(t, context.Background(), Get(server.URL+"/c"))
+
+	require.Len(t, b.responseCache.entries, 1, "expired entries are dropped when a new one is stored")
+}
@@ -0,0 +1,229 @@
+package reqbuilder
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ConnInfo describes the connection a request was sent over.
+type ConnInfo struct {
+	Reused     bool
+	WasIdle    bool
+	IdleTime   time.Duration
+	LocalAddr  net.Addr
+	RemoteAddr net.Addr
+}
+
+// Response wraps an *http.Response sent by a Builder with details collected while sending it.
+type Response struct {
+	*http.Response
+	meta *requestMeta
+}
+
+// Wrap returns the wrapper for a response sent by a Builder. For other responses the
+// wrapper carries no details and its accessors report that.
+func Wrap(response *http.Response) *Response {
+	return &Response{Response: response, meta: metaOf(response)}
+}
+
+// ConnInfo returns the connection the final response was received on.
+// ok is false when no connection was observed, e.g. for responses not sent by a Builder.
+func (r *Response) ConnInfo() (info ConnInfo, ok bool) {
+	if r.meta == nil {
+		return ConnInfo{}, false
+	}
+
+	r.meta.mu.Lock()
+	defer r.meta.mu.Unlock()
+
+	if r.meta.conn == nil {
+		return ConnInfo{}, false
+	}
+
+	return *r.meta.conn, true
+}
+
+// ConnClosed reports whether the connection the response was received on has been
+// closed. With WithConnectionClose or WithHTTP10 that happens once the body has been
+// read to the end or closed. ok is false when the connection was not observed, e.g.
+// for responses not sent by a Builder or with a custom DialContext.
+func (r *Response) ConnClosed() (closed, ok bool) {
+	if r.meta == nil {
+		return false, false
+	}
+
+	r.meta.mu.Lock()
+	defer r.meta.mu.Unlock()
+
+	if r.meta.counted == nil {
+		return false, false
+	}
+
+	return r.meta.counted.closed.Load(), true
+}
+
+// requestMetaKey is the context key requestMeta is stored under.
+type requestMetaKey struct{}
+
+// requestMeta collects details about a request while it is being sent.
+type requestMeta struct {
+	// b is the Builder that sent the request, which decodes its body.
+	b *Builder
+
+	mu     sync.Mutex
+	conn   *ConnInfo
+	body   []byte
+	timing timingTrace
+	info   []InfoResponse
+
+	// counted is the connection the response was received on, if it was dialed
+	// through countDials.
+	counted *countedConn
+	tee     io.Writer
+}
+
+// metaOf returns the details recorded for a response sent by a Builder, or nil.
+func metaOf(response *http.Response) *requestMeta {
+	if response == nil || response.Request == nil {
+		return nil
+	}
+
+	meta, _ := response.Request.Context().Value(requestMetaKey{}).(*requestMeta)
+
+	return meta
+}
+
+// connCounter counts the connections used by a Builder and its copies.
+type connCounter struct {
+	created atomic.Int64
+	reused  atomic.Int64
+	opened  atomic.Int64
+	closed  atomic.Int64
+}
+
+// ConnStats describes the connection pool usage of a Builder and the copies made from it.
+type ConnStats struct {
+	// Opened and Closed count connections dialed and closed by the transport.
+	Opened int
+	Closed int
+	// Open is the number of connections currently open, idle or in use.
+	Open int
+	// Reused counts requests sent over a pooled connection.
+	Reused int
+}
+
+// ConnStats returns the connection pool counters of the Builder.
+func (b *Builder) ConnStats() ConnStats {
+	opened, closed := int(b.conns.opened.Load()), int(b.conns.closed.Load())
+
+	return ConnStats{Opened: opened, Closed: closed, Open: opened - closed, Reused: int(b.conns.reused.Load())}
+}
+
+// countDials wraps dial so that the connections it opens and closes are counted.
+func (b *Builder) countDials(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	conns := b.conns
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		conns.opened.Add(1)
+
+		return &countedConn{Conn: conn, conns: conns}, nil
+	}
+}
+
+// countedConn counts its Close.
+type countedConn struct {
+	net.Conn
+	conns  *connCounter
+	closed atomic.Bool
+}
+
+func (c *countedConn) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		c.conns.closed.Add(1)
+	}
+
+	return c.Conn.Close()
+}
+
+// countedConnOf returns the countedConn under conn, looking through TLS, or nil.
+func countedConnOf(conn net.Conn) *countedConn {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+
+	counted, _ := conn.(*countedConn)
+
+	return counted
+}
+
+// ConnReuseStats returns how many requests were sent over a new connection and how
+// many reused a pooled one since the Builder was created.
+func (b *Builder) ConnReuseStats() (created, reused int) {
+	return int(b.conns.created.Load()), int(b.conns.reused.Load())
+}
+
+// trace returns a client trace recording connection details and timings into meta.
+func (b *Builder) trace(meta *requestMeta) *httptrace.ClientTrace {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			conn := &ConnInfo{
+				Reused:   info.Reused,
+				WasIdle:  info.WasIdle,
+				IdleTime: info.IdleTime,
+			}
+			if info.Conn != nil {
+				conn.LocalAddr = info.Conn.LocalAddr()
+				conn.RemoteAddr = info.Conn.RemoteAddr()
+			}
+			counted := countedConnOf(info.Conn)
+
+			if info.Reused {
+				b.conns.reused.Add(1)
+			} else {
+				b.conns.created.Add(1)
+			}
+
+			meta.mu.Lock()
+			meta.conn = conn
+			meta.counted = counted
+			if info.Reused {
+				meta.timing.timings = Timings{Reused: true}
+			}
+			meta.mu.Unlock()
+		},
+	}
+	b.timingHooks(meta, trace)
+	informationalHooks(meta, trace)
+
+	return trace
+}
+
+// ExpectConnectionReused fails the test unless the response was received on a pooled connection.
+func (b *Builder) ExpectConnectionReused(t *testing.T, response *http.Response) {
+	t.Helper()
+
+	info, ok := Wrap(response).ConnInfo()
+	b.require.True(ok, "no connection info recorded for the response")
+	b.require.Truef(info.Reused, "expected a reused connection, got a new one to %v", info.RemoteAddr)
+}
+
+// ExpectNewConnection fails the test unless the response was received on a newly dialed connection.
+func (b *Builder) ExpectNewConnection(t *testing.T, response *http.Response) {
+	t.Helper()
+
+	info, ok := Wrap(response).ConnInfo()
+	b.require.True(ok, "no connection info recorded for the response")
+	b.require.Falsef(info.Reused, "expected a new connection, got one reused from the pool (%v)", info.LocalAddr)
+}
@@ -0,0 +1,92 @@
+package reqbuilder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionReuse(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }
+	plain := newServer(t, handler)
+	secure := httptest.NewTLSServer(http.HandlerFunc(handler))
+	t.Cleanup(secure.Close)
+
+	for name, server := range map[string]*httptest.Server{"http": plain, "https": secure} {
+		t.Run(name, func(t *testing.T) {
+			b := New(require.New(t), WithTLSConfig(secure.Client().Transport.(*http.Transport).TLSClientConfig))
+			ctx := context.Background()
+
+			for i := range 10 {
+				response, _ := b.Send(t, ctx, Get(server.URL))
+				io.Copy(io.Discard, response.Body)
+				response.Body.Close()
+
+				if i == 0 {
+					b.ExpectNewConnection(t, response)
+				} else {
+					b.ExpectConnectionReused(t, response)
+				}
+			}
+
+			created, reused := b.ConnReuseStats()
+			require.Equal(t, 1, created)
+			require.Equal(t, 9, reused)
+		})
+	}
+}
+
+func TestConnectionCloseChurn(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	b := New(require.New(t), WithConnectionClose())
+
+	for range 3 {
+		response, _ := b.Send(t, context.Background(), Get(server.URL))
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+		b.ExpectNewConnection(t, response)
+
+		info, ok := Wrap(response).ConnInfo()
+		require.True(t, ok)
+		require.NotNil(t, info.LocalAddr)
+		require.Equal(t, server.Listener.Addr().String(), info.RemoteAddr.String())
+	}
+
+	created, reused := b.ConnReuseStats()
+	require.Equal(t, 3, created)
+	require.Zero(t, reused)
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.ExpectConnectionReused(t, &http.Response{})
+	})
+	require.Contains(t, msg, "no connection info recorded")
+}
+
+func TestConnStats(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+
+	b := New(require.New(t))
+	for range 5 {
+		response, _ := b.Send(t, context.Background(), Get(server.URL))
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+	}
+
+	require.Equal(t, ConnStats{Opened: 1, Open: 1, Reused: 4}, b.ConnStats(), "keep-alive requests must share one connection")
+
+	churn := New(require.New(t), WithConnectionClose())
+	for range 3 {
+		response, _ := churn.Send(t, context.Background(), Get(server.URL))
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+	}
+
+	require.Eventually(t, func() bool {
+		return churn.ConnStats() == ConnStats{Opened: 3, Closed: 3}
+	}, time.Second, 10*time.Millisecond, "got %+v", churn.ConnStats())
+}
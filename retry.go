@@ -0,0 +1,258 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// retryPolicy configures how failed requests are retried.
+type retryPolicy struct {
+	maxRetries int
+	backoff    time.Duration
+}
+
+// WithRetry retries requests that fail with a transport error or a 5xx status up to
+// maxRetries times, waiting backoff, 2*backoff, 4*backoff, ... between attempts.
+// Request bodies are replayed through Request.GetBody.
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(b *Builder) {
+		b.retry = &retryPolicy{maxRetries: maxRetries, backoff: backoff}
+	}
+}
+
+// defaultRetryPolicy limits retries asked for by WithRetryPredicate when WithRetry
+// is not set.
+var defaultRetryPolicy = &retryPolicy{maxRetries: 3, backoff: 100 * time.Millisecond}
+
+// retryPolicy returns the WithRetry policy, or the default one for retry predicates.
+func (b *Builder) retryPolicy() *retryPolicy {
+	if b.retry != nil {
+		return b.retry
+	}
+
+	return defaultRetryPolicy
+}
+
+// RetryJitter selects how retry backoff is randomized.
+type RetryJitter int
+
+const (
+	// NoJitter waits exactly the exponential backoff.
+	NoJitter RetryJitter = iota
+	// FullJitter waits a random duration in [0, backoff).
+	FullJitter
+	// EqualJitter waits half the backoff plus a random duration in [0, backoff/2).
+	EqualJitter
+)
+
+// WithRetryJitter randomizes the exponential backoff of WithRetry, so parallel tests
+// retrying against the same server do not retry in lockstep.
+func WithRetryJitter(jitter RetryJitter) Option {
+	return func(b *Builder) {
+		b.retryJitter = jitter
+	}
+}
+
+// WithRetryJitterSeed draws the jitter of WithRetryJitter from a source seeded with
+// seed instead of the global one, so the waits of a run can be reproduced.
+func WithRetryJitterSeed(seed uint64) Option {
+	return func(b *Builder) {
+		b.jitterRand = &lockedRand{rand: rand.New(rand.NewPCG(seed, seed))}
+	}
+}
+
+// lockedRand is a random source safe for use by parallel tests sharing a Builder.
+type lockedRand struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// duration returns a random duration in [0, n).
+func (r *lockedRand) duration(n time.Duration) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return time.Duration(r.rand.Int64N(int64(n)))
+}
+
+// delay returns the wait before retry number attempt (starting at 0). Waits that
+// would overflow are clamped to the longest representable duration.
+func (p *retryPolicy) delay(attempt int) time.Duration {
+	if p.backoff <= 0 {
+		return p.backoff
+	}
+	if attempt >= 63 || p.backoff > math.MaxInt64>>attempt {
+		return math.MaxInt64
+	}
+
+	return p.backoff << attempt
+}
+
+// jitter returns a random duration in [0, n).
+func (b *Builder) jitter(n time.Duration) time.Duration {
+	if b.jitterRand != nil {
+		return b.jitterRand.duration(n)
+	}
+
+	return rand.N(n)
+}
+
+// retryDelay returns the wait before retry number attempt with the Builder's jitter applied.
+func (b *Builder) retryDelay(attempt int) time.Duration {
+	backoff := b.retryPolicy().delay(attempt)
+	if backoff <= 0 {
+		return backoff
+	}
+
+	switch b.retryJitter {
+	case FullJitter:
+		return b.jitter(backoff)
+	case EqualJitter:
+		half := backoff / 2
+		return half + b.jitter(backoff-half)
+	default:
+		return backoff
+	}
+}
+
+// shouldRetry reports whether an attempt's outcome is worth retrying.
+func (p *retryPolicy) shouldRetry(response *http.Response, err error) bool {
+	return err != nil || response.StatusCode >= 500
+}
+
+// send sends req, retrying according to the Builder's retry policy.
+func (b *Builder) send(req *http.Request) (*http.Response, error) {
+	client := b.httpClient()
+
+	if b.retry == nil && b.retryPredicate == nil {
+		return client.Do(req)
+	}
+
+	ctx := req.Context()
+	noRetry := false
+	for _, key := range b.noRetryKeys {
+		if ctx.Value(key) != nil {
+			noRetry = true
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		response, err := client.Do(attemptReq)
+		if noRetry || attempt >= b.retryPolicy().maxRetries {
+			return response, err
+		}
+
+		retry, wait := b.shouldRetry(response, err, attempt)
+		if !retry {
+			return response, err
+		}
+
+		if response != nil {
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+
+		if err := b.clock.Sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// shouldRetry decides whether to retry after an attempt and how long to wait first.
+func (b *Builder) shouldRetry(response *http.Response, err error, attempt int) (bool, time.Duration) {
+	if b.retryPredicate == nil {
+		return b.retry.shouldRetry(response, err), b.retryDelay(attempt)
+	}
+
+	if response != nil {
+		response = peekResponse(response, retryPeekLimit)
+	}
+
+	retry, wait := b.retryPredicate(response, err, attempt)
+	if retry && wait == 0 {
+		wait = b.retryDelay(attempt)
+	}
+
+	return retry, wait
+}
+
+// retryPeekLimit is how much of a response body retry predicates can see.
+const retryPeekLimit = 64 << 10
+
+// peekResponse returns a copy of response whose body holds at most limit bytes of the
+// original body. The bytes read are put back in front of the original body.
+func peekResponse(response *http.Response, limit int64) *http.Response {
+	prefix, _ := io.ReadAll(io.LimitReader(response.Body, limit))
+	response.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), response.Body), response.Body}
+
+	copied := *response
+	copied.Body = io.NopCloser(bytes.NewReader(prefix))
+
+	return &copied
+}
+
+// RetryPredicate decides after each attempt whether to retry and how long to wait
+// first; a zero wait uses the WithRetry backoff. response is nil when err is not, and
+// its body is a copy of at most the first 64KB of the real body.
+type RetryPredicate func(response *http.Response, err error, attempt int) (retry bool, wait time.Duration)
+
+// WithRetryPredicate replaces the "transport errors and 5xx" rule of WithRetry with
+// predicates: a request is retried when any of them asks for it, after the longest of
+// their waits. The number of retries and the backoff used for a zero wait come from
+// WithRetry, or are 3 retries starting at 100ms without it. As a per-request option it
+// overrides the Builder's predicates.
+func WithRetryPredicate(predicates ...RetryPredicate) Option {
+	return func(b *Builder) {
+		b.retryPredicate = func(response *http.Response, err error, attempt int) (bool, time.Duration) {
+			retry, wait := false, time.Duration(0)
+			for _, predicate := range predicates {
+				if r, w := predicate(response, err, attempt); r {
+					retry, wait = true, max(wait, w)
+				}
+			}
+			return retry, wait
+		}
+	}
+}
+
+// RetryStatuses retries responses with one of the given status codes.
+func RetryStatuses(codes ...int) RetryPredicate {
+	return func(response *http.Response, _ error, _ int) (bool, time.Duration) {
+		return response != nil && slices.Contains(codes, response.StatusCode), 0
+	}
+}
+
+// RetryTransportErrors retries requests that failed without a response.
+func RetryTransportErrors() RetryPredicate {
+	return func(_ *http.Response, err error, _ int) (bool, time.Duration) {
+		return err != nil, 0
+	}
+}
+
+// NoRetryFor disables retries for requests whose context carries a value for key.
+func NoRetryFor(key any) Option {
+	return func(b *Builder) {
+		b.noRetryKeys = append(b.noRetryKeys[:len(b.noRetryKeys):len(b.noRetryKeys)], key)
+	}
+}
@@ -0,0 +1,180 @@
+package reqbuilder
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sleepRecorder is a Clock whose Sleep returns at once, recording the duration.
+type sleepRecorder struct {
+	realClock
+	sleeps []time.Duration
+}
+
+func (c *sleepRecorder) Sleep(ctx context.Context, d time.Duration) error {
+	c.sleeps = append(c.sleeps, d)
+
+	return ctx.Err()
+}
+
+// flakyServer fails the first failures requests with status and then succeeds.
+func flakyServer(t *testing.T, failures int32, status int) (*atomic.Int32, string) {
+	var calls atomic.Int32
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+		}
+	})
+
+	return &calls, server.URL
+}
+
+func TestWithRetryBackoff(t *testing.T) {
+	calls, url := flakyServer(t, 3, http.StatusServiceUnavailable)
+	clock := &sleepRecorder{}
+
+	b := New(require.New(t), WithRetry(5, 100*time.Millisecond), WithClock(clock))
+
+	start := time.Now()
+	response, _ := b.Send(t, context.Background(), Get(url))
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.EqualValues(t, 4, calls.Load())
+	require.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}, clock.sleeps)
+	require.Less(t, time.Since(start), 100*time.Millisecond, "backoff must not sleep for real")
+}
+
+func TestWithRetryGivesUp(t *testing.T) {
+	calls, url := flakyServer(t, 10, http.StatusBadGateway)
+	clock := &sleepRecorder{}
+
+	b := New(require.New(t), WithRetry(2, time.Second), WithClock(clock))
+	response, _ := b.Send(t, context.Background(), Get(url))
+
+	require.Equal(t, http.StatusBadGateway, response.StatusCode)
+	require.EqualValues(t, 3, calls.Load())
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second}, clock.sleeps)
+}
+
+func TestWithRetryJitter(t *testing.T) {
+	backoffs := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond}
+
+	for _, tt := range []struct {
+		name   string
+		jitter RetryJitter
+		lower  func(backoff time.Duration) time.Duration
+	}{
+		{"full", FullJitter, func(time.Duration) time.Duration { return 0 }},
+		{"equal", EqualJitter, func(backoff time.Duration) time.Duration { return backoff / 2 }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			run := func(seed uint64) []time.Duration {
+				_, url := flakyServer(t, 4, http.StatusServiceUnavailable)
+				clock := &sleepRecorder{}
+				b := New(require.New(t), WithRetry(4, 100*time.Millisecond), WithRetryJitter(tt.jitter),
+					WithRetryJitterSeed(seed), WithClock(clock))
+				b.Send(t, context.Background(), Get(url))
+				return clock.sleeps
+			}
+
+			sleeps := run(1)
+			require.Len(t, sleeps, len(backoffs))
+			for i, backoff := range backoffs {
+				require.GreaterOrEqual(t, sleeps[i], tt.lower(backoff))
+				require.Less(t, sleeps[i], backoff)
+			}
+			require.NotEqual(t, backoffs, sleeps)
+
+			require.Equal(t, sleeps, run(1), "the same seed gives the same waits")
+			require.NotEqual(t, sleeps, run(2))
+		})
+	}
+}
+
+func TestRetryDelayOverflow(t *testing.T) {
+	p := &retryPolicy{backoff: time.Hour}
+	require.Equal(t, 8*time.Hour, p.delay(3))
+	require.Equal(t, time.Duration(math.MaxInt64), p.delay(40))
+	require.Equal(t, time.Duration(math.MaxInt64), p.delay(63))
+	require.Equal(t, time.Duration(math.MaxInt64), p.delay(200))
+
+	p = &retryPolicy{backoff: time.Nanosecond}
+	require.Equal(t, time.Duration(1<<62), p.delay(62))
+	require.Equal(t, time.Duration(math.MaxInt64), p.delay(63))
+
+	require.Zero(t, (&retryPolicy{}).delay(100))
+}
+
+func TestWithRetryPredicateDefaults(t *testing.T) {
+	calls, url := flakyServer(t, 10, http.StatusConflict)
+	clock := &sleepRecorder{}
+
+	b := New(require.New(t), WithRetryPredicate(RetryStatuses(http.StatusConflict)), WithClock(clock))
+	response, _ := b.Send(t, context.Background(), Get(url))
+
+	require.Equal(t, http.StatusConflict, response.StatusCode)
+	require.EqualValues(t, 4, calls.Load(), "without WithRetry a predicate is limited to 3 retries")
+	require.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}, clock.sleeps)
+}
+
+func TestWithRetryPredicatePerRequest(t *testing.T) {
+	calls, url := flakyServer(t, 1, http.StatusServiceUnavailable)
+	b := New(require.New(t), WithRetry(3, time.Second), WithClock(&sleepRecorder{}),
+		WithRetryPredicate(RetryStatuses(http.StatusServiceUnavailable)))
+
+	// The per-request predicate beats the Builder's, so the 503 is the assertion target.
+	response, _ := b.RequestWithoutBody(t, context.Background(), http.MethodGet, url, "/", nil, nil, "",
+		WithRetryPredicate(RetryStatuses(http.StatusConflict)))
+	require.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	require.EqualValues(t, 1, calls.Load())
+
+	response, _ = b.Send(t, context.Background(), Get(url))
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.EqualValues(t, 2, calls.Load())
+}
+
+func TestWithRetryPredicatePeeksBody(t *testing.T) {
+	large := strings.Repeat("x", 2*retryPeekLimit)
+	var calls atomic.Int32
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Write([]byte(`{"state":"pending"}`))
+			return
+		}
+		w.Write([]byte(`{"state":"done"}` + large))
+	})
+
+	var seen []int
+	pending := func(response *http.Response, err error, attempt int) (bool, time.Duration) {
+		body, _ := io.ReadAll(response.Body)
+		seen = append(seen, len(body))
+		return strings.Contains(string(body), "pending"), 0
+	}
+
+	b := New(require.New(t), WithRetryPredicate(pending), WithClock(&sleepRecorder{}))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	require.EqualValues(t, 2, calls.Load())
+	require.Equal(t, []int{len(`{"state":"pending"}`), retryPeekLimit}, seen, "predicates see at most the peek limit")
+	require.Equal(t, `{"state":"done"}`+large, string(b.requireBody(response)), "the caller gets the whole body")
+}
+
+func TestNoRetryFor(t *testing.T) {
+	type idempotencyKey struct{}
+	calls, url := flakyServer(t, 10, http.StatusBadGateway)
+
+	b := New(require.New(t), WithRetry(3, time.Second), WithClock(&sleepRecorder{}), NoRetryFor(idempotencyKey{}))
+	ctx := context.WithValue(context.Background(), idempotencyKey{}, true)
+	response, _ := b.Send(t, ctx, Get(url))
+
+	require.Equal(t, http.StatusBadGateway, response.StatusCode)
+	require.EqualValues(t, 1, calls.Load())
+}
@@ -0,0 +1,247 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a declarative request flow loaded from a YAML or JSON file:
+//
+//	name: signup
+//	vars:
+//	  email: jane@example.com
+//	steps:
+//	  - name: create account
+//	    method: POST
+//	    path: /accounts
+//	    headers:
+//	      X-Request-Source: tests
+//	    body:                      # inline YAML/JSON, sent as JSON
+//	      email: "{{email}}"
+//	    # body_file: signup.json   # or a file relative to the scenario
+//	    capture:
+//	      account_id: $.id         # JSONPath into the response body
+//	      location: header:Location
+//	    expect:
+//	      status: 201
+//	      json: {email: "{{email}}"}
+//	      headers:
+//	        Content-Type: application/json
+//
+// {{name}} placeholders in paths, headers and bodies are replaced by vars and earlier
+// captures. A string body is sent as is; any other body is encoded as JSON. The JSON
+// Schema is in testdata/scenarios/scenario.schema.json.
+type Scenario struct {
+	Name  string            `yaml:"name" json:"name"`
+	Vars  map[string]string `yaml:"vars" json:"vars"`
+	Steps []ScenarioStep    `yaml:"steps" json:"steps"`
+
+	path string
+}
+
+// ScenarioStep is one request of a Scenario.
+type ScenarioStep struct {
+	Name     string            `yaml:"name" json:"name"`
+	Method   string            `yaml:"method" json:"method"`
+	Path     string            `yaml:"path" json:"path"`
+	Headers  map[string]string `yaml:"headers" json:"headers"`
+	Body     any               `yaml:"body" json:"body"`
+	BodyFile string            `yaml:"body_file" json:"body_file"`
+	Capture  map[string]string `yaml:"capture" json:"capture"`
+	Expect   ScenarioExpect    `yaml:"expect" json:"expect"`
+
+	line int
+}
+
+// ScenarioExpect lists the checks made on a step's response.
+type ScenarioExpect struct {
+	Status  int               `yaml:"status" json:"status"`
+	JSON    any               `yaml:"json" json:"json"`
+	Headers map[string]string `yaml:"headers" json:"headers"`
+}
+
+// ParseScenario parses a scenario file. JSON is accepted as YAML. Errors name the file
+// and line; unknown fields are errors.
+func ParseScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	scenario := &Scenario{path: path}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(scenario); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	lines := stepLines(&root)
+	for i := range scenario.Steps {
+		step := &scenario.Steps[i]
+		if i < len(lines) {
+			step.line = lines[i]
+		}
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step %d", i+1)
+		}
+		if err := step.validate(); err != nil {
+			return nil, fmt.Errorf("%s:%d: step %q: %w", path, step.line, step.Name, err)
+		}
+	}
+
+	if len(scenario.Steps) == 0 {
+		return nil, fmt.Errorf("%s: no steps", path)
+	}
+
+	return scenario, nil
+}
+
+// stepLines returns the line of each entry of the top-level steps sequence.
+func stepLines(root *yaml.Node) []int {
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+
+	mapping := root.Content[0].Content
+	for i := 0; i+1 < len(mapping); i += 2 {
+		if mapping[i].Value != "steps" {
+			continue
+		}
+		var lines []int
+		for _, step := range mapping[i+1].Content {
+			lines = append(lines, step.Line)
+		}
+		return lines
+	}
+
+	return nil
+}
+
+func (s *ScenarioStep) validate() error {
+	if s.Method == "" {
+		return errors.New("method is required")
+	}
+	if s.Path == "" {
+		return errors.New("path is required")
+	}
+	if s.Body != nil && s.BodyFile != "" {
+		return errors.New("body and body_file are exclusive")
+	}
+
+	for name, source := range s.Capture {
+		if !strings.HasPrefix(source, "$") && !strings.HasPrefix(source, "header:") {
+			return fmt.Errorf("capture %q: %q is neither a JSONPath nor header:<name>", name, source)
+		}
+	}
+
+	return nil
+}
+
+// LoadScenario parses the scenario at path and runs it as a Flow, failing the test on
+// a parse error or a failing step. It returns the flow for inspecting captured
+// variables.
+func (b *Builder) LoadScenario(t *testing.T, ctx context.Context, path string) *Flow {
+	t.Helper()
+
+	scenario, err := ParseScenario(path)
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	flow, err := scenario.flow(b, t, ctx)
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	flow.Run()
+
+	return flow
+}
+
+// flow converts the scenario into a Flow.
+func (s *Scenario) flow(b *Builder, t *testing.T, ctx context.Context) (*Flow, error) {
+	flow := b.Flow(t, ctx)
+	for name, value := range s.Vars {
+		flow.Set(name, value)
+	}
+
+	for _, step := range s.Steps {
+		spec, err := s.spec(step)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: step %q: %w", s.path, step.line, step.Name, err)
+		}
+		flow.Step(fmt.Sprintf("%s (%s:%d)", step.Name, s.path, step.line), spec)
+	}
+
+	return flow, nil
+}
+
+// spec builds the RequestSpec for a step.
+func (s *Scenario) spec(step ScenarioStep) (*RequestSpec, error) {
+	spec := NewSpec(strings.ToUpper(step.Method), step.Path)
+	for key, value := range step.Headers {
+		spec.SetHeader(key, value)
+	}
+
+	switch {
+	case step.BodyFile != "":
+		body, err := os.ReadFile(filepath.Join(filepath.Dir(s.path), step.BodyFile))
+		if err != nil {
+			return nil, err
+		}
+		spec.Body = body
+		if spec.Headers.Get("Content-Type") == "" && json.Valid(body) {
+			spec.SetHeader("Content-Type", "application/json")
+		}
+	case step.Body != nil:
+		if text, ok := step.Body.(string); ok {
+			spec.Body = []byte(text)
+			break
+		}
+		body, err := json.Marshal(step.Body)
+		if err != nil {
+			return nil, fmt.Errorf("body: %w", err)
+		}
+		spec.Body = body
+		if spec.Headers.Get("Content-Type") == "" {
+			spec.SetHeader("Content-Type", "application/json")
+		}
+	}
+
+	for name, source := range step.Capture {
+		if header, ok := strings.CutPrefix(source, "header:"); ok {
+			spec.CaptureHeader(name, strings.TrimSpace(header))
+		} else {
+			spec.Capture(name, source)
+		}
+	}
+
+	if step.Expect.Status != 0 {
+		spec.ExpectStatus(step.Expect.Status)
+	}
+	if step.Expect.JSON != nil {
+		spec.ExpectJSON(step.Expect.JSON)
+	}
+	for key, value := range step.Expect.Headers {
+		spec.ExpectHeader(key, value)
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,97 @@
+package reqbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// signupServer implements the API that testdata/scenarios/signup.yaml exercises.
+func signupServer(t *testing.T) string {
+	var email string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /accounts", func(w http.ResponseWriter, r *http.Request) {
+		var account struct{ Email, Password string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&account))
+		email = account.Email
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Location", "/accounts/a1")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"id": "a1", "email": email})
+	})
+	mux.HandleFunc("POST /sessions", func(w http.ResponseWriter, r *http.Request) {
+		var login struct{ Email, Password string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&login))
+		if login.Email != email || login.Password != "correct horse battery staple" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": "t1"})
+	})
+	mux.HandleFunc("GET /accounts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"id": r.PathValue("id"), "email": email, "roles": []string{"member"}})
+	})
+
+	return newServer(t, mux.ServeHTTP).URL
+}
+
+func TestLoadScenario(t *testing.T) {
+	b := New(require.New(t), WithBaseURL(signupServer(t)))
+
+	flow := b.LoadScenario(t, context.Background(), "testdata/scenarios/signup.yaml")
+
+	require.Equal(t, "a1", flow.Var("account_id"))
+	require.Equal(t, "/accounts/a1", flow.Var("account_url"))
+	require.Equal(t, "t1", flow.Var("token"))
+}
+
+func TestLoadScenarioFailingStep(t *testing.T) {
+	url := signupServer(t)
+	path := filepath.Join(t.TempDir(), "wrong.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`name: wrong
+steps:
+  - name: create account
+    method: POST
+    path: /accounts
+    body: {email: ann@example.com}
+    expect:
+      headers:
+        Content-Type: text/html
+`), 0o644))
+
+	msg := failure(t, []Option{WithBaseURL(url)}, func(b *Builder) {
+		b.LoadScenario(t, context.Background(), path)
+	})
+
+	require.Contains(t, msg, `create account (`+path+`:3)`)
+	require.Contains(t, msg, `expected header Content-Type: text/html, got ["application/json; charset=utf-8"]`)
+	require.Contains(t, msg, "request: POST /accounts")
+}
+
+func TestParseScenarioErrors(t *testing.T) {
+	for name, tt := range map[string]struct{ yaml, want string }{
+		"no method":   {"steps:\n  - name: a\n  - name: b\n    path: /x\n", `:2: step "a": method is required`},
+		"bad capture": {"steps:\n  - method: GET\n    path: /x\n    capture: {id: id}\n", `:2: step "step 1": capture "id": "id" is neither a JSONPath nor header:<name>`},
+		"unknown key": {"steps:\n  - method: GET\n    pth: /x\n", "line 3: field pth not found"},
+		"empty":       {"name: x\n", "no steps"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scenario.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.yaml), 0o644))
+
+			_, err := ParseScenario(path)
+			require.ErrorContains(t, err, path)
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package reqbuilder
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// SecurityCheck names one of the checks made by ExpectSecurityHeaders.
+type SecurityCheck string
+
+const (
+	// SecurityHSTS requires Strict-Transport-Security with a long enough max-age.
+	SecurityHSTS SecurityCheck = "hsts"
+	// SecurityContentTypeOptions requires X-Content-Type-Options: nosniff.
+	SecurityContentTypeOptions SecurityCheck = "content-type-options"
+	// SecurityFrameOptions requires X-Frame-Options or a CSP frame-ancestors directive.
+	SecurityFrameOptions SecurityCheck = "frame-options"
+	// SecurityCSP requires the Content-Security-Policy directives listed in the policy.
+	SecurityCSP SecurityCheck = "csp"
+	// SecurityLeakage requires headers revealing server software to be absent.
+	SecurityLeakage SecurityCheck = "leakage"
+)
+
+// SecurityPolicy describes the response headers ExpectSecurityHeaders requires.
+// Zero fields take their value from DefaultSecurityPolicy.
+type SecurityPolicy struct {
+	// HSTSMinMaxAge is the smallest accepted Strict-Transport-Security max-age.
+	HSTSMinMaxAge time.Duration
+	// HSTSIncludeSubDomains additionally requires the includeSubDomains directive.
+	HSTSIncludeSubDomains bool
+	// FrameOptions are the accepted X-Frame-Options values.
+	FrameOptions []string
+	// CSPDirectives are directives the Content-Security-Policy must contain, such as
+	// "default-src 'self'". Every listed source must be present in the directive.
+	CSPDirectives []string
+	// ForbiddenHeaders must not be present at all.
+	ForbiddenHeaders []string
+	// Skip lists checks that are not made, for endpoints that legitimately differ,
+	// e.g. SecurityHSTS for a plain-HTTP health check.
+	Skip []SecurityCheck
+}
+
+// DefaultSecurityPolicy returns the policy used for zero SecurityPolicy fields: HSTS
+// for at least 180 days, nosniff, X-Frame-Options DENY or SAMEORIGIN, and no Server
+// or X-Powered-By headers. No CSP directives are required by default.
+func DefaultSecurityPolicy() SecurityPolicy {
+	return SecurityPolicy{
+		HSTSMinMaxAge:    180 * 24 * time.Hour,
+		FrameOptions:     []string{"DENY", "SAMEORIGIN"},
+		ForbiddenHeaders: []string{"Server", "X-Powered-By"},
+	}
+}
+
+// withDefaults fills zero fields from DefaultSecurityPolicy.
+func (p SecurityPolicy) withDefaults() SecurityPolicy {
+	defaults := DefaultSecurityPolicy()
+	if p.HSTSMinMaxAge == 0 {
+		p.HSTSMinMaxAge = defaults.HSTSMinMaxAge
+	}
+	if p.FrameOptions == nil {
+		p.FrameOptions = defaults.FrameOptions
+	}
+	if p.ForbiddenHeaders == nil {
+		p.ForbiddenHeaders = defaults.ForbiddenHeaders
+	}
+
+	return p
+}
+
+// ParseCSP splits a Content-Security-Policy header value into its directives, keyed by
+// lowercased directive name, each with its list of sources. Like browsers, only the
+// first occurrence of a repeated directive is kept.
+func ParseCSP(value string) map[string][]string {
+	directives := map[string][]string{}
+
+	for _, directive := range strings.Split(value, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+
+		name := strings.ToLower(fields[0])
+		if _, ok := directives[name]; !ok {
+			directives[name] = fields[1:]
+		}
+	}
+
+	return directives
+}
+
+// ExpectSecurityHeaders fails the test unless the response carries the security headers
+// required by policy. All violations are reported together, with the actual header values.
+func (b *Builder) ExpectSecurityHeaders(t *testing.T, response *http.Response, policy SecurityPolicy) {
+	t.Helper()
+
+	b.require.NotNil(response, "no response")
+
+	policy = policy.withDefaults()
+
+	var violations []string
+	check := func(name SecurityCheck, fn func() []string) {
+		if !slices.Contains(policy.Skip, name) {
+			violations = append(violations, fn()...)
+		}
+	}
+
+	header := response.Header
+	csp := header.Values("Content-Security-Policy")
+
+	check(SecurityHSTS, func() []string {
+		return checkHSTS(header.Values("Strict-Transport-Security"), policy)
+	})
+
+	check(SecurityContentTypeOptions, func() []string {
+		value := header.Get("X-Content-Type-Options")
+		if !strings.EqualFold(strings.TrimSpace(value), "nosniff") {
+			return []string{fmt.Sprintf("X-Content-Type-Options: expected nosniff, got %q", value)}
+		}
+		return nil
+	})
+
+	check(SecurityFrameOptions, func() []string {
+		value := header.Get("X-Frame-Options")
+		for _, accepted := range policy.FrameOptions {
+			if strings.EqualFold(strings.TrimSpace(value), accepted) {
+				return nil
+			}
+		}
+		for _, c := range csp {
+			if _, ok := ParseCSP(c)["frame-ancestors"]; ok {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("X-Frame-Options: expected one of %q or CSP frame-ancestors, got %q", policy.FrameOptions, value)}
+	})
+
+	check(SecurityCSP, func() []string {
+		var missing []string
+		for _, required := range policy.CSPDirectives {
+			if !cspSatisfies(csp, required) {
+				missing = append(missing, fmt.Sprintf("Content-Security-Policy: missing %q, got %q", required, csp))
+			}
+		}
+		return missing
+	})
+
+	check(SecurityLeakage, func() []string {
+		var leaked []string
+		for _, key := range policy.ForbiddenHeaders {
+			if values := header.Values(key); len(values) != 0 {
+				leaked = append(leaked, fmt.Sprintf("%s: expected absent, got %q", http.CanonicalHeaderKey(key), values))
+			}
+		}
+		return leaked
+	})
+
+	if len(violations) != 0 {
+		b.require.Failf("missing security headers", "%s\n\n%s", strings.Join(violations, "\n"), dumpHeader(header))
+	}
+}
+
+// checkHSTS validates Strict-Transport-Security values against policy.
+func checkHSTS(values []string, policy SecurityPolicy) []string {
+	if len(values) == 0 {
+		return []string{"Strict-Transport-Security: expected, got none"}
+	}
+
+	maxAge := -1
+	includeSubDomains := false
+
+	for _, directive := range strings.Split(values[0], ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "max-age":
+			if n, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				maxAge = n
+			}
+		case "includesubdomains":
+			includeSubDomains = true
+		}
+	}
+
+	var violations []string
+	if time.Duration(maxAge)*time.Second < policy.HSTSMinMaxAge {
+		violations = append(violations, fmt.Sprintf("Strict-Transport-Security: expected max-age of at least %d, got %q",
+			int(policy.HSTSMinMaxAge.Seconds()), values[0]))
+	}
+	if policy.HSTSIncludeSubDomains && !includeSubDomains {
+		violations = append(violations, fmt.Sprintf("Strict-Transport-Security: expected includeSubDomains, got %q", values[0]))
+	}
+
+	return violations
+}
+
+// cspSatisfies reports whether any of the policies contains the directive required
+// with all of its sources.
+func cspSatisfies(policies []string, required string) bool {
+	fields := strings.Fields(required)
+	if len(fields) == 0 {
+		return true
+	}
+
+	name := strings.ToLower(fields[0])
+
+	for _, policy := range policies {
+		sources, ok := ParseCSP(policy)[name]
+		if !ok {
+			continue
+		}
+
+		all := true
+		for _, want := range fields[1:] {
+			if !slices.ContainsFunc(sources, func(s string) bool { return strings.EqualFold(s, want) }) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,84 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func securityServer(t *testing.T) string {
+	return newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		switch r.URL.Path {
+		case "/secure":
+			h.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("Content-Security-Policy", "default-src 'self' https://cdn.example.com; frame-ancestors 'none'")
+		case "/leaky":
+			h.Set("Strict-Transport-Security", "max-age=600")
+			h.Set("X-Content-Type-Options", "sniff")
+			h.Set("X-Frame-Options", "ALLOW-FROM https://example.com")
+			h.Set("Server", "nginx/1.18.0")
+			h.Set("X-Powered-By", "PHP/7.4")
+		case "/health":
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+		}
+	}).URL
+}
+
+func TestExpectSecurityHeaders(t *testing.T) {
+	base := securityServer(t)
+	ctx := context.Background()
+	b := New(require.New(t))
+
+	response, _ := b.Send(t, ctx, Get(base+"/secure"))
+	b.ExpectSecurityHeaders(t, response, SecurityPolicy{
+		HSTSIncludeSubDomains: true,
+		CSPDirectives:         []string{"default-src 'self'", "default-src https://cdn.example.com 'SELF'"},
+	})
+
+	response, _ = b.Send(t, ctx, Get(base+"/health"))
+	b.ExpectSecurityHeaders(t, response, SecurityPolicy{Skip: []SecurityCheck{SecurityHSTS}})
+}
+
+func TestExpectSecurityHeadersReportsAllViolations(t *testing.T) {
+	base := securityServer(t)
+
+	msg := failure(t, nil, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), Get(base+"/leaky"))
+		b.ExpectSecurityHeaders(t, response, SecurityPolicy{
+			HSTSIncludeSubDomains: true,
+			CSPDirectives:         []string{"default-src 'self'"},
+		})
+	})
+
+	for _, want := range []string{
+		`Strict-Transport-Security: expected max-age of at least 15552000, got "max-age=600"`,
+		`Strict-Transport-Security: expected includeSubDomains, got "max-age=600"`,
+		`X-Content-Type-Options: expected nosniff, got "sniff"`,
+		`X-Frame-Options: expected one of ["DENY" "SAMEORIGIN"] or CSP frame-ancestors, got "ALLOW-FROM https://example.com"`,
+		`Content-Security-Policy: missing "default-src 'self'", got []`,
+		`Server: expected absent, got ["nginx/1.18.0"]`,
+		`X-Powered-By: expected absent, got ["PHP/7.4"]`,
+	} {
+		require.Contains(t, msg, want)
+	}
+
+	msg = failure(t, nil, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), Get(base+"/health"))
+		b.ExpectSecurityHeaders(t, response, SecurityPolicy{})
+	})
+	require.Contains(t, msg, "Strict-Transport-Security: expected, got none")
+	require.NotContains(t, msg, "X-Frame-Options: expected")
+}
+
+func TestParseCSP(t *testing.T) {
+	require.Equal(t, map[string][]string{
+		"default-src":               {"'self'"},
+		"img-src":                   {"*", "data:"},
+		"upgrade-insecure-requests": {},
+	}, ParseCSP("Default-Src 'self'; img-src * data:;; upgrade-insecure-requests; default-src 'none'"))
+}
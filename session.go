@@ -0,0 +1,156 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// Session sends requests that share state: cookies set by responses, the values of
+// sticky headers, see WithStickyHeaders, and with WithRefererTracking the previous URL.
+type Session struct {
+	b *Builder
+
+	mu      sync.Mutex
+	cookies []*http.Cookie
+	sticky  http.Header
+	referer *url.URL
+}
+
+// Session starts a session on a copy of the Builder with opts applied.
+func (b *Builder) Session(opts ...Option) *Session {
+	return &Session{b: b.With(opts...), sticky: http.Header{}}
+}
+
+// WithStickyHeaders makes a Session store the value of each listed response header and
+// send it on the session's following requests, for servers that rotate a token on
+// every response. A response with an empty value removes the stored header. A header
+// set on the request itself takes precedence.
+func WithStickyHeaders(names ...string) Option {
+	return func(b *Builder) {
+		sticky := b.stickyHeaders[:len(b.stickyHeaders):len(b.stickyHeaders)]
+		for _, name := range names {
+			sticky = append(sticky, http.CanonicalHeaderKey(name))
+		}
+		b.stickyHeaders = sticky
+	}
+}
+
+// WithRefererTracking makes a Session send the final URL, after redirects, of its
+// previous request as the Referer of the next one, like a browser navigating: none on
+// the first request and none when going from https to http. WithReferer and
+// WithoutReferer override it for a single request.
+func WithRefererTracking() Option {
+	return func(b *Builder) {
+		b.trackReferer = true
+	}
+}
+
+// WithReferer sends referer as the Referer header.
+func WithReferer(referer string) Option {
+	return func(b *Builder) {
+		b.referer = referer
+		b.noReferer = false
+	}
+}
+
+// WithoutReferer sends no Referer header.
+func WithoutReferer() Option {
+	return func(b *Builder) {
+		b.referer = ""
+		b.noReferer = true
+	}
+}
+
+// applyReferer sets or removes the Referer header as configured by WithReferer and
+// WithoutReferer.
+func (b *Builder) applyReferer(req *http.Request) {
+	switch {
+	case b.noReferer:
+		req.Header.Del("Referer")
+	case b.referer != "":
+		req.Header.Set("Referer", b.referer)
+	}
+}
+
+// refererFor returns the Referer a browser sends when navigating from prev to next:
+// prev without credentials and fragment, or nothing on an https to http downgrade.
+func refererFor(prev, next *url.URL) string {
+	if prev == nil || prev.Scheme == "https" && next.Scheme == "http" {
+		return ""
+	}
+
+	referer := *prev
+	referer.User = nil
+	referer.Fragment = ""
+	referer.RawFragment = ""
+
+	return referer.String()
+}
+
+// Send sends spec with the session's cookies, sticky headers and Referer, and records
+// the cookies, sticky headers and final URL of the response.
+func (s *Session) Send(t *testing.T, ctx context.Context, spec *RequestSpec, opts ...Option) *http.Response {
+	t.Helper()
+
+	b := s.b.With(opts...)
+	spec = spec.clone()
+
+	s.mu.Lock()
+	spec.Cookies = append(spec.Cookies, s.cookies...)
+	for name, values := range s.sticky {
+		if _, ok := spec.Headers[name]; !ok {
+			spec.Headers[name] = append([]string(nil), values...)
+		}
+	}
+	s.mu.Unlock()
+
+	req := b.newSpecRequest(t, ctx, spec)
+	if b.trackReferer && req.Header.Get("Referer") == "" {
+		s.mu.Lock()
+		if referer := refererFor(s.referer, req.URL); referer != "" {
+			req.Header.Set("Referer", referer)
+		}
+		s.mu.Unlock()
+	}
+
+	response := b.do(t, req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if response.Request != nil {
+		s.referer = response.Request.URL
+	}
+	s.cookies = mergeCookies(response, s.cookies)
+	for _, name := range b.stickyHeaders {
+		values, ok := response.Header[name]
+		switch {
+		case !ok:
+		case len(values) == 0 || values[0] == "":
+			s.sticky.Del(name)
+		default:
+			s.sticky.Set(name, values[0])
+		}
+	}
+
+	return response
+}
+
+// Cookies returns the session's cookies.
+func (s *Session) Cookies() []*http.Cookie {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*http.Cookie(nil), s.cookies...)
+}
+
+// StickyHeader returns the stored value of a sticky header.
+func (s *Session) StickyHeader(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sticky.Get(name)
+}
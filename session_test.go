@@ -0,0 +1,79 @@
+package reqbuilder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStickyHeaders(t *testing.T) {
+	// The server accepts each hop only with the token it issued on the previous one.
+	issued, hop := "", 0
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Session-Token"); got != issued {
+			http.Error(w, fmt.Sprintf("hop %d: expected token %q, got %q", hop, issued, got), http.StatusUnauthorized)
+			return
+		}
+		hop++
+		issued = fmt.Sprintf("token-%d", hop)
+		w.Header().Set("X-Session-Token", issued)
+	})
+
+	s := New(require.New(t)).Session(WithStickyHeaders("x-session-token"))
+	for i := range 3 {
+		response := s.Send(t, context.Background(), Get(server.URL))
+		require.Equal(t, http.StatusOK, response.StatusCode, "hop %d", i)
+	}
+	require.Equal(t, "token-3", s.StickyHeader("X-Session-Token"))
+}
+
+func TestSessionStickyHeadersOverrideAndClear(t *testing.T) {
+	var received []string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Continuation"))
+		w.Header()["X-Continuation"] = []string{r.URL.Query().Get("next")}
+	})
+
+	s := New(require.New(t)).Session(WithStickyHeaders("X-Continuation"))
+	s.Send(t, context.Background(), Get(server.URL+"?next=c1"))
+	s.Send(t, context.Background(), Get(server.URL+"?next=c2").SetHeader("X-Continuation", "manual"))
+	s.Send(t, context.Background(), Get(server.URL+"?next="))
+	s.Send(t, context.Background(), Get(server.URL+"?next=c3"))
+
+	require.Equal(t, []string{"", "manual", "c2", ""}, received)
+	require.Equal(t, "c3", s.StickyHeader("X-Continuation"))
+}
+
+func TestSessionRefererTracking(t *testing.T) {
+	var referers []string
+	record := func(w http.ResponseWriter, r *http.Request) {
+		referers = append(referers, r.Header.Get("Referer"))
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/landing#top", http.StatusFound)
+		}
+	}
+	plain := newServer(t, record)
+	secure, b := newTLSServer(t, record)
+
+	s := b.Session(WithRefererTracking())
+	ctx := context.Background()
+	s.Send(t, ctx, Get(plain.URL+"/start"))
+	s.Send(t, ctx, Get(secure.URL+"/checkout"))
+	s.Send(t, ctx, Get(plain.URL+"/analytics"))
+	s.Send(t, ctx, Get(plain.URL+"/a"), WithReferer("https://ads.example/campaign"))
+	s.Send(t, ctx, Get(plain.URL+"/b"), WithoutReferer())
+	s.Send(t, ctx, Get(plain.URL+"/c"))
+
+	require.Equal(t, []string{
+		"",                             // first request
+		plain.URL + "/start",           // the client's own Referer on the redirect hop
+		plain.URL + "/landing",         // the final URL after the redirect, without fragment
+		"",                             // https to http downgrade
+		"https://ads.example/campaign", // WithReferer
+		"",                             // WithoutReferer
+		plain.URL + "/b",
+	}, referers)
+}
@@ -0,0 +1,218 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// RequestSpec describes a request independently of the Builder that sends it.
+// A URL starting with "/" is resolved against the Builder's base URL, see WithBaseURL.
+type RequestSpec struct {
+	Method        string
+	URL           string
+	Headers       http.Header
+	Body          []byte
+	Cookies       []*http.Cookie
+	Authorization string
+
+	name          string
+	expectStatus  int
+	expectJSON    any
+	expectHeaders http.Header
+	captures      []capture
+	err           error
+}
+
+// NewSpec returns a RequestSpec for method and url.
+func NewSpec(method, url string) *RequestSpec {
+	return &RequestSpec{Method: method, URL: url, Headers: http.Header{}}
+}
+
+// Get returns a GET RequestSpec.
+func Get(url string) *RequestSpec {
+	return NewSpec(http.MethodGet, url)
+}
+
+// Post returns a POST RequestSpec.
+func Post(url string) *RequestSpec {
+	return NewSpec(http.MethodPost, url)
+}
+
+// Put returns a PUT RequestSpec.
+func Put(url string) *RequestSpec {
+	return NewSpec(http.MethodPut, url)
+}
+
+// Patch returns a PATCH RequestSpec.
+func Patch(url string) *RequestSpec {
+	return NewSpec(http.MethodPatch, url)
+}
+
+// Delete returns a DELETE RequestSpec. Like any spec it can carry a body, for APIs
+// that accept one on DELETE:
+//
+//	reqbuilder.Delete("/index/_query").JSONBody(query)
+func Delete(url string) *RequestSpec {
+	return NewSpec(http.MethodDelete, url)
+}
+
+// SetHeader sets a request header.
+func (s *RequestSpec) SetHeader(key, value string) *RequestSpec {
+	if s.Headers == nil {
+		s.Headers = http.Header{}
+	}
+	s.Headers.Set(key, value)
+
+	return s
+}
+
+// BodyBytes sets the request body and its content type.
+func (s *RequestSpec) BodyBytes(contentType string, body []byte) *RequestSpec {
+	s.Body = body
+
+	return s.SetHeader("Content-Type", contentType)
+}
+
+// JSONBody sets the request body to v marshaled as JSON. If v cannot be marshaled
+// the test fails when the spec is sent.
+func (s *RequestSpec) JSONBody(v any) *RequestSpec {
+	body, err := json.Marshal(v)
+	if err != nil {
+		s.err = fmt.Errorf("JSONBody: %w", err)
+		return s
+	}
+
+	return s.BodyBytes("application/json", body)
+}
+
+// Named names the request in reports, see WithReport.
+func (s *RequestSpec) Named(name string) *RequestSpec {
+	s.name = name
+
+	return s
+}
+
+// ExpectStatus makes flows fail the step unless the response has the given status.
+func (s *RequestSpec) ExpectStatus(code int) *RequestSpec {
+	s.expectStatus = code
+
+	return s
+}
+
+// ExpectJSON makes flows fail the step unless the JSON response body contains subset:
+// objects must have at least the expected keys, arrays must match element by element.
+func (s *RequestSpec) ExpectJSON(subset any) *RequestSpec {
+	s.expectJSON = subset
+
+	return s
+}
+
+// ExpectHeader makes flows fail the step unless the response header key has value.
+func (s *RequestSpec) ExpectHeader(key, value string) *RequestSpec {
+	if s.expectHeaders == nil {
+		s.expectHeaders = http.Header{}
+	}
+	s.expectHeaders.Add(key, value)
+
+	return s
+}
+
+// Capture stores the value at a JSONPath such as "$.items[0].id" of the JSON response
+// body under name, for use as {{name}} in later flow steps.
+func (s *RequestSpec) Capture(name, path string) *RequestSpec {
+	s.captures = append(s.captures, capture{name: name, jsonPath: path})
+
+	return s
+}
+
+// CaptureHeader stores the value of a response header under name.
+func (s *RequestSpec) CaptureHeader(name, header string) *RequestSpec {
+	s.captures = append(s.captures, capture{name: name, header: header})
+
+	return s
+}
+
+// clone returns a deep copy of the spec.
+func (s *RequestSpec) clone() *RequestSpec {
+	copied := *s
+	copied.Headers = s.Headers.Clone()
+	if copied.Headers == nil {
+		copied.Headers = http.Header{}
+	}
+	copied.Body = append([]byte(nil), s.Body...)
+	copied.Cookies = append([]*http.Cookie(nil), s.Cookies...)
+	copied.expectHeaders = s.expectHeaders.Clone()
+	copied.captures = append([]capture(nil), s.captures...)
+
+	return &copied
+}
+
+// capture extracts a variable from a response.
+type capture struct {
+	name     string
+	jsonPath string
+	header   string
+}
+
+// WithBaseURL sets the scheme and host that RequestSpec URLs starting with "/" are sent to.
+func WithBaseURL(baseURL string) Option {
+	return func(b *Builder) {
+		b.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// Send sends the request described by spec.
+func (b *Builder) Send(t *testing.T, ctx context.Context, spec *RequestSpec) (*http.Response, []*http.Cookie) {
+	t.Helper()
+
+	req := b.newSpecRequest(t, ctx, spec)
+	response := b.do(t, req)
+
+	return response, mergeCookies(response, spec.Cookies)
+}
+
+// newSpecRequest builds the *http.Request for spec.
+func (b *Builder) newSpecRequest(t *testing.T, ctx context.Context, spec *RequestSpec) *http.Request {
+	t.Helper()
+
+	target := spec.URL
+	if strings.HasPrefix(target, "/") {
+		target = b.baseURL + target
+	}
+
+	if spec.err != nil {
+		b.logError(t, spec.err)
+	}
+	b.require.NoError(spec.err, "building %s %s", spec.Method, spec.URL)
+
+	if spec.name != "" {
+		ctx = context.WithValue(ctx, requestNameKey{}, spec.name)
+	}
+
+	req, err := newRequest(ctx, spec.Method, target, bytes.NewReader(spec.Body))
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	for k, vs := range spec.Headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
+	for _, cookie := range spec.Cookies {
+		b.addCookie(req, cookie)
+	}
+
+	if spec.Authorization != "" {
+		req.Header.Set("Authorization", spec.Authorization)
+	}
+
+	return req
+}
@@ -0,0 +1,30 @@
+package reqbuilder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteWithBody(t *testing.T) {
+	var method, contentType string
+	var body []byte
+	var length int64
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		method, contentType, length = r.Method, r.Header.Get("Content-Type"), r.ContentLength
+		body, _ = io.ReadAll(r.Body)
+	})
+
+	b := New(require.New(t))
+	query := map[string]any{"query": map[string]any{"term": map[string]string{"user": "ann"}}}
+	response, _ := b.Send(t, context.Background(), Delete(server.URL+"/index/_query").JSONBody(query))
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, http.MethodDelete, method)
+	require.Equal(t, "application/json", contentType)
+	require.JSONEq(t, `{"query":{"term":{"user":"ann"}}}`, string(body))
+	require.EqualValues(t, len(body), length, "the body must be sent with its length")
+}
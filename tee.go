@@ -0,0 +1,159 @@
+package reqbuilder
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// WithBodyTee copies each response body to w as the test reads it, decoded, through
+// ReadResponseBody, BodyReader, DecodeJSON or ExpectBodySHA256. Reads the Builder makes
+// for itself, for validators, assertions and failure messages, are not copied. A body
+// buffered with WithBodyReplay is copied every time the test reads it. If w has a Flush() error or
+// Close method, it is flushed and closed when the test that sent the request ends.
+func WithBodyTee(w io.Writer) Option {
+	return func(b *Builder) {
+		b.bodyTee = w
+	}
+}
+
+// WithBodyTeeToTestLog logs the first maxBytes of each response body read by the test
+// when the test ends, hex-dumped if binary, to the test log or the WithLogger logger.
+// It composes with WithBodyTee.
+func WithBodyTeeToTestLog(maxBytes int) Option {
+	return func(b *Builder) {
+		b.bodyTeeLog = maxBytes
+	}
+}
+
+// WithRawBodyTee makes WithBodyTee and WithBodyTeeToTestLog copy the body as received,
+// before decompression, once, as it is read from the connection.
+func WithRawBodyTee() Option {
+	return func(b *Builder) {
+		b.bodyTeeRaw = true
+	}
+}
+
+// teeBody attaches the Builder's body tees to response.
+func (b *Builder) teeBody(t *testing.T, req *http.Request, response *http.Response) {
+	t.Helper()
+
+	var writers []io.Writer
+	if b.bodyTee != nil {
+		writers = append(writers, b.bodyTee)
+		b.closeTeeOnCleanup(t, b.bodyTee)
+	}
+	if b.bodyTeeLog > 0 {
+		log := &logTee{max: b.bodyTeeLog}
+		writers = append(writers, log)
+		t.Cleanup(func() {
+			log.flush(t, b, req)
+		})
+	}
+
+	if len(writers) == 0 || response.Body == nil {
+		return
+	}
+
+	tee := io.MultiWriter(writers...)
+	if b.bodyTeeRaw {
+		response.Body = struct {
+			io.Reader
+			io.Closer
+		}{&teeReader{Reader: response.Body, w: tee}, response.Body}
+		return
+	}
+
+	if meta := metaOf(response); meta != nil {
+		meta.tee = tee
+	}
+}
+
+// closeTeeOnCleanup flushes and closes w when t finishes, once per test and writer.
+func (b *Builder) closeTeeOnCleanup(t *testing.T, w io.Writer) {
+	flusher, canFlush := w.(interface{ Flush() error })
+	closer, canClose := w.(io.Closer)
+	if !canFlush && !canClose {
+		return
+	}
+
+	key := teeCleanupKey{t: t, w: w}
+	if _, registered := b.cleanups.LoadOrStore(key, struct{}{}); registered {
+		return
+	}
+
+	t.Cleanup(func() {
+		if canFlush {
+			flusher.Flush()
+		}
+		if canClose {
+			closer.Close()
+		}
+		b.cleanups.Delete(key)
+	})
+}
+
+// teeCleanupKey identifies a cleanup registered by closeTeeOnCleanup.
+type teeCleanupKey struct {
+	t *testing.T
+	w io.Writer
+}
+
+// teeReader copies what is read from Reader to w. Unlike io.TeeReader it ignores write
+// errors, so a failing debug writer does not fail the read.
+type teeReader struct {
+	io.Reader
+	w io.Writer
+}
+
+func (r *teeReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.w.Write(p[:n])
+	}
+
+	return n, err
+}
+
+// logTee keeps the first max bytes written to it for WithBodyTeeToTestLog.
+type logTee struct {
+	mu    sync.Mutex
+	max   int
+	head  []byte
+	total int
+}
+
+func (l *logTee) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total += len(p)
+	if room := l.max - len(l.head); room > 0 {
+		l.head = append(l.head, p[:min(room, len(p))]...)
+	}
+
+	return len(p), nil
+}
+
+// flush logs the kept bytes with the Builder's logger.
+func (l *logTee) flush(t *testing.T, b *Builder, req *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.total == 0 {
+		return
+	}
+
+	more := ""
+	if l.total > len(l.head) {
+		more = fmt.Sprintf("... (%d more bytes)", l.total-len(l.head))
+	}
+	b.logf(t, slog.LevelInfo, "response body",
+		slog.String("method", req.Method),
+		slog.String("url", redactURL(req.URL)),
+		slog.Int("bytes", l.total),
+		slog.String("body", excerpt(l.head, l.max)+more))
+}
@@ -0,0 +1,101 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// flushCloser records what is written to it and how often it is flushed and closed.
+type flushCloser struct {
+	bytes.Buffer
+	flushes, closes int
+}
+
+func (w *flushCloser) Flush() error {
+	w.flushes++
+
+	return nil
+}
+
+func (w *flushCloser) Close() error {
+	w.closes++
+
+	return nil
+}
+
+func TestWithBodyTee(t *testing.T) {
+	content := []byte(`{"event":"tick"}` + "\n")
+	url := gzipServer(t, content)
+	encoded, err := EncodeGzip(content)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		want []byte
+	}{
+		{"decoded", nil, content},
+		{"raw", []Option{WithRawBodyTee()}, encoded},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var tee bytes.Buffer
+			b := New(require.New(t), append([]Option{WithRawBodyAccess(), WithBodyTee(&tee)}, tt.opts...)...)
+
+			response, _ := b.Send(t, context.Background(), Get(url))
+			reader, err := b.BodyReader(response)
+			require.NoError(t, err)
+			body, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			reader.Close()
+
+			require.Equal(t, content, body, "the test reads the decoded body either way")
+			require.Equal(t, tt.want, tee.Bytes())
+		})
+	}
+}
+
+func TestWithBodyTeeSkipsInternalReads(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	var tee bytes.Buffer
+	b := New(require.New(t), WithBodyTee(&tee), WithResponseValidator(func(*http.Response) error { return nil }))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	b.ExpectBodyContains(t, response, "ok")
+	b.RequireJSONEq(t, response, `{"ok":true}`)
+	require.Empty(t, tee.String(), "validators and assertions are not copied")
+
+	body, err := b.ReadResponseBody(response)
+	require.NoError(t, err)
+	require.Equal(t, `{"ok":true}`, string(body))
+	require.Equal(t, `{"ok":true}`, tee.String(), "the test's own read is copied once")
+}
+
+func TestWithBodyTeeCleanup(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/")))
+	})
+
+	w := &flushCloser{}
+	b := New(require.New(t), WithBodyTee(w))
+	t.Run("send", func(t *testing.T) {
+		for _, path := range []string{"/one", "/two"} {
+			response, _ := b.Send(t, context.Background(), Get(server.URL+path))
+			b.ReadResponseBody(response)
+		}
+		require.Zero(t, w.flushes+w.closes, "the writer stays open while the test runs")
+	})
+
+	require.Equal(t, "onetwo", w.String())
+	require.Equal(t, 1, w.flushes, "flushed once when the test ends")
+	require.Equal(t, 1, w.closes, "closed once when the test ends")
+}
@@ -0,0 +1,140 @@
+package reqbuilder
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"testing"
+	"time"
+)
+
+// Timings breaks down where the time of a request went. Connect and TLS are zero and
+// Reused is set when the request was sent over a pooled connection. BodyRead and Total
+// are zero until the response body has been read to the end or closed.
+type Timings struct {
+	DNS      time.Duration
+	Connect  time.Duration
+	TLS      time.Duration
+	TTFB     time.Duration
+	BodyRead time.Duration
+	Total    time.Duration
+	Reused   bool
+}
+
+// timingTrace holds the timestamps Timings are computed from. It is guarded by requestMeta.mu.
+type timingTrace struct {
+	start, dnsStart, connectStart, tlsStart, firstByte time.Time
+	timings                                            Timings
+}
+
+// Timings returns the timing breakdown of the final attempt of the request.
+// ok is false for responses not sent by a Builder.
+func (r *Response) Timings() (timings Timings, ok bool) {
+	if r.meta == nil {
+		return Timings{}, false
+	}
+
+	r.meta.mu.Lock()
+	defer r.meta.mu.Unlock()
+
+	if r.meta.timing.start.IsZero() {
+		return Timings{}, false
+	}
+
+	return r.meta.timing.timings, true
+}
+
+// ExpectTTFBUnder fails the test unless the first response byte arrived within d of
+// the request being started.
+func (b *Builder) ExpectTTFBUnder(t *testing.T, response *http.Response, d time.Duration) {
+	t.Helper()
+
+	timings, ok := Wrap(response).Timings()
+	b.require.True(ok, "no timings recorded for the response")
+	b.require.Truef(timings.TTFB < d, "expected time to first byte under %s, got %s (%+v)", d, timings.TTFB, timings)
+}
+
+// timingHooks adds hooks to trace that record timings into meta.
+func (b *Builder) timingHooks(meta *requestMeta, trace *httptrace.ClientTrace) {
+	record := func(fn func(tt *timingTrace, now time.Time)) {
+		now := b.clock.Now()
+		meta.mu.Lock()
+		fn(&meta.timing, now)
+		meta.mu.Unlock()
+	}
+
+	trace.GetConn = func(string) {
+		record(func(tt *timingTrace, now time.Time) {
+			*tt = timingTrace{start: now}
+		})
+	}
+	trace.DNSStart = func(httptrace.DNSStartInfo) {
+		record(func(tt *timingTrace, now time.Time) { tt.dnsStart = now })
+	}
+	trace.DNSDone = func(httptrace.DNSDoneInfo) {
+		record(func(tt *timingTrace, now time.Time) { tt.timings.DNS = now.Sub(tt.dnsStart) })
+	}
+	trace.ConnectStart = func(string, string) {
+		record(func(tt *timingTrace, now time.Time) { tt.connectStart = now })
+	}
+	trace.ConnectDone = func(string, string, error) {
+		record(func(tt *timingTrace, now time.Time) { tt.timings.Connect = now.Sub(tt.connectStart) })
+	}
+	trace.TLSHandshakeStart = func() {
+		record(func(tt *timingTrace, now time.Time) { tt.tlsStart = now })
+	}
+	trace.TLSHandshakeDone = func(tls.ConnectionState, error) {
+		record(func(tt *timingTrace, now time.Time) { tt.timings.TLS = now.Sub(tt.tlsStart) })
+	}
+	trace.GotFirstResponseByte = func() {
+		record(func(tt *timingTrace, now time.Time) {
+			tt.firstByte = now
+			tt.timings.TTFB = now.Sub(tt.start)
+		})
+	}
+}
+
+// timedBody records when the response body has been fully read.
+type timedBody struct {
+	io.ReadCloser
+	b    *Builder
+	meta *requestMeta
+	done bool
+}
+
+func (r *timedBody) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if errors.Is(err, io.EOF) {
+		r.finish()
+	}
+
+	return n, err
+}
+
+func (r *timedBody) Close() error {
+	r.finish()
+
+	return r.ReadCloser.Close()
+}
+
+// finish records BodyRead and Total once.
+func (r *timedBody) finish() {
+	if r.done {
+		return
+	}
+	r.done = true
+
+	now := r.b.clock.Now()
+
+	r.meta.mu.Lock()
+	defer r.meta.mu.Unlock()
+
+	tt := &r.meta.timing
+	if tt.start.IsZero() || tt.firstByte.IsZero() {
+		return
+	}
+	tt.timings.BodyRead = now.Sub(tt.firstByte)
+	tt.timings.Total = now.Sub(tt.start)
+}
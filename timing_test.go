@@ -0,0 +1,89 @@
+package reqbuilder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func slowBodyHandler(w http.ResponseWriter, r *http.Request) {
+	time.Sleep(50 * time.Millisecond)
+	w.Write([]byte("first"))
+	w.(http.Flusher).Flush()
+	time.Sleep(30 * time.Millisecond)
+	w.Write([]byte("second"))
+}
+
+func TestTimings(t *testing.T) {
+	server, b := newTLSServer(t, slowBodyHandler)
+	ctx := context.Background()
+
+	response, _ := b.Send(t, ctx, Get(server.URL))
+	timings, ok := Wrap(response).Timings()
+	require.True(t, ok)
+	require.False(t, timings.Reused)
+	require.Positive(t, timings.Connect)
+	require.Positive(t, timings.TLS)
+	require.GreaterOrEqual(t, timings.TTFB, 50*time.Millisecond)
+	require.Greater(t, timings.TTFB, timings.Connect+timings.TLS)
+	require.Zero(t, timings.BodyRead, "the body has not been read yet")
+
+	_, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	timings, _ = Wrap(response).Timings()
+	require.GreaterOrEqual(t, timings.BodyRead, 30*time.Millisecond)
+	require.GreaterOrEqual(t, timings.Total, timings.TTFB+timings.BodyRead)
+
+	response, _ = b.Send(t, ctx, Get(server.URL))
+	response.Body.Close()
+	timings, _ = Wrap(response).Timings()
+	require.True(t, timings.Reused)
+	require.Zero(t, timings.Connect)
+	require.Zero(t, timings.TLS)
+	require.Zero(t, timings.DNS)
+	require.GreaterOrEqual(t, timings.TTFB, 50*time.Millisecond)
+	require.Positive(t, timings.Total)
+
+	_, ok = Wrap(&http.Response{}).Timings()
+	require.False(t, ok)
+}
+
+func TestTimingsParallel(t *testing.T) {
+	server, b := newTLSServer(t, slowBodyHandler)
+
+	for i := range 8 {
+		t.Run("", func(t *testing.T) {
+			t.Parallel()
+
+			sub := b.forTest(t)
+			response, _ := sub.Send(t, context.Background(), Get(server.URL))
+			io.Copy(io.Discard, response.Body)
+
+			timings, ok := Wrap(response).Timings()
+			require.True(t, ok, i)
+			require.GreaterOrEqual(t, timings.TTFB, 50*time.Millisecond)
+			require.GreaterOrEqual(t, timings.Total, timings.TTFB)
+			if timings.Reused {
+				require.Zero(t, timings.Connect)
+			}
+		})
+	}
+}
+
+func TestExpectTTFBUnder(t *testing.T) {
+	server := newServer(t, slowBodyHandler)
+
+	b := New(require.New(t))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+	b.ExpectTTFBUnder(t, response, 5*time.Second)
+
+	msg := failure(t, nil, func(b *Builder) {
+		response, _ := b.Send(t, context.Background(), Get(server.URL))
+		b.ExpectTTFBUnder(t, response, time.Millisecond)
+	})
+	require.Contains(t, msg, "expected time to first byte under 1ms")
+}
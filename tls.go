@@ -0,0 +1,106 @@
+package reqbuilder
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// errNotTLS is returned for responses that were not received over TLS.
+var errNotTLS = errors.New("response was not over TLS")
+
+// TLSDetails describes the TLS session a response was received over.
+type TLSDetails struct {
+	Version      uint16
+	VersionName  string
+	CipherSuite  string
+	ALPN         string
+	Certificates []*x509.Certificate
+}
+
+// WithMinTLSVersion sets the lowest TLS version the Builder accepts; handshakes below it fail.
+func WithMinTLSVersion(version uint16) Option {
+	return func(b *Builder) {
+		b.tlsConfig().MinVersion = version
+	}
+}
+
+// WithTLSVersion limits the TLS versions the Builder offers to min through max, e.g.
+// tls.VersionTLS12 for both to check that a server refuses TLS 1.2. Zero leaves a
+// bound at Go's default.
+func WithTLSVersion(min, max uint16) Option {
+	return func(b *Builder) {
+		if min != 0 && max != 0 {
+			b.require.LessOrEqualf(min, max, "TLS min version %s is above max version %s",
+				tls.VersionName(min), tls.VersionName(max))
+		}
+		config := b.tlsConfig()
+		config.MinVersion = min
+		config.MaxVersion = max
+	}
+}
+
+// WithCipherSuites restricts the cipher suites the Builder offers, e.g. to weak ones
+// to check that a server refuses them. It only affects TLS 1.2 and below; TLS 1.3
+// suites are not configurable.
+func WithCipherSuites(suites ...uint16) Option {
+	return func(b *Builder) {
+		b.tlsConfig().CipherSuites = suites
+	}
+}
+
+// tlsConfig returns the transport's TLS configuration, creating it if needed.
+func (b *Builder) tlsConfig() *tls.Config {
+	transport := b.ownTransport()
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	} else {
+		transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+	}
+
+	return transport.TLSClientConfig
+}
+
+// TLSInfo returns the negotiated TLS version, cipher suite, ALPN protocol and peer
+// certificate chain of the response.
+func (b *Builder) TLSInfo(response *http.Response) (*TLSDetails, error) {
+	if response == nil || response.TLS == nil {
+		return nil, errNotTLS
+	}
+
+	state := response.TLS
+
+	return &TLSDetails{
+		Version:      state.Version,
+		VersionName:  tls.VersionName(state.Version),
+		CipherSuite:  tls.CipherSuiteName(state.CipherSuite),
+		ALPN:         state.NegotiatedProtocol,
+		Certificates: state.PeerCertificates,
+	}, nil
+}
+
+// ExpectTLSVersionAtLeast fails the test unless the response was received over at least the given TLS version.
+func (b *Builder) ExpectTLSVersionAtLeast(t *testing.T, response *http.Response, version uint16) {
+	t.Helper()
+
+	info, err := b.TLSInfo(response)
+	b.require.NoError(err)
+	b.require.GreaterOrEqualf(info.Version, version,
+		"negotiated %s, expected at least %s", info.VersionName, tls.VersionName(version))
+}
+
+// ExpectCertSAN fails the test unless the peer certificate is valid for the given host name or IP.
+func (b *Builder) ExpectCertSAN(t *testing.T, response *http.Response, name string) {
+	t.Helper()
+
+	info, err := b.TLSInfo(response)
+	b.require.NoError(err)
+	b.require.NotEmpty(info.Certificates, "server presented no certificate")
+
+	leaf := info.Certificates[0]
+	b.require.NoErrorf(leaf.VerifyHostname(name),
+		"certificate does not cover %q (DNS SANs %v, IP SANs %v)", name, leaf.DNSNames, leaf.IPAddresses)
+}
@@ -0,0 +1,125 @@
+package reqbuilder
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTLSServer starts a TLS server and returns it with a Builder trusting it.
+func newTLSServer(t *testing.T, handler http.HandlerFunc, opts ...Option) (*httptest.Server, *Builder) {
+	t.Helper()
+
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	config := server.Client().Transport.(*http.Transport).TLSClientConfig
+	b := New(require.New(t), append([]Option{WithTLSConfig(config)}, opts...)...)
+
+	return server, b
+}
+
+func TestTLSInfo(t *testing.T) {
+	server, b := newTLSServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+
+	info, err := b.TLSInfo(response)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), info.Version)
+	require.Equal(t, "TLS 1.3", info.VersionName)
+	require.NotEmpty(t, info.CipherSuite)
+	require.NotEmpty(t, info.Certificates)
+
+	b.ExpectTLSVersionAtLeast(t, response, tls.VersionTLS13)
+	b.ExpectCertSAN(t, response, "example.com")
+	b.ExpectCertSAN(t, response, "127.0.0.1")
+
+	msg := failure(t, nil, func(fb *Builder) {
+		fb.ExpectCertSAN(t, response, "api.example.org")
+	})
+	require.Contains(t, msg, `certificate does not cover "api.example.org"`)
+}
+
+func TestTLSAssertionsOnPlainHTTP(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	response, _ := New(require.New(t)).Send(t, context.Background(), Get(server.URL))
+
+	msg := failure(t, nil, func(b *Builder) {
+		b.ExpectTLSVersionAtLeast(t, response, tls.VersionTLS12)
+	})
+	require.Contains(t, msg, "response was not over TLS")
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.ExpectCertSAN(t, response, "example.com")
+	})
+	require.Contains(t, msg, "response was not over TLS")
+}
+
+func TestWithMinTLSVersion(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	config := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	msg := failure(t, []Option{WithTLSConfig(config), WithMinTLSVersion(tls.VersionTLS13)}, func(b *Builder) {
+		b.Send(t, context.Background(), Get(server.URL))
+	})
+	require.Contains(t, msg, "protocol version")
+}
+
+func TestWithTLSVersion(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MinVersion: tls.VersionTLS13}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	config := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	msg := failure(t, []Option{WithTLSConfig(config), WithTLSVersion(tls.VersionTLS12, tls.VersionTLS12)}, func(b *Builder) {
+		b.Send(t, context.Background(), Get(server.URL))
+	})
+	require.Contains(t, msg, "protocol version")
+
+	b := New(require.New(t), WithTLSConfig(config), WithTLSVersion(tls.VersionTLS13, tls.VersionTLS13))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+	info, err := b.TLSInfo(response)
+	require.NoError(t, err)
+	require.Equal(t, "TLS 1.3", info.VersionName)
+
+	msg = failure(t, nil, func(b *Builder) {
+		b.With(WithTLSVersion(tls.VersionTLS13, tls.VersionTLS12))
+	})
+	require.Contains(t, msg, "TLS min version TLS 1.3 is above max version TLS 1.2")
+}
+
+func TestWithCipherSuites(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12, CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	config := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	msg := failure(t, []Option{WithTLSConfig(config), WithCipherSuites(tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256)}, func(b *Builder) {
+		b.Send(t, context.Background(), Get(server.URL))
+	})
+	require.Contains(t, msg, "handshake failure")
+
+	b := New(require.New(t), WithTLSConfig(config), WithCipherSuites(tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384))
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+	info, err := b.TLSInfo(response)
+	require.NoError(t, err)
+	require.Equal(t, "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", info.CipherSuite)
+}
@@ -0,0 +1,210 @@
+package reqbuilder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// WithMaxResponseHeaderBytes limits the size of response headers; larger responses
+// fail with "server response headers exceeded n bytes". n must be positive.
+func WithMaxResponseHeaderBytes(n int64) Option {
+	return func(b *Builder) {
+		b.require.Positivef(n, "max response header bytes must be positive, got %d", n)
+		b.ownTransport().MaxResponseHeaderBytes = n
+	}
+}
+
+// WithReadBufferSize sets the size of the buffer responses are read through. n must be positive.
+func WithReadBufferSize(n int) Option {
+	return func(b *Builder) {
+		b.require.Positivef(n, "read buffer size must be positive, got %d", n)
+		b.ownTransport().ReadBufferSize = n
+	}
+}
+
+// WithWriteBufferSize sets the size of the buffer requests are written through. n must be positive.
+func WithWriteBufferSize(n int) Option {
+	return func(b *Builder) {
+		b.require.Positivef(n, "write buffer size must be positive, got %d", n)
+		b.ownTransport().WriteBufferSize = n
+	}
+}
+
+// WithMaxIdleConns limits the idle connections kept across all hosts; 0 means no limit.
+func WithMaxIdleConns(n int) Option {
+	return func(b *Builder) {
+		b.require.GreaterOrEqualf(n, 0, "max idle connections must not be negative, got %d", n)
+		b.ownTransport().MaxIdleConns = n
+	}
+}
+
+// WithMaxIdleConnsPerHost limits the idle connections kept per host. n must be positive.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(b *Builder) {
+		b.require.Positivef(n, "max idle connections per host must be positive, got %d", n)
+		b.ownTransport().MaxIdleConnsPerHost = n
+	}
+}
+
+// WithMaxConnsPerHost caps the connections, dialing, active and idle, open to a single
+// host; further requests wait for a connection to become free. 0 means no limit.
+func WithMaxConnsPerHost(n int) Option {
+	return func(b *Builder) {
+		b.require.GreaterOrEqualf(n, 0, "max connections per host must not be negative, got %d", n)
+		b.ownTransport().MaxConnsPerHost = n
+	}
+}
+
+// WithIdleConnTimeout closes connections that stay idle longer than d; 0 means no limit.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(b *Builder) {
+		b.require.GreaterOrEqualf(d, time.Duration(0), "idle connection timeout must not be negative, got %s", d)
+		b.ownTransport().IdleConnTimeout = d
+	}
+}
+
+// CloseIdleConnections closes the idle connections of the Builder's transport. Builders
+// also do this when a test that sent requests through them finishes.
+func (b *Builder) CloseIdleConnections() {
+	b.transport.CloseIdleConnections()
+}
+
+// cleanupKey identifies a transport used by a test.
+type cleanupKey struct {
+	t         *testing.T
+	transport *http.Transport
+}
+
+// closeIdleOnCleanup closes the transport's idle connections and the Builder's idle zstd
+// decoders when t finishes, once per test and transport.
+func (b *Builder) closeIdleOnCleanup(t *testing.T) {
+	key := cleanupKey{t: t, transport: b.transport}
+	if _, registered := b.cleanups.LoadOrStore(key, struct{}{}); registered {
+		return
+	}
+
+	t.Cleanup(func() {
+		key.transport.CloseIdleConnections()
+		b.zstd.close()
+		b.cleanups.Delete(key)
+	})
+}
+
+// Warmup opens up to n pooled connections to host by sending n concurrent HEAD
+// requests, so that later requests do not pay for dialing and TLS handshakes. Any
+// response status counts; only transport errors fail the test. The transport keeps at
+// most WithMaxIdleConnsPerHost idle connections, 2 by default. n must be positive.
+func (b *Builder) Warmup(t *testing.T, ctx context.Context, host string, n int) {
+	t.Helper()
+
+	b.require.Positivef(n, "warmup connection count must be positive, got %d", n)
+
+	b.closeIdleOnCleanup(t)
+
+	reqs := make([]*http.Request, n)
+	for i := range reqs {
+		req, err := newRequest(ctx, http.MethodHead, host, nil)
+		if err != nil {
+			b.logError(t, err)
+		}
+		b.require.NoError(err)
+		reqs[i] = b.prepare(t, req)
+	}
+
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			response, err := b.send(req)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		b.logError(t, err)
+		b.require.NoError(err, "warming up %s", host)
+	}
+}
+
+// PhaseTimeouts bounds the phases of a request separately; zero fields leave the
+// transport's setting unchanged.
+type PhaseTimeouts struct {
+	// Dial bounds establishing the TCP (or Unix socket) connection.
+	Dial time.Duration
+	// TLSHandshake bounds the TLS handshake.
+	TLSHandshake time.Duration
+	// ResponseHeader bounds the wait for response headers after the request is written.
+	ResponseHeader time.Duration
+	// IdleConn closes pooled connections idle for longer.
+	IdleConn time.Duration
+}
+
+// WithTimeoutPerPhase applies the non-zero timeouts of p, see PhaseTimeouts. Unlike a
+// client timeout they leave slow response bodies alone.
+func WithTimeoutPerPhase(p PhaseTimeouts) Option {
+	return func(b *Builder) {
+		for _, opt := range []struct {
+			d   time.Duration
+			set func(time.Duration) Option
+		}{
+			{p.Dial, WithDialTimeout},
+			{p.TLSHandshake, WithTLSHandshakeTimeout},
+			{p.ResponseHeader, WithResponseHeaderTimeout},
+			{p.IdleConn, WithIdleConnTimeout},
+		} {
+			if opt.d != 0 {
+				opt.set(opt.d)(b)
+			}
+		}
+	}
+}
+
+// WithDialTimeout fails requests whose connection is not established within d. It
+// bounds the dialer set up by New, WithLocalAddr or WithUnixSocket, so apply it after
+// those. d must be positive.
+func WithDialTimeout(d time.Duration) Option {
+	return func(b *Builder) {
+		b.require.Positivef(d, "dial timeout must be positive, got %s", d)
+		transport := b.ownTransport()
+		dial := transport.DialContext
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			return dial(ctx, network, addr)
+		}
+	}
+}
+
+// WithTLSHandshakeTimeout fails requests whose TLS handshake takes longer than d; 0
+// means no limit.
+func WithTLSHandshakeTimeout(d time.Duration) Option {
+	return func(b *Builder) {
+		b.require.GreaterOrEqualf(d, time.Duration(0), "TLS handshake timeout must not be negative, got %s", d)
+		b.ownTransport().TLSHandshakeTimeout = d
+	}
+}
+
+// WithResponseHeaderTimeout fails requests whose response headers do not arrive within
+// d of writing the request, with "timeout awaiting response headers"; 0 means no limit.
+func WithResponseHeaderTimeout(d time.Duration) Option {
+	return func(b *Builder) {
+		b.require.GreaterOrEqualf(d, time.Duration(0), "response header timeout must not be negative, got %s", d)
+		b.ownTransport().ResponseHeaderTimeout = d
+	}
+}
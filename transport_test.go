@@ -0,0 +1,192 @@
+package reqbuilder
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxResponseHeaderBytesHeaderBomb(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		cookie := strings.Repeat("x", 4000)
+		for i := range 2000 { // about 8MB of Set-Cookie headers
+			w.Header().Add("Set-Cookie", fmt.Sprintf("c%d=%s", i, cookie))
+		}
+	})
+
+	msg := failure(t, []Option{WithMaxResponseHeaderBytes(64 << 10)}, func(b *Builder) {
+		b.Send(t, context.Background(), Get(server.URL))
+	})
+	require.Contains(t, msg, "server response headers exceeded 65536 bytes")
+}
+
+func TestTransportOptions(t *testing.T) {
+	b := New(require.New(t),
+		WithReadBufferSize(8<<10),
+		WithWriteBufferSize(16<<10),
+		WithMaxIdleConns(50),
+		WithMaxIdleConnsPerHost(10),
+		WithIdleConnTimeout(30*time.Second),
+		WithMaxResponseHeaderBytes(1<<20),
+	)
+
+	transport := b.transport
+	require.Equal(t, 8<<10, transport.ReadBufferSize)
+	require.Equal(t, 16<<10, transport.WriteBufferSize)
+	require.Equal(t, 50, transport.MaxIdleConns)
+	require.Equal(t, 10, transport.MaxIdleConnsPerHost)
+	require.Equal(t, 30*time.Second, transport.IdleConnTimeout)
+	require.EqualValues(t, 1<<20, transport.MaxResponseHeaderBytes)
+
+	require.Zero(t, New(require.New(t)).transport.ReadBufferSize, "options must not leak into other Builders")
+}
+
+func TestTransportOptionValidation(t *testing.T) {
+	for want, opt := range map[string]Option{
+		"max response header bytes must be positive, got 0":     WithMaxResponseHeaderBytes(0),
+		"read buffer size must be positive, got -1":             WithReadBufferSize(-1),
+		"write buffer size must be positive, got 0":             WithWriteBufferSize(0),
+		"max idle connections must not be negative, got -1":     WithMaxIdleConns(-1),
+		"max idle connections per host must be positive, got 0": WithMaxIdleConnsPerHost(0),
+		"idle connection timeout must not be negative, got -1s": WithIdleConnTimeout(-time.Second),
+	} {
+		msg := failure(t, nil, func(b *Builder) {
+			b.With(opt)
+		})
+		require.Contains(t, msg, want)
+	}
+}
+
+func TestCloseIdleConnectionsOnCleanup(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	b := New(require.New(t))
+
+	var response *http.Response
+	t.Run("sub", func(t *testing.T) {
+		response, _ = b.forTest(t).Send(t, context.Background(), Get(server.URL))
+		response.Body.Close()
+
+		closed, ok := Wrap(response).ConnClosed()
+		require.True(t, ok)
+		require.False(t, closed, "the connection is pooled while the test runs")
+	})
+
+	require.Eventually(t, func() bool {
+		closed, _ := Wrap(response).ConnClosed()
+		return closed
+	}, time.Second, 10*time.Millisecond, "the idle connection is closed when the test finishes")
+}
+
+func TestWithMaxConnsPerHostQueues(t *testing.T) {
+	var active, peak atomic.Int32
+	release := make(chan struct{})
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		<-release
+		active.Add(-1)
+	})
+	unblock := sync.OnceFunc(func() { close(release) })
+	t.Cleanup(unblock)
+
+	b := New(require.New(t), WithMaxConnsPerHost(2))
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, _ := b.Send(t, context.Background(), Get(server.URL))
+			response.Body.Close()
+		}()
+	}
+
+	require.Eventually(t, func() bool { return active.Load() == 2 }, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.EqualValues(t, 2, active.Load(), "requests beyond the cap must queue")
+	require.Equal(t, 2, b.ConnStats().Opened)
+
+	unblock()
+	wg.Wait()
+
+	require.EqualValues(t, 2, peak.Load())
+	require.Equal(t, 2, b.ConnStats().Opened, "queued requests must reuse the capped connections")
+}
+
+func TestWarmup(t *testing.T) {
+	var methods []string
+	var mu sync.Mutex
+	server, b := newTLSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method)
+		mu.Unlock()
+	})
+
+	b.Warmup(t, context.Background(), server.URL, 2)
+
+	require.Equal(t, []string{http.MethodHead, http.MethodHead}, methods)
+	opened := b.ConnStats().Opened
+	require.Positive(t, opened)
+
+	response, _ := b.Send(t, context.Background(), Get(server.URL))
+	b.ExpectConnectionReused(t, response)
+	require.Equal(t, opened, b.ConnStats().Opened, "the request after warmup must not dial")
+}
+
+func TestWarmupInvalidCount(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		msg := failure(t, nil, func(b *Builder) {
+			b.Warmup(t, context.Background(), "http://127.0.0.1:1", n)
+		})
+		require.Contains(t, msg, "warmup connection count must be positive")
+	}
+}
+
+func TestWithResponseHeaderTimeout(t *testing.T) {
+	url, gone := slowServer(t, 5*time.Second)
+
+	start := time.Now()
+	msg := failure(t, []Option{WithTimeoutPerPhase(PhaseTimeouts{ResponseHeader: 50 * time.Millisecond})}, func(b *Builder) {
+		b.Send(t, context.Background(), Get(url))
+	})
+
+	require.Contains(t, msg, "timeout awaiting response headers")
+	require.Less(t, time.Since(start), 2*time.Second)
+	require.True(t, <-gone, "the server must see the client give up")
+}
+
+func TestWithTLSHandshakeTimeout(t *testing.T) {
+	// A listener that accepts connections but never answers the ClientHello.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+
+	msg := failure(t, []Option{WithTLSHandshakeTimeout(50 * time.Millisecond)}, func(b *Builder) {
+		b.Send(t, context.Background(), Get("https://"+listener.Addr().String()))
+	})
+	require.Contains(t, msg, "TLS handshake timeout")
+}
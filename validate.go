@@ -0,0 +1,43 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// WithResponseValidator checks every response with validate right after it is received
+// and fails the test with the response described when it returns an error. Validators
+// accumulate, so cross-cutting invariants such as "no 5xx" or "every response carries
+// X-Trace-Id" can be declared once on the Builder. A validator may read the body it is
+// given; the caller still receives the full body.
+func WithResponseValidator(validate func(*http.Response) error) Option {
+	return func(b *Builder) {
+		b.validators = append(b.validators[:len(b.validators):len(b.validators)], validate)
+	}
+}
+
+// validate runs the Builder's response validators.
+func (b *Builder) validate(t *testing.T, response *http.Response) {
+	t.Helper()
+
+	if len(b.validators) == 0 {
+		return
+	}
+
+	raw, err := bufferBody(response)
+	if err != nil {
+		b.logError(t, err)
+	}
+	b.require.NoError(err)
+
+	for _, validate := range b.validators {
+		copied := *response
+		copied.Body = io.NopCloser(bytes.NewReader(raw))
+
+		if err := validate(&copied); err != nil {
+			b.require.Failf("response validation failed", "%v\n%s", err, b.describe(response))
+		}
+	}
+}
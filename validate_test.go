@@ -0,0 +1,75 @@
+package reqbuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func rejectServerErrors(response *http.Response) error {
+	if response.StatusCode >= 500 {
+		return fmt.Errorf("server error %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+func TestWithResponseValidator(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		w.Header().Set("X-Trace-Id", "t-1")
+		w.Write([]byte("payload"))
+	})
+	ctx := context.Background()
+
+	var validated []string
+	b := New(require.New(t), WithResponseValidator(rejectServerErrors), WithResponseValidator(func(r *http.Response) error {
+		body, err := io.ReadAll(r.Body)
+		validated = append(validated, string(body))
+		return err
+	}))
+
+	response, _ := b.Send(t, ctx, Get(server.URL+"/ok"))
+	require.Equal(t, []string{"payload"}, validated)
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.Equal(t, "payload", string(body), "validators must not consume the body")
+
+	msg := failure(t, []Option{WithResponseValidator(rejectServerErrors)}, func(b *Builder) {
+		b.Send(t, ctx, Get(server.URL+"/broken"))
+	})
+	require.Contains(t, msg, "response validation failed")
+	require.Contains(t, msg, "server error 502")
+	require.Contains(t, msg, "-> 502 Bad Gateway")
+}
+
+func TestWithResponseValidatorPerRequest(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	calls := 0
+	count := func(*http.Response) error {
+		calls++
+		return nil
+	}
+
+	b := New(require.New(t), WithResponseValidator(count))
+	b.RequestWithoutBody(t, context.Background(), http.MethodGet, server.URL, "/", nil, nil, "",
+		WithResponseValidator(count))
+	require.Equal(t, 2, calls, "per-request validators add to the Builder's")
+
+	b.RequestWithoutBody(t, context.Background(), http.MethodGet, server.URL, "/", nil, nil, "")
+	require.Equal(t, 3, calls)
+
+	msg := failure(t, []Option{WithResponseValidator(func(*http.Response) error { return errors.New("no trace id") })}, func(b *Builder) {
+		b.RequestWithoutBody(t, context.Background(), http.MethodGet, server.URL, "/", nil, nil, "")
+	})
+	require.Contains(t, msg, "no trace id")
+}
@@ -0,0 +1,224 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// SignatureScheme describes how a webhook sender signs its requests.
+type SignatureScheme struct {
+	// Header carries the signature.
+	Header string
+	// Tolerance is how far the signed timestamp may be from the time of verification,
+	// for schemes that sign one. Older deliveries are rejected as replays.
+	Tolerance time.Duration
+
+	format signatureFormat
+}
+
+type signatureFormat int
+
+const (
+	githubFormat signatureFormat = iota
+	stripeFormat
+)
+
+var (
+	// GitHubSignature is "X-Hub-Signature-256: sha256=<hex HMAC-SHA256 of the body>".
+	GitHubSignature = SignatureScheme{Header: "X-Hub-Signature-256", format: githubFormat}
+	// StripeSignature is "Stripe-Signature: t=<unix time>,v1=<hex HMAC-SHA256 of
+	// "<t>.<body>">", accepted within five minutes of t.
+	StripeSignature = SignatureScheme{Header: "Stripe-Signature", Tolerance: 5 * time.Minute, format: stripeFormat}
+)
+
+// SignWebhook returns the signature header value for body signed with secret at
+// timestamp, for sending webhooks to the service under test.
+func SignWebhook(scheme SignatureScheme, secret, body []byte, timestamp time.Time) string {
+	switch scheme.format {
+	case stripeFormat:
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		return "t=" + ts + ",v1=" + hmacHex(secret, []byte(ts+"."), body)
+	default:
+		return "sha256=" + hmacHex(secret, body)
+	}
+}
+
+// VerifyWebhookSignature fails the test unless req carries a valid signature of body
+// for secret. Signatures are compared in constant time; timestamps outside the
+// scheme's tolerance fail as replays.
+func (b *Builder) VerifyWebhookSignature(t *testing.T, req *http.Request, body []byte, scheme SignatureScheme, secret []byte) {
+	t.Helper()
+
+	if err := verifySignature(req.Header, body, scheme, secret, b.clock.Now()); err != nil {
+		b.require.Failf("invalid webhook signature", "%s %s: %v\n%s", req.Method, req.URL, err, dumpHeader(req.Header))
+	}
+}
+
+// verifySignature checks the signature in header as of now.
+func verifySignature(header http.Header, body []byte, scheme SignatureScheme, secret []byte, now time.Time) error {
+	value := header.Get(scheme.Header)
+	if value == "" {
+		return fmt.Errorf("no %s header", scheme.Header)
+	}
+
+	switch scheme.format {
+	case stripeFormat:
+		return verifyStripe(value, body, scheme.Tolerance, secret, now)
+	default:
+		signature, ok := strings.CutPrefix(value, "sha256=")
+		if !ok {
+			return fmt.Errorf("%s: expected sha256=<hex>, got %q", scheme.Header, value)
+		}
+		if !hmacEqual(signature, hmacHex(secret, body)) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	}
+}
+
+func verifyStripe(value string, body []byte, tolerance time.Duration, secret []byte, now time.Time) error {
+	var ts string
+	var signatures []string
+	for _, item := range strings.Split(value, ",") {
+		key, v, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch key {
+		case "t":
+			ts = v
+		case "v1":
+			signatures = append(signatures, v)
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", ts)
+	}
+	if len(signatures) == 0 {
+		return errors.New("no v1 signature")
+	}
+
+	expected := hmacHex(secret, []byte(ts+"."), body)
+	valid := false
+	for _, signature := range signatures {
+		valid = hmacEqual(signature, expected) || valid
+	}
+	if !valid {
+		return errors.New("signature mismatch")
+	}
+
+	if age := now.Sub(time.Unix(unix, 0)); tolerance > 0 && (age > tolerance || age < -tolerance) {
+		return fmt.Errorf("timestamp %s is %s from now, outside the %s tolerance", ts, age.Round(time.Second), tolerance)
+	}
+
+	return nil
+}
+
+// hmacHex returns the hex HMAC-SHA256 of the concatenated parts.
+func hmacHex(secret []byte, parts ...[]byte) string {
+	mac := hmac.New(sha256.New, secret)
+	for _, part := range parts {
+		mac.Write(part)
+	}
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hmacEqual compares hex signatures in constant time.
+func hmacEqual(got, want string) bool {
+	return hmac.Equal([]byte(strings.ToLower(got)), []byte(want))
+}
+
+// CapturedRequest is a request received by a CaptureServer.
+type CapturedRequest struct {
+	Request  *http.Request
+	Body     []byte
+	Received time.Time
+}
+
+// SignatureError returns why the request does not carry a valid signature of its body,
+// judging the timestamp as of when it was received, or nil.
+func (c CapturedRequest) SignatureError(scheme SignatureScheme, secret []byte) error {
+	return verifySignature(c.Request.Header, c.Body, scheme, secret, c.Received)
+}
+
+// CaptureServer is a test server that records the requests it receives, such as
+// webhooks sent by the service under test, and answers them with Status.
+type CaptureServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	requests []CapturedRequest
+	received chan struct{}
+}
+
+// NewCaptureServer starts a CaptureServer answering 200 OK, closed when t finishes.
+func NewCaptureServer(t *testing.T) *CaptureServer {
+	s := &CaptureServer{status: http.StatusOK, received: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Respond sets the status returned for later requests, for testing sender retries.
+func (s *CaptureServer) Respond(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = status
+}
+
+func (s *CaptureServer) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	s.mu.Lock()
+	s.requests = append(s.requests, CapturedRequest{Request: r, Body: body, Received: time.Now()})
+	status := s.status
+	close(s.received)
+	s.received = make(chan struct{})
+	s.mu.Unlock()
+
+	w.WriteHeader(status)
+}
+
+// Requests returns the requests received so far.
+func (s *CaptureServer) Requests() []CapturedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]CapturedRequest(nil), s.requests...)
+}
+
+// WaitForRequests waits until at least n requests were received and returns them.
+func (s *CaptureServer) WaitForRequests(ctx context.Context, n int) ([]CapturedRequest, error) {
+	for {
+		s.mu.Lock()
+		requests, received := append([]CapturedRequest(nil), s.requests...), s.received
+		s.mu.Unlock()
+
+		if len(requests) >= n {
+			return requests, nil
+		}
+
+		select {
+		case <-received:
+		case <-ctx.Done():
+			return requests, fmt.Errorf("received %d of %d requests: %w", len(requests), n, ctx.Err())
+		}
+	}
+}
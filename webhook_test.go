@@ -0,0 +1,65 @@
+package reqbuilder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSignatures(t *testing.T) {
+	secret := []byte("whsec_test")
+	body := []byte(`{"event":"invoice.paid","id":"evt_1"}`)
+	capture := NewCaptureServer(t)
+	b := New(require.New(t))
+
+	for _, scheme := range []SignatureScheme{GitHubSignature, StripeSignature} {
+		t.Run(scheme.Header, func(t *testing.T) {
+			signature := SignWebhook(scheme, secret, body, time.Now())
+			b.Send(t, context.Background(), Post(capture.URL+"/hooks").BodyBytes("application/json", body).SetHeader(scheme.Header, signature))
+
+			requests := capture.Requests()
+			delivery := requests[len(requests)-1]
+			require.Equal(t, body, delivery.Body)
+			require.NoError(t, delivery.SignatureError(scheme, secret))
+			b.VerifyWebhookSignature(t, delivery.Request, delivery.Body, scheme, secret)
+
+			require.EqualError(t, delivery.SignatureError(scheme, []byte("other")), "signature mismatch")
+			tampered := CapturedRequest{Request: delivery.Request, Body: []byte(`{"event":"invoice.paid","id":"evt_2"}`), Received: delivery.Received}
+			require.EqualError(t, tampered.SignatureError(scheme, secret), "signature mismatch")
+
+			msg := failure(t, nil, func(b *Builder) {
+				b.VerifyWebhookSignature(t, delivery.Request, delivery.Body, scheme, []byte("other"))
+			})
+			require.Contains(t, msg, "POST /hooks: signature mismatch")
+		})
+	}
+
+	requests, err := capture.WaitForRequests(context.Background(), 2)
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+}
+
+func TestWebhookSignatureReplay(t *testing.T) {
+	secret := []byte("whsec_test")
+	body := []byte(`{}`)
+	signed := time.Unix(1_700_000_000, 0)
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/hooks", nil)
+	require.NoError(t, err)
+	req.Header.Set("Stripe-Signature", SignWebhook(StripeSignature, secret, body, signed))
+
+	clock := NewFakeClock(signed.Add(4 * time.Minute))
+	New(require.New(t), WithClock(clock)).VerifyWebhookSignature(t, req, body, StripeSignature, secret)
+
+	clock.Advance(2 * time.Minute)
+	msg := failure(t, []Option{WithClock(clock)}, func(b *Builder) {
+		b.VerifyWebhookSignature(t, req, body, StripeSignature, secret)
+	})
+	require.Contains(t, msg, "timestamp 1700000000 is 6m0s from now, outside the 5m0s tolerance")
+
+	req.Header.Set("Stripe-Signature", "t=1700000000")
+	require.EqualError(t, verifySignature(req.Header, body, StripeSignature, secret, signed), "no v1 signature")
+}
@@ -0,0 +1,93 @@
+package reqbuilder
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdPool reuses zstd decoders across responses. Its decoders decode synchronously,
+// so idle ones hold buffers but no goroutines; close releases them at test cleanup.
+type zstdPool struct {
+	mu   sync.Mutex
+	free []*zstd.Decoder
+}
+
+// sharedZstd decodes zstd for readers that belong to no Builder, such as PartBody.
+var sharedZstd = &zstdPool{}
+
+// decoder is decodingReader with zstd decoded by one of the pool's decoders.
+func (p *zstdPool) decoder(encoding string, r io.Reader) (io.ReadCloser, error) {
+	if encoding == "zstd" {
+		return p.reader(r)
+	}
+
+	return decodingReader(encoding, r)
+}
+
+// reader returns a pooled decoder reading r, which goes back to the pool on Close.
+func (p *zstdPool) reader(r io.Reader) (io.ReadCloser, error) {
+	p.mu.Lock()
+	var decoder *zstd.Decoder
+	if n := len(p.free); n != 0 {
+		decoder, p.free = p.free[n-1], p.free[:n-1]
+	}
+	p.mu.Unlock()
+
+	if decoder == nil {
+		var err error
+		decoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := decoder.Reset(r); err != nil {
+		decoder.Close()
+		return nil, err
+	}
+
+	return &pooledZstdReader{decoder: decoder, pool: p}, nil
+}
+
+// close closes the idle decoders.
+func (p *zstdPool) close() {
+	p.mu.Lock()
+	free := p.free
+	p.free = nil
+	p.mu.Unlock()
+
+	for _, decoder := range free {
+		decoder.Close()
+	}
+}
+
+// pooledZstdReader is a decoder borrowed from a zstdPool.
+type pooledZstdReader struct {
+	decoder *zstd.Decoder
+	pool    *zstdPool
+}
+
+func (r *pooledZstdReader) Read(p []byte) (int, error) {
+	if r.decoder == nil {
+		return 0, zstd.ErrDecoderClosed
+	}
+
+	return r.decoder.Read(p)
+}
+
+// Close detaches the decoder from its input and returns it to the pool.
+func (r *pooledZstdReader) Close() error {
+	if r.decoder == nil {
+		return nil
+	}
+
+	r.decoder.Reset(nil)
+	r.pool.mu.Lock()
+	r.pool.free = append(r.pool.free, r.decoder)
+	r.pool.mu.Unlock()
+	r.decoder = nil
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package reqbuilder
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func zstdBody(tb testing.TB) (content, encoded []byte) {
+	content = bytes.Repeat([]byte(`{"id":1,"name":"sensor"}`), 200)
+	encoded, err := EncodeZstd(content)
+	require.NoError(tb, err)
+
+	return content, encoded
+}
+
+func TestZstdDecodersReleased(t *testing.T) {
+	content, encoded := zstdBody(t)
+	base := runtime.NumGoroutine()
+
+	b := New(require.New(t))
+	t.Run("decode", func(t *testing.T) {
+		server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "zstd")
+			w.Write(encoded)
+		})
+
+		for range 1000 {
+			response, _ := b.Send(t, context.Background(), Get(server.URL).SetHeader("Accept-Encoding", "zstd"))
+			body, err := b.ReadResponseBody(response)
+			require.NoError(t, err)
+			require.Equal(t, content, body)
+
+			part, err := decodingReader("zstd", bytes.NewReader(encoded))
+			require.NoError(t, err)
+			body, err = io.ReadAll(part)
+			require.NoError(t, err)
+			require.Equal(t, content, body)
+			part.Close()
+		}
+
+		b.zstd.mu.Lock()
+		defer b.zstd.mu.Unlock()
+		require.Len(t, b.zstd.free, 1, "sequential responses reuse one decoder")
+	})
+
+	require.Empty(t, b.zstd.free, "test cleanup closes the idle decoders")
+	// Connection goroutines of the closed transport may still be winding down.
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > base && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.LessOrEqual(t, runtime.NumGoroutine(), base, "goroutines left after 1000 decodes")
+}
+
+func TestPooledZstdReaderClose(t *testing.T) {
+	content, encoded := zstdBody(t)
+	pool := &zstdPool{}
+
+	reader, err := pool.reader(bytes.NewReader(encoded))
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, content, body)
+
+	require.NoError(t, reader.Close())
+	require.NoError(t, reader.Close(), "closing twice is a no-op")
+	require.Len(t, pool.free, 1, "the decoder goes back to the pool once")
+
+	_, err = reader.Read(make([]byte, 1))
+	require.ErrorIs(t, err, zstd.ErrDecoderClosed)
+}
+
+// BenchmarkZstdDecode compares a new decoder per response, as before pooling, with the
+// Builder's pool.
+func BenchmarkZstdDecode(b *testing.B) {
+	_, encoded := zstdBody(b)
+
+	b.Run("NewReader", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			decoder, err := zstd.NewReader(bytes.NewReader(encoded))
+			if err != nil {
+				b.Fatal(err)
+			}
+			io.Copy(io.Discard, decoder)
+			decoder.Close()
+		}
+	})
+
+	b.Run("Pool", func(b *testing.B) {
+		pool := &zstdPool{}
+		defer pool.close()
+
+		b.ReportAllocs()
+		for range b.N {
+			reader, err := pool.reader(bytes.NewReader(encoded))
+			if err != nil {
+				b.Fatal(err)
+			}
+			io.Copy(io.Discard, reader)
+			reader.Close()
+		}
+	})
+}